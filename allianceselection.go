@@ -0,0 +1,539 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+
+	"github.com/a-h/templ"
+)
+
+// Alliance selection follows the FRC serpentine draft: captains are the
+// highest-ranked teams not yet on an alliance, round 1 picks run 1→N and
+// round 2 runs N→1. A team that declines an invitation cannot be picked
+// later but may still become a captain.
+
+const (
+	selectionPick    = "pick"
+	selectionDecline = "decline"
+)
+
+type selectionAction struct {
+	Seq    int // 0 for a predicted action that isn't recorded
+	Action string
+	Team   string
+}
+
+// errSelectionConflict means someone else recorded an action after the
+// board the caller acted on.
+var errSelectionConflict = errors.New("alliance selection was changed by someone else")
+
+type selectionState struct {
+	Alliances  [][]string // captain first
+	OnClock    int        // alliance index whose turn it is, -1 when done
+	Round      int        // 1 or 2
+	Declined   map[string]bool
+	onAlliance map[string]bool
+	rankings   []string
+	turn       int
+	seq        int // last recorded action's, 0 before the first
+}
+
+// allianceCount is 8 for a normal event, fewer for tiny test or offseason fields.
+func allianceCount(teams int) int {
+	n := teams / 3
+	if n > 8 {
+		n = 8
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func newSelectionState(rankings []string) *selectionState {
+	s := &selectionState{
+		Alliances:  make([][]string, allianceCount(len(rankings))),
+		Declined:   map[string]bool{},
+		onAlliance: map[string]bool{},
+		rankings:   rankings,
+	}
+	s.startTurn()
+	return s
+}
+
+// startTurn finds whose turn it is and seats that alliance's captain if it
+// does not have one yet.
+func (s *selectionState) startTurn() {
+	n := len(s.Alliances)
+	if s.turn >= 2*n {
+		s.OnClock = -1
+		return
+	}
+	s.Round = s.turn/n + 1
+	if s.Round == 1 {
+		s.OnClock = s.turn
+	} else {
+		s.OnClock = 2*n - 1 - s.turn
+	}
+
+	if len(s.Alliances[s.OnClock]) == 0 {
+		for _, t := range s.rankings {
+			if !s.onAlliance[t] {
+				s.Alliances[s.OnClock] = []string{t}
+				s.onAlliance[t] = true
+				break
+			}
+		}
+	}
+}
+
+func (s *selectionState) clone() *selectionState {
+	c := *s
+	c.Alliances = make([][]string, len(s.Alliances))
+	for i, a := range s.Alliances {
+		c.Alliances[i] = append([]string(nil), a...)
+	}
+	c.Declined = map[string]bool{}
+	for t := range s.Declined {
+		c.Declined[t] = true
+	}
+	c.onAlliance = map[string]bool{}
+	for t := range s.onAlliance {
+		c.onAlliance[t] = true
+	}
+	return &c
+}
+
+// available reports whether a team can still be invited.
+func (s *selectionState) available(team string) bool {
+	return !s.onAlliance[team] && !s.Declined[team]
+}
+
+func (s *selectionState) apply(a selectionAction) error {
+	if s.OnClock < 0 {
+		return errors.New("alliance selection is already complete")
+	}
+	if !s.available(a.Team) {
+		return fmt.Errorf("team %s is not available", a.Team)
+	}
+	switch a.Action {
+	case selectionPick:
+		s.Alliances[s.OnClock] = append(s.Alliances[s.OnClock], a.Team)
+		s.onAlliance[a.Team] = true
+		s.turn++
+		s.startTurn()
+	case selectionDecline:
+		s.Declined[a.Team] = true
+	default:
+		return fmt.Errorf("unknown action %q", a.Action)
+	}
+	return nil
+}
+
+// remaining lists teams that can still be picked, in ranking order.
+func (s *selectionState) remaining() []string {
+	var teams []string
+	for _, t := range s.rankings {
+		if s.available(t) {
+			teams = append(teams, t)
+		}
+	}
+	return teams
+}
+
+func (s *selectionState) allianceOf(team string) int {
+	for i, a := range s.Alliances {
+		for _, t := range a {
+			if t == team {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func loadSelectionActions(ctx context.Context, eventKey string) ([]selectionAction, error) {
+	recorded, err := repo.Selection.Actions(ctx, eventKey)
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]selectionAction, len(recorded))
+	for i, a := range recorded {
+		actions[i] = selectionAction{Seq: a.Seq, Action: a.Action, Team: a.TeamNumber}
+	}
+	return actions, nil
+}
+
+// replaySelection rebuilds the draft from TBA rankings and recorded actions.
+func replaySelection(ctx context.Context, eventKey string) (*selectionState, error) {
+	rankings, err := getRankingsCached(ctx, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rankings: %w", err)
+	}
+	if len(rankings) == 0 {
+		return nil, errors.New("TBA has no rankings for this event yet")
+	}
+	actions, err := loadSelectionActions(ctx, eventKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newSelectionState(rankings)
+	for _, a := range actions {
+		if err := s.apply(a); err != nil {
+			return nil, fmt.Errorf("recorded %s of %s no longer fits the rankings: %w", a.Action, a.Team, err)
+		}
+		s.seq = a.Seq
+	}
+	return s, nil
+}
+
+// syncPickListWithSelection marks every team now on an alliance as picked in
+// the event's pick list, and clears the marks it made earlier for teams an
+// undo or reset took off again. Teams a strategist marked by hand are left
+// alone. A new revision is saved only if something changed.
+func syncPickListWithSelection(ctx context.Context, eventKey string, s *selectionState) error {
+	pl, err := loadPickList(ctx, eventKey, 0)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil // no pick list to keep in sync
+	}
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i, e := range pl.Entries {
+		switch {
+		case s.onAlliance[e.Team] && e.Status != pickStatusPicked:
+			pl.Entries[i].Status = pickStatusPicked
+			pl.Entries[i].SelectionPicked = true
+			changed = true
+		case !s.onAlliance[e.Team] && e.SelectionPicked:
+			pl.Entries[i].Status = pickStatusAvailable
+			pl.Entries[i].SelectionPicked = false
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	_, err = savePickList(ctx, eventKey, pl.Revision, pl.Entries, selectionPickListAuthor)
+	return err
+}
+
+// selectionPickListAuthor is who pick list revisions saved by
+// syncPickListWithSelection are credited to.
+const selectionPickListAuthor = "Alliance selection"
+
+// ── Recommendations ───────────────────────────────────────────────────────────
+
+// selectionProfile is what we know about a team's strength for drafting.
+type selectionProfile struct {
+	Scoring     float64 // 1-10
+	Reliability float64 // 1-10
+	Defense     float64 // 0-10
+	EPA         float64
+	HasAnalysis bool
+	HasEPA      bool
+}
+
+func selectionProfiles(ctx context.Context, eventKey string, teams []string) map[string]selectionProfile {
+	epa := fetchEPATotals(ctx, teams)
+	var maxEPA float64
+	for _, v := range epa {
+		if v > maxEPA {
+			maxEPA = v
+		}
+	}
+
+	profiles := map[string]selectionProfile{}
+	for _, t := range teams {
+		var p selectionProfile
+		if v, ok := epa[t]; ok {
+			p.EPA, p.HasEPA = v, true
+		}
+		if a, ok := cachedAnalysis(ctx, eventKey, t); ok {
+			p.HasAnalysis = true
+			p.Scoring = float64(a.Scoring)
+			p.Reliability = float64(a.Reliability)
+			p.Defense = float64(a.Defense)
+		} else if p.HasEPA && maxEPA > 0 {
+			// No scouting: use relative EPA as a stand-in for scoring and
+			// assume middling reliability.
+			p.Scoring = p.EPA / maxEPA * 10
+			p.Reliability = 5
+		}
+		profiles[t] = p
+	}
+	return profiles
+}
+
+// recommendPicks ranks the remaining teams for our alliance. Scoring counts
+// more when the alliance is light on offense, defense counts more when
+// nobody on the alliance defends yet, and our own pick list order breaks ties.
+func recommendPicks(ctx context.Context, eventKey string, s *selectionState, ourTeam string, profiles map[string]selectionProfile, limit int) []templates.SelectionRecommendation {
+	ours := []string{ourTeam}
+	if idx := s.allianceOf(ourTeam); idx >= 0 {
+		ours = s.Alliances[idx]
+	}
+
+	var scoringSum, bestDefense float64
+	for _, t := range ours {
+		p := profiles[t]
+		scoringSum += p.Scoring
+		if p.Defense > bestDefense {
+			bestDefense = p.Defense
+		}
+	}
+	scoringGap := 1 - scoringSum/float64(len(ours))/10 // 0 = already elite, 1 = nothing
+	needDefense := bestDefense < 6
+
+	listRank := map[string]int{}
+	dnp := map[string]bool{}
+	if pl, err := loadPickList(ctx, eventKey, 0); err == nil {
+		for i, e := range pl.Entries {
+			listRank[e.Team] = i + 1
+			if e.Status == pickStatusDNP {
+				dnp[e.Team] = true
+			}
+		}
+	}
+
+	var recs []templates.SelectionRecommendation
+	for _, t := range s.remaining() {
+		if t == ourTeam || dnp[t] {
+			continue
+		}
+		p := profiles[t]
+		score := p.Scoring*(1+scoringGap) + p.Reliability
+		var reasons []string
+		if needDefense {
+			score += p.Defense * 0.8
+			if p.Defense >= 6 {
+				reasons = append(reasons, "adds the defense we lack")
+			}
+		} else {
+			score += p.Defense * 0.2
+		}
+		if p.Scoring >= 7 {
+			reasons = append(reasons, "strong scorer")
+		}
+		if p.Reliability >= 8 {
+			reasons = append(reasons, "very reliable")
+		} else if p.HasAnalysis && p.Reliability <= 4 {
+			reasons = append(reasons, "reliability risk")
+		}
+		if r, ok := listRank[t]; ok {
+			score += 3 / float64(r)
+		}
+		if !p.HasAnalysis {
+			reasons = append(reasons, "no scouting analysis")
+		}
+		recs = append(recs, templates.SelectionRecommendation{
+			Team:     t,
+			Score:    score,
+			ListRank: listRank[t],
+			Reasons:  strings.Join(reasons, ", "),
+		})
+	}
+
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Score > recs[j].Score })
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+	return recs
+}
+
+// predictPicks plays the draft forward until our alliance is next on the
+// clock, assuming every other captain takes the strongest team by public
+// numbers (EPA first, then our scouting, then ranking).
+func predictPicks(s *selectionState, ourTeam string, profiles map[string]selectionProfile, limit int) []templates.SelectionPrediction {
+	sim := s.clone()
+
+	rankIndex := map[string]int{}
+	for i, t := range s.rankings {
+		rankIndex[t] = i
+	}
+	strength := func(t string) float64 {
+		p := profiles[t]
+		if p.HasEPA {
+			return 1000 + p.EPA
+		}
+		if p.HasAnalysis {
+			return 500 + p.Scoring*10 + p.Reliability
+		}
+		return float64(len(s.rankings) - rankIndex[t])
+	}
+
+	var predictions []templates.SelectionPrediction
+	for len(predictions) < limit && sim.OnClock >= 0 {
+		if sim.allianceOf(ourTeam) == sim.OnClock {
+			break
+		}
+		remaining := sim.remaining()
+		if len(remaining) == 0 {
+			break
+		}
+		sort.SliceStable(remaining, func(i, j int) bool { return strength(remaining[i]) > strength(remaining[j]) })
+		pick := remaining[0]
+		if pick == ourTeam && len(remaining) > 1 {
+			pick = remaining[1] // we will decline; plan around it
+		}
+		predictions = append(predictions, templates.SelectionPrediction{
+			Alliance: sim.OnClock + 1,
+			Captain:  sim.Alliances[sim.OnClock][0],
+			Team:     pick,
+		})
+		if err := sim.apply(selectionAction{Action: selectionPick, Team: pick}); err != nil {
+			log.Printf("alliance selection: predicting %s for alliance %d: %v", pick, sim.OnClock+1, err)
+			break
+		}
+	}
+	return predictions
+}
+
+// ── Handlers ──────────────────────────────────────────────────────────────────
+
+func allianceSelectionPageHandler(w http.ResponseWriter, r *http.Request) {
+	eventMap, err := currentEventMap(r.Context())
+	if err != nil {
+		http.Error(w, "Could not load events", 500)
+		return
+	}
+
+	data := templates.AllianceSelectionPageData{
+		Events:        eventMap,
+		SelectedEvent: r.URL.Query().Get("event_key"),
+		OurTeam:       r.URL.Query().Get("team_number"),
+	}
+	templ.Handler(templates.AllianceSelectionPage(data)).ServeHTTP(w, r)
+}
+
+func renderSelectionBoard(w http.ResponseWriter, r *http.Request, eventKey, ourTeam string) {
+	s, err := replaySelection(r.Context(), eventKey)
+	if err != nil {
+		templates.AllianceSelectionError(err.Error()).Render(r.Context(), w)
+		return
+	}
+
+	profiles := selectionProfiles(r.Context(), eventKey, s.rankings)
+	board := templates.AllianceSelectionBoard{
+		EventKey:  eventKey,
+		OurTeam:   ourTeam,
+		Seq:       s.seq,
+		OnClock:   s.OnClock + 1,
+		Round:     s.Round,
+		Remaining: s.remaining(),
+	}
+	for i, a := range s.Alliances {
+		board.Alliances = append(board.Alliances, templates.SelectionAlliance{Number: i + 1, Teams: a})
+	}
+	for t := range s.Declined {
+		board.Declined = append(board.Declined, t)
+	}
+	sort.Strings(board.Declined)
+	if ourTeam != "" {
+		board.Recommendations = recommendPicks(r.Context(), eventKey, s, ourTeam, profiles, 8)
+		board.Predictions = predictPicks(s, ourTeam, profiles, 8)
+	}
+	templates.AllianceSelectionBoardView(board).Render(r.Context(), w)
+}
+
+func apiAllianceSelectionBoardHandler(w http.ResponseWriter, r *http.Request) {
+	eventKey := r.FormValue("event_key")
+	if eventKey == "" || eventKey == "none" {
+		http.Error(w, "event_key required", http.StatusBadRequest)
+		return
+	}
+	renderSelectionBoard(w, r, eventKey, strings.TrimSpace(r.FormValue("team_number")))
+}
+
+// apiAllianceSelectionRecordHandler records a pick or decline for the
+// alliance on the clock, or undoes the last action. seq is the last action
+// on the caller's board; if someone else has recorded since, nothing
+// changes and the caller gets a 409.
+func apiAllianceSelectionRecordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	eventKey := r.FormValue("event_key")
+	action := r.FormValue("action")
+	team := strings.TrimSpace(strings.TrimPrefix(r.FormValue("team"), "frc"))
+	if eventKey == "" {
+		http.Error(w, "event_key required", http.StatusBadRequest)
+		return
+	}
+	seq, err := strconv.Atoi(r.FormValue("seq"))
+	if err != nil {
+		http.Error(w, "seq required", http.StatusBadRequest)
+		return
+	}
+
+	switch action {
+	case "undo", "reset":
+		if action == "reset" {
+			err = repo.Selection.Reset(r.Context(), eventKey, seq)
+		} else {
+			err = repo.Selection.Undo(r.Context(), eventKey, seq)
+		}
+		if errors.Is(err, store.ErrConflict) {
+			http.Error(w, errSelectionConflict.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("alliance selection: %v", err)
+			http.Error(w, "DB error", http.StatusInternalServerError)
+			return
+		}
+		// The board shows why if the draft can't be replayed.
+		if s, err := replaySelection(r.Context(), eventKey); err == nil {
+			if err := syncPickListWithSelection(r.Context(), eventKey, s); err != nil {
+				log.Printf("alliance selection: failed to update pick list for %s: %v", eventKey, err)
+			}
+		}
+	case selectionPick, selectionDecline:
+		s, err := replaySelection(r.Context(), eventKey)
+		if err != nil {
+			templates.AllianceSelectionError(err.Error()).Render(r.Context(), w)
+			return
+		}
+		if s.seq != seq {
+			http.Error(w, errSelectionConflict.Error(), http.StatusConflict)
+			return
+		}
+		if err := s.apply(selectionAction{Action: action, Team: team}); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// Append only lands if nothing was recorded since the replay, so s
+		// is still the draft it was checked against.
+		err = repo.Selection.Append(r.Context(), eventKey, seq, action, team)
+		if errors.Is(err, store.ErrConflict) {
+			http.Error(w, errSelectionConflict.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("alliance selection: %v", err)
+			http.Error(w, "DB error", http.StatusInternalServerError)
+			return
+		}
+		if err := syncPickListWithSelection(r.Context(), eventKey, s); err != nil {
+			log.Printf("alliance selection: failed to update pick list for %s: %v", eventKey, err)
+		}
+	default:
+		http.Error(w, "unknown action", http.StatusBadRequest)
+		return
+	}
+
+	renderSelectionBoard(w, r, eventKey, strings.TrimSpace(r.FormValue("team_number")))
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+)
+
+// Responses from TBA and Statbotics are kept in the api_cache table so a
+// restart doesn't refetch everything and an outage doesn't stop scouting.
+// Within maxAge a stored response is used as is; after that it's revalidated
+// with If-None-Match / If-Modified-Since, and if the upstream can't be reached
+// the stored copy is served anyway and the page gets a "last updated" banner.
+
+// errUpstreamNotFound is returned for a 404, e.g. a team with no EPA yet.
+var errUpstreamNotFound = errors.New("not found")
+
+// apiFetch is one revalidation of a URL in flight. Requests that arrive
+// meanwhile serve the stored copy, or wait for this if there isn't one.
+type apiFetch struct {
+	done chan struct{}
+	body []byte // the current body, if err is nil; both are set before done closes
+	err  error
+}
+
+var (
+	apiFetchesMu sync.Mutex
+	apiFetches   = map[string]*apiFetch{}
+)
+
+// startAPIFetch revalidates url in the background unless that's already
+// under way, and reports whether this call started it. The fetch doesn't use
+// the caller's context, so a closed page doesn't fail it for the others
+// waiting; outbound's timeouts still bound it.
+func startAPIFetch(ctx context.Context, url string, header http.Header, cached store.CachedResponse, haveCached bool, check func([]byte) error) (*apiFetch, bool) {
+	apiFetchesMu.Lock()
+	defer apiFetchesMu.Unlock()
+	if f, ok := apiFetches[url]; ok {
+		return f, false
+	}
+	f := &apiFetch{done: make(chan struct{})}
+	apiFetches[url] = f
+	go func() {
+		f.body, f.err = revalidate(context.WithoutCancel(ctx), url, header, cached, haveCached, check)
+		apiFetchesMu.Lock()
+		delete(apiFetches, url)
+		apiFetchesMu.Unlock()
+		close(f.done)
+	}()
+	return f, true
+}
+
+// waitForAPIFetch returns once any revalidation of url in flight is done.
+func waitForAPIFetch(url string) {
+	apiFetchesMu.Lock()
+	f := apiFetches[url]
+	apiFetchesMu.Unlock()
+	if f != nil {
+		<-f.done
+	}
+}
+
+// loadCachedResponse reports whether anything is stored for url.
+func loadCachedResponse(ctx context.Context, url string) (store.CachedResponse, bool, error) {
+	c, err := repo.APICache.Get(ctx, url)
+	if errors.Is(err, store.ErrNotFound) {
+		return store.CachedResponse{}, false, nil
+	}
+	if err != nil {
+		return store.CachedResponse{}, false, err
+	}
+	return c, true, nil
+}
+
+// cachedGet decodes the JSON at url into v, from api_cache when it's younger
+// than maxAge. source names the data in the stale banner, e.g. "Schedule".
+func cachedGet(ctx context.Context, url string, header http.Header, maxAge time.Duration, source string, v interface{}) error {
+	cached, haveCached, err := loadCachedResponse(ctx, url)
+	if err != nil {
+		return err
+	}
+	if haveCached && !cached.Expired && time.Since(cached.FetchedAt) < maxAge {
+		return json.Unmarshal(cached.Body, v)
+	}
+
+	// Upstream's response is checked against a fresh value of v's type, since
+	// other requests may be waiting on it.
+	check := func(body []byte) error {
+		return json.Unmarshal(body, reflect.New(reflect.TypeOf(v).Elem()).Interface())
+	}
+	f, started := startAPIFetch(ctx, url, header, cached, haveCached, check)
+	if !started && haveCached {
+		return json.Unmarshal(cached.Body, v)
+	}
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	switch {
+	case f.err == nil:
+		return json.Unmarshal(f.body, v)
+	case errors.Is(f.err, errUpstreamNotFound) || !haveCached:
+		return f.err
+	}
+	log.Printf("api cache: %s: %v; serving copy from %s", url, f.err, cached.FetchedAt.Local().Format(time.Kitchen))
+	templates.NoteStale(ctx, source, cached.FetchedAt)
+	return json.Unmarshal(cached.Body, v)
+}
+
+// revalidate fetches url, conditionally if there's a stored copy, and
+// stores what comes back if check accepts it. It returns the current body.
+func revalidate(ctx context.Context, url string, header http.Header, cached store.CachedResponse, haveCached bool, check func([]byte) error) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, vals := range header {
+		req.Header[k] = vals
+	}
+	if haveCached {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := outbound.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && haveCached:
+		if err := repo.APICache.Touch(ctx, url, time.Now()); err != nil {
+			log.Printf("%v", err)
+		}
+		return cached.Body, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errUpstreamNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, errors.New(resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := check(body); err != nil {
+		return nil, fmt.Errorf("bad response: %w", err)
+	}
+	if err := repo.APICache.Put(ctx, url, store.CachedResponse{
+		Body:         body,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	}); err != nil {
+		log.Printf("%v", err)
+	}
+	return body, nil
+}
+
+// expireCachedResponse makes the next read of url revalidate. The stored copy
+// stays as the fallback.
+func expireCachedResponse(url string) {
+	if err := repo.APICache.Expire(context.Background(), url); err != nil {
+		log.Printf("%v", err)
+	}
+}
+
+// apiCacheEditMu serializes updateCachedResponse's read-modify-write.
+var apiCacheEditMu sync.Mutex
+
+// updateCachedResponse decodes the JSON stored for url into v, calls edit and
+// stores v back, e.g. to apply a webhook. The validators are dropped since
+// the body no longer matches upstream's. Nothing happens if url isn't stored.
+func updateCachedResponse(url string, v interface{}, edit func()) {
+	// Edit what a revalidation in flight brings back, not what it replaces
+	waitForAPIFetch(url)
+	apiCacheEditMu.Lock()
+	defer apiCacheEditMu.Unlock()
+
+	ctx := context.Background()
+	cached, ok, err := loadCachedResponse(ctx, url)
+	if err != nil {
+		log.Printf("%v", err)
+		return
+	}
+	if !ok || json.Unmarshal(cached.Body, v) != nil {
+		return
+	}
+	edit()
+	body, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if err := repo.APICache.ReplaceBody(ctx, url, body); err != nil {
+		log.Printf("%v", err)
+	}
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+
+	"github.com/a-h/templ"
+)
+
+// Accounts live in the users table with PBKDF2 password hashes. Logging in
+// creates a session whose random token goes in an HttpOnly cookie; only its
+// SHA-256 is stored. Every route in main() is wrapped in requireRole, and
+// destructive admin routes also need requireFreshAuth: the password must have
+// been entered within the last few minutes.
+
+type role int
+
+const (
+	roleScouter role = iota + 1
+	roleStrategist
+	roleAdmin
+)
+
+func (r role) String() string {
+	switch r {
+	case roleScouter:
+		return "scouter"
+	case roleStrategist:
+		return "strategist"
+	case roleAdmin:
+		return "admin"
+	}
+	return "unknown"
+}
+
+func parseRole(s string) (role, bool) {
+	for _, r := range []role{roleScouter, roleStrategist, roleAdmin} {
+		if r.String() == s {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+const (
+	sessionCookie   = "vibe_session"
+	sessionLifetime = 7 * 24 * time.Hour // long enough for a whole event
+	reauthWindow    = 5 * time.Minute
+	pbkdf2Iter      = 600_000
+)
+
+// ── Passwords ─────────────────────────────────────────────────────────────────
+
+// hashPassword returns "pbkdf2-sha256$<iter>$<salt>$<hash>" with base64 parts.
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, pbkdf2Iter, 32)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", pbkdf2Iter, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+func checkPassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter < 1 {
+		return false
+	}
+	enc := base64.RawStdEncoding
+	salt, err1 := enc.DecodeString(parts[2])
+	want, err2 := enc.DecodeString(parts[3])
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iter, len(want))
+	return err == nil && subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// ── Users ─────────────────────────────────────────────────────────────────────
+
+type user struct {
+	ID       int
+	Username string
+	Role     role
+}
+
+var errBadLogin = errors.New("wrong username or password")
+
+var dummyPasswordHash, _ = hashPassword("not a real password")
+
+func authenticate(ctx context.Context, username, password string) (user, error) {
+	stored, err := repo.Users.ByUsername(ctx, strings.TrimSpace(username))
+	if errors.Is(err, store.ErrNotFound) {
+		// Burn the same time as a real check so usernames can't be probed
+		checkPassword(dummyPasswordHash, password)
+		return user{}, errBadLogin
+	}
+	if err != nil {
+		return user{}, err
+	}
+	if !checkPassword(stored.PasswordHash, password) {
+		return user{}, errBadLogin
+	}
+	u := user{ID: stored.ID, Username: stored.Username}
+	u.Role, _ = parseRole(stored.Role)
+	return u, nil
+}
+
+// saveUser creates a user or updates an existing one's role. An empty
+// password keeps the current one (and is refused for new users).
+func saveUser(ctx context.Context, username string, r role, password string) error {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return errors.New("username required")
+	}
+	if password == "" {
+		err := repo.Users.SetRole(ctx, username, r.String())
+		if errors.Is(err, store.ErrNotFound) {
+			return errors.New("password required for a new user")
+		}
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return repo.Users.Save(ctx, username, r.String(), hash)
+}
+
+// examplePassword is the placeholder older copies of .env.example shipped.
+const examplePassword = "change_me"
+
+// ensureAdminUser creates the "admin" account from ADMIN_PASSWORD when no
+// admin exists yet, so a fresh install can be logged into.
+func ensureAdminUser() {
+	ctx := context.Background()
+	admins, err := repo.Users.CountWithRole(ctx, roleAdmin.String())
+	if err != nil {
+		log.Fatalf("Error checking for an admin account: %s", err)
+	}
+	if admins > 0 {
+		return
+	}
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		log.Println("No admin account exists. Set ADMIN_PASSWORD to create one named \"admin\".")
+		return
+	}
+	if password == examplePassword {
+		log.Printf("No admin account exists, and ADMIN_PASSWORD is still %q from .env.example. Set your own to create one.", examplePassword)
+		return
+	}
+	if err := saveUser(ctx, "admin", roleAdmin, password); err != nil {
+		log.Fatalf("Error creating admin account: %s", err)
+	}
+	log.Println("Created admin account \"admin\" from ADMIN_PASSWORD")
+}
+
+// ── Sessions ──────────────────────────────────────────────────────────────────
+
+type session struct {
+	tokenHash string
+	User      user
+	ReauthAt  time.Time
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func startSession(w http.ResponseWriter, r *http.Request, u user) error {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	now := time.Now().UTC()
+
+	if err := repo.Sessions.Start(r.Context(), hashToken(token), u.ID, now, now.Add(sessionLifetime)); err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  now.Add(sessionLifetime),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// lookupSession reports whether r carries a live session. An error means
+// the database couldn't say, not that there isn't one.
+func lookupSession(r *http.Request) (session, bool, error) {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil || c.Value == "" {
+		return session{}, false, nil
+	}
+	stored, err := repo.Sessions.Lookup(r.Context(), hashToken(c.Value), time.Now().UTC())
+	if errors.Is(err, store.ErrNotFound) {
+		return session{}, false, nil
+	}
+	if err != nil {
+		return session{}, false, err
+	}
+	s := session{
+		tokenHash: stored.TokenHash,
+		User:      user{ID: stored.User.ID, Username: stored.User.Username},
+		ReauthAt:  stored.ReauthAt,
+	}
+	s.User.Role, _ = parseRole(stored.User.Role)
+	return s, true, nil
+}
+
+type sessionCtxKey struct{}
+
+func currentSession(r *http.Request) (session, bool) {
+	s, ok := r.Context().Value(sessionCtxKey{}).(session)
+	return s, ok
+}
+
+// ── Middleware ────────────────────────────────────────────────────────────────
+
+// requireRole lets the request through only for a signed-in user with at
+// least the given role. Pages redirect to the login form; API and htmx
+// requests get a 401 (htmx is told to redirect the whole page).
+func requireRole(min role, h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, ok, err := lookupSession(r)
+		if err != nil {
+			// Don't sign people out because the database was busy
+			log.Printf("auth: %v", err)
+			http.Error(w, "DB error", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			if r.Header.Get("HX-Request") != "" {
+				w.Header().Set("HX-Redirect", loginURL(r))
+			}
+			if r.Method == http.MethodGet && r.Header.Get("HX-Request") == "" && !strings.HasPrefix(r.URL.Path, "/api/") {
+				http.Redirect(w, r, loginURL(r), http.StatusSeeOther)
+				return
+			}
+			http.Error(w, "Login required", http.StatusUnauthorized)
+			return
+		}
+		if s.User.Role < min {
+			http.Error(w, "Forbidden: "+min.String()+" role required", http.StatusForbidden)
+			return
+		}
+		ctx := context.WithValue(r.Context(), sessionCtxKey{}, s)
+		h(w, r.WithContext(templates.WithStaleTracking(ctx)))
+	})
+}
+
+// requireFreshAuth guards destructive endpoints. It must run inside
+// requireRole. Clients that get a 401 with X-Reauth-Required should POST the
+// password to /api/reauth and retry.
+func requireFreshAuth(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, ok := currentSession(r)
+		if !ok || time.Since(s.ReauthAt) > reauthWindow {
+			w.Header().Set("X-Reauth-Required", "1")
+			http.Error(w, "Please re-enter your password to continue", http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func loginURL(r *http.Request) string {
+	next := r.URL.RequestURI()
+	if r.Header.Get("HX-Request") != "" {
+		next = "/"
+	}
+	return "/login?next=" + url.QueryEscape(next)
+}
+
+// safeNext only allows local paths so /login can't bounce users off-site.
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// ── Handlers ──────────────────────────────────────────────────────────────────
+
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+	data := templates.LoginPageData{Next: safeNext(r.FormValue("next"))}
+	if r.Method != http.MethodPost {
+		templ.Handler(templates.LoginPage(data)).ServeHTTP(w, r)
+		return
+	}
+
+	u, err := authenticate(r.Context(), r.FormValue("username"), r.FormValue("password"))
+	if err != nil {
+		if !errors.Is(err, errBadLogin) {
+			log.Printf("login: %v", err)
+		}
+		data.Username = r.FormValue("username")
+		data.Error = errBadLogin.Error()
+		w.WriteHeader(http.StatusUnauthorized)
+		templates.LoginPage(data).Render(r.Context(), w)
+		return
+	}
+	if err := startSession(w, r, u); err != nil {
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, data.Next, http.StatusSeeOther)
+}
+
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		if err := repo.Sessions.End(r.Context(), hashToken(c.Value)); err != nil {
+			log.Printf("logout: %v", err)
+			http.Error(w, "DB error", http.StatusInternalServerError)
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "", Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// apiReauthHandler confirms the signed-in user's password and opens the
+// re-auth window for destructive actions.
+func apiReauthHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s, _ := currentSession(r)
+	if _, err := authenticate(r.Context(), s.User.Username, r.FormValue("password")); err != nil {
+		http.Error(w, "Wrong password", http.StatusUnauthorized)
+		return
+	}
+	if err := repo.Sessions.Reauth(r.Context(), s.tokenHash, time.Now().UTC()); err != nil {
+		log.Printf("reauth: %v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func listUsers(ctx context.Context) ([]templates.AdminUser, error) {
+	stored, err := repo.Users.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]templates.AdminUser, len(stored))
+	for i, u := range stored {
+		users[i] = templates.AdminUser{Username: u.Username, Role: u.Role}
+	}
+	return users, nil
+}
+
+func renderUserList(w http.ResponseWriter, r *http.Request, message string) {
+	users, err := listUsers(r.Context())
+	if err != nil {
+		log.Printf("%v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	s, _ := currentSession(r)
+	templates.AdminUserList(users, s.User.Username, message).Render(r.Context(), w)
+}
+
+func apiSaveUserHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rl, ok := parseRole(r.FormValue("role"))
+	if !ok {
+		http.Error(w, "unknown role", http.StatusBadRequest)
+		return
+	}
+	username := strings.TrimSpace(r.FormValue("username"))
+	if s, _ := currentSession(r); username == s.User.Username && rl != roleAdmin {
+		renderUserList(w, r, "You can't remove your own admin role.")
+		return
+	}
+	if err := saveUser(r.Context(), username, rl, r.FormValue("password")); err != nil {
+		renderUserList(w, r, err.Error())
+		return
+	}
+	renderUserList(w, r, "Saved "+username+".")
+}
+
+func apiDeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	username := r.FormValue("username")
+	if s, _ := currentSession(r); username == s.User.Username {
+		renderUserList(w, r, "You can't delete your own account.")
+		return
+	}
+	if err := repo.Users.Delete(r.Context(), username); err != nil {
+		log.Printf("%v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	renderUserList(w, r, "Deleted "+username+".")
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+)
+
+// Backups are whole-database snapshots taken with VACUUM INTO, which is
+// consistent while the server keeps writing. Restoring is two steps: the
+// upload is checked, migrated to this build's schema and described, and only
+// a second, password-confirmed request copies it over the live tables.
+
+// restoreSkipTables are left alone by a restore. Sessions are cleared
+// instead, since user ids in the backup may belong to different people;
+// jobs belong to the running server.
+var restoreSkipTables = map[string]bool{
+	"schema_migrations": true,
+	"sessions":          true,
+	"jobs":              true,
+}
+
+// backupHandler downloads a snapshot of the database.
+func backupHandler(w http.ResponseWriter, r *http.Request) {
+	dir, err := os.MkdirTemp("", "vibe-scout-backup-")
+	if err != nil {
+		http.Error(w, "Backup failed", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "backup.db")
+	if _, err := db.ExecContext(r.Context(), `VACUUM INTO ?`, path); err != nil {
+		log.Printf("backup: %v", err)
+		http.Error(w, "Backup failed", http.StatusInternalServerError)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		http.Error(w, "Backup failed", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	name := "vibescout-" + time.Now().Format("20060102-1504") + ".db"
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	io.Copy(w, f)
+}
+
+// restoreDir holds uploaded backups between the two restore steps.
+func restoreDir() string {
+	return filepath.Join(filepath.Dir(dbPath()), "restore")
+}
+
+var restoreTokenRE = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// maxBackupUpload is far above a season of scouting plus the API cache.
+const maxBackupUpload = 512 << 20
+
+// apiRestoreUploadHandler checks an uploaded backup and shows what restoring
+// it would bring back, with the button to confirm.
+func apiRestoreUploadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBackupUpload)
+	file, _, err := r.FormFile("backup")
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			templates.RestorePreview(templates.RestoreSummary{Error: fmt.Sprintf("Backups over %d MB can't be restored here", maxBackupUpload>>20)}).Render(r.Context(), w)
+			return
+		}
+		templates.RestorePreview(templates.RestoreSummary{Error: "Choose a backup file"}).Render(r.Context(), w)
+		return
+	}
+	defer file.Close()
+
+	if err := os.MkdirAll(restoreDir(), 0o700); err != nil {
+		http.Error(w, "Could not stage backup", http.StatusInternalServerError)
+		return
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		log.Printf("restore: %v", err)
+		http.Error(w, "Could not stage backup", http.StatusInternalServerError)
+		return
+	}
+	path := filepath.Join(restoreDir(), hex.EncodeToString(token)+".db")
+	if err := saveUpload(path, file); err != nil {
+		log.Printf("restore: %v", err)
+		http.Error(w, "Could not stage backup", http.StatusInternalServerError)
+		return
+	}
+
+	summary, err := checkBackup(path)
+	if err != nil {
+		os.Remove(path)
+		templates.RestorePreview(templates.RestoreSummary{Error: "Not a usable backup: " + err.Error()}).Render(r.Context(), w)
+		return
+	}
+	summary.Token = hex.EncodeToString(token)
+	templates.RestorePreview(summary).Render(r.Context(), w)
+}
+
+func saveUpload(path string, src io.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// checkBackup opens a staged backup on its own, checks it, brings it up to
+// this build's schema and counts what's in it.
+func checkBackup(path string) (templates.RestoreSummary, error) {
+	conn, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return templates.RestoreSummary{}, err
+	}
+	defer conn.Close()
+
+	var integrity string
+	if err := conn.QueryRow(`PRAGMA integrity_check`).Scan(&integrity); err != nil {
+		return templates.RestoreSummary{}, fmt.Errorf("not an SQLite database")
+	}
+	if integrity != "ok" {
+		return templates.RestoreSummary{}, fmt.Errorf("integrity check failed: %s", integrity)
+	}
+	var tables int
+	conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'scout_submissions'`).Scan(&tables)
+	if tables == 0 {
+		return templates.RestoreSummary{}, fmt.Errorf("no scouting tables")
+	}
+	if err := store.Migrate(conn); err != nil {
+		return templates.RestoreSummary{}, err
+	}
+
+	s := templates.RestoreSummary{}
+	conn.QueryRow(`SELECT COUNT(*), COUNT(DISTINCT event_key), COALESCE(MAX(created_at), '') FROM scout_submissions`).
+		Scan(&s.Submissions, &s.Events, &s.LatestSubmission)
+	conn.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&s.Users)
+	conn.QueryRow(`SELECT COUNT(*) FROM pick_lists`).Scan(&s.PickLists)
+	return s, nil
+}
+
+// apiRestoreConfirmHandler restores a staged backup over the live database.
+func apiRestoreConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := r.FormValue("token")
+	if !restoreTokenRE.MatchString(token) {
+		http.Error(w, "Bad restore token", http.StatusBadRequest)
+		return
+	}
+	path := filepath.Join(restoreDir(), token+".db")
+	if _, err := os.Stat(path); err != nil {
+		templates.RestorePreview(templates.RestoreSummary{Error: "That upload has expired; upload the backup again"}).Render(r.Context(), w)
+		return
+	}
+	defer os.Remove(path)
+
+	if err := restoreDatabase(r.Context(), path); err != nil {
+		log.Printf("restore: %v", err)
+		templates.RestorePreview(templates.RestoreSummary{Error: "Restore failed; nothing was changed"}).Render(r.Context(), w)
+		return
+	}
+	log.Printf("Database restored from backup")
+	templates.RestorePreview(templates.RestoreSummary{Restored: true}).Render(r.Context(), w)
+}
+
+// restoreDatabase replaces every table's rows with the backup's in one
+// transaction, on one connection with the backup attached. Copying rows
+// rather than swapping files keeps the open database handle, and the jobs
+// and streams using it, valid.
+func restoreDatabase(ctx context.Context, path string) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Rows go in table by table, so references are only whole at the end
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `PRAGMA foreign_keys = ON`)
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS backup`, path); err != nil {
+		return fmt.Errorf("attach: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `DETACH DATABASE backup`)
+
+	tables, err := connStrings(ctx, conn, `
+		SELECT name FROM main.sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return err
+	}
+	// Copy by column name: legacy databases gained columns in a different
+	// order than fresh ones.
+	columns := map[string][]string{}
+	for _, table := range tables {
+		cols, err := connStrings(ctx, conn, `
+			SELECT m.name FROM pragma_table_info(?, 'main') m
+			JOIN pragma_table_info(?, 'backup') b ON b.name = m.name`, table, table)
+		if err != nil {
+			return fmt.Errorf("%s columns: %w", table, err)
+		}
+		columns[table] = cols
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range tables {
+		if restoreSkipTables[table] {
+			continue
+		}
+		cols := columns[table]
+		if _, err := tx.ExecContext(ctx, `DELETE FROM main.`+table); err != nil {
+			return fmt.Errorf("clear %s: %w", table, err)
+		}
+		if len(cols) == 0 {
+			continue // not in the backup
+		}
+		list := `"` + strings.Join(cols, `", "`) + `"`
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO main.%s (%s) SELECT %s FROM backup.%s`, table, list, list, table)); err != nil {
+			return fmt.Errorf("restore %s: %w", table, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM main.sessions`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func connStrings(ctx context.Context, conn *sql.Conn, query string, args ...any) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// cleanRestoreDir drops staged uploads nobody confirmed.
+func cleanRestoreDir() {
+	entries, err := os.ReadDir(restoreDir())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && time.Since(info.ModTime()) > time.Hour {
+			os.Remove(filepath.Join(restoreDir(), e.Name()))
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+)
+
+// An event bundle carries one event's submissions, cached analyses, match
+// plans and pick lists to a partner team's Vibe Scout. Rows keep who scouted
+// them and which team they came from (TEAM_NAME on the exporting server),
+// since scouter ids and users don't mean anything on another instance.
+
+const eventBundleFormat = "vibe-scout-event-bundle"
+
+type eventBundle struct {
+	Format      string                `json:"format"`
+	Version     int                   `json:"version"`
+	EventKey    string                `json:"event_key"`
+	Source      string                `json:"source"`
+	ExportedAt  time.Time             `json:"exported_at"`
+	Submissions []bundleSubmission    `json:"submissions"`
+	Analyses    []bundleCached        `json:"analyses"`
+	MatchPlans  []bundleCached        `json:"match_plans"`
+	PickLists   []bundlePickListEntry `json:"pick_lists"`
+}
+
+type bundleSubmission struct {
+	// UUID identifies the row across instances so a re-import is skipped.
+	// Rows saved without a client id (AI fills, the test seed) have none;
+	// the importer derives one from the source and ID.
+	ID            int64          `json:"id"`
+	UUID          string         `json:"uuid,omitempty"`
+	MatchKey      string         `json:"match_key"`
+	TeamNumber    string         `json:"team_number"`
+	Notes         string         `json:"notes"`
+	Scouter       string         `json:"scouter,omitempty"`
+	Source        string         `json:"source,omitempty"` // set if it was itself imported
+	AIGenerated   bool           `json:"ai_generated"`
+	CreatedAt     time.Time      `json:"created_at"`
+	SchemaSeason  int            `json:"schema_season,omitempty"`
+	SchemaVersion int            `json:"schema_version,omitempty"`
+	Metrics       []bundleMetric `json:"metrics,omitempty"`
+}
+
+type bundleMetric struct {
+	Key  string  `json:"key"`
+	Int  *int    `json:"int,omitempty"`
+	Text *string `json:"text,omitempty"`
+}
+
+// bundleCached is a cached analysis (no MatchKey) or match plan.
+type bundleCached struct {
+	MatchKey   string    `json:"match_key,omitempty"`
+	TeamNumber string    `json:"team_number"`
+	Text       string    `json:"text"`
+	NotesHash  string    `json:"notes_hash"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type bundlePickListEntry struct {
+	Revision  int             `json:"revision"`
+	Entries   json.RawMessage `json:"entries"`
+	Author    string          `json:"author"`
+	Source    string          `json:"source,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// teamName is how this instance labels the bundles it exports; empty if
+// TEAM_NAME isn't set, in which case importers name the source themselves.
+func teamName() string {
+	return strings.TrimSpace(os.Getenv("TEAM_NAME"))
+}
+
+// bundleExportHandler downloads /api/admin/bundle?event_key=….
+func bundleExportHandler(w http.ResponseWriter, r *http.Request) {
+	eventKey := r.URL.Query().Get("event_key")
+	if eventKey == "" {
+		http.Error(w, "Event key required", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+
+	b := eventBundle{
+		Format:     eventBundleFormat,
+		Version:    1,
+		EventKey:   eventKey,
+		Source:     teamName(),
+		ExportedAt: time.Now().UTC(),
+	}
+	subs, err := repo.Submissions.ForEvent(ctx, eventKey)
+	if err == nil {
+		for _, s := range subs {
+			bs := bundleSubmission{
+				ID:            s.ID,
+				UUID:          s.UUID,
+				MatchKey:      s.MatchKey,
+				TeamNumber:    s.TeamNumber,
+				Notes:         s.Notes,
+				Scouter:       s.ScouterName,
+				Source:        s.Source,
+				AIGenerated:   s.AIGenerated,
+				CreatedAt:     s.CreatedAt,
+				SchemaSeason:  s.SchemaSeason,
+				SchemaVersion: s.SchemaVersion,
+			}
+			for _, m := range s.Metrics {
+				bs.Metrics = append(bs.Metrics, bundleMetric{Key: m.Key, Int: m.IntValue, Text: m.TextValue})
+			}
+			b.Submissions = append(b.Submissions, bs)
+		}
+	}
+	var analyses []store.Analysis
+	if err == nil {
+		analyses, err = repo.Analysis.ForEvent(ctx, eventKey)
+	}
+	for _, a := range analyses {
+		b.Analyses = append(b.Analyses, bundleCached{TeamNumber: a.TeamNumber, Text: a.Text, NotesHash: a.NotesHash, CreatedAt: a.CreatedAt})
+	}
+	var plans []store.MatchPlan
+	if err == nil {
+		plans, err = repo.MatchPlans.ForEvent(ctx, eventKey)
+	}
+	for _, p := range plans {
+		b.MatchPlans = append(b.MatchPlans, bundleCached{
+			MatchKey: p.MatchKey, TeamNumber: p.TeamNumber, Text: p.Text, NotesHash: p.NotesHash, CreatedAt: p.CreatedAt,
+		})
+	}
+	var pickLists []store.PickList
+	if err == nil {
+		pickLists, err = repo.PickLists.ForEvent(ctx, eventKey)
+	}
+	for _, pl := range pickLists {
+		b.PickLists = append(b.PickLists, bundlePickListEntry{
+			Revision: pl.Revision, Entries: json.RawMessage(pl.Entries), Author: pl.Author, Source: pl.Source, CreatedAt: pl.CreatedAt,
+		})
+	}
+	if err != nil {
+		log.Printf("bundle %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "vibescout-"+eventKey+"-bundle.json"))
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	enc.Encode(b)
+}
+
+// apiBundleImportHandler imports an uploaded event bundle.
+func apiBundleImportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	file, _, err := r.FormFile("bundle")
+	if err != nil {
+		templates.BundleImportResult("", "Choose a bundle file").Render(r.Context(), w)
+		return
+	}
+	defer file.Close()
+
+	var b eventBundle
+	if err := json.NewDecoder(file).Decode(&b); err != nil || b.Format != eventBundleFormat {
+		templates.BundleImportResult("", "Not a Vibe Scout event bundle").Render(r.Context(), w)
+		return
+	}
+	if b.Version != 1 {
+		templates.BundleImportResult("", fmt.Sprintf("Bundle version %d is newer than this server understands", b.Version)).Render(r.Context(), w)
+		return
+	}
+	if b.EventKey == "" {
+		templates.BundleImportResult("", "Bundle has no event key").Render(r.Context(), w)
+		return
+	}
+	source := strings.TrimSpace(r.FormValue("source"))
+	if source == "" {
+		source = b.Source
+	}
+	if source == "" {
+		templates.BundleImportResult("", "This bundle doesn't say which team it's from; enter it as the source").Render(r.Context(), w)
+		return
+	}
+	if source == teamName() {
+		templates.BundleImportResult("", "This bundle came from this server").Render(r.Context(), w)
+		return
+	}
+
+	imp, err := b.toImport(source)
+	if err != nil {
+		templates.BundleImportResult("", err.Error()).Render(r.Context(), w)
+		return
+	}
+	n, err := repo.ImportEvent(r.Context(), imp)
+	if err != nil {
+		log.Printf("bundle import %s: %v", b.EventKey, err)
+		templates.BundleImportResult("", "Import failed; nothing was changed").Render(r.Context(), w)
+		return
+	}
+
+	msg := fmt.Sprintf("Imported %s from %s: %d of %d submissions, %d analyses, %d match plans",
+		b.EventKey, source, n.Submissions, len(b.Submissions), n.Analyses, n.MatchPlans)
+	switch {
+	case n.PickListsSkipped:
+		msg += "; pick lists skipped since this event already has one"
+	case n.PickLists > 0:
+		msg += fmt.Sprintf(", %d pick list revisions", n.PickLists)
+	}
+	log.Print(msg)
+	templates.BundleImportResult(msg, "").Render(r.Context(), w)
+}
+
+// toImport checks the bundle's match keys and converts it for the store.
+func (b eventBundle) toImport(source string) (store.EventImport, error) {
+	imp := store.EventImport{EventKey: b.EventKey, Source: source}
+	for _, s := range b.Submissions {
+		id, err := parseMatchKey(s.MatchKey)
+		if err != nil || id.EventKey != b.EventKey {
+			return store.EventImport{}, fmt.Errorf("bad match key %q in bundle", s.MatchKey)
+		}
+		if s.TeamNumber == "" {
+			return store.EventImport{}, fmt.Errorf("submission for %s has no team", s.MatchKey)
+		}
+		uuid := s.UUID
+		if uuid == "" {
+			uuid = fmt.Sprintf("%s/%s/%d", source, b.EventKey, s.ID)
+		}
+		sub := store.Submission{
+			MatchKey:      id.Key(),
+			CompLevel:     id.CompLevel,
+			SetNumber:     id.SetNumber,
+			MatchNumber:   id.MatchNumber,
+			TeamNumber:    s.TeamNumber,
+			Notes:         s.Notes,
+			AIGenerated:   s.AIGenerated,
+			UUID:          uuid,
+			SchemaSeason:  s.SchemaSeason,
+			SchemaVersion: s.SchemaVersion,
+			Source:        s.Source,
+			ScouterName:   s.Scouter,
+			CreatedAt:     s.CreatedAt,
+		}
+		for _, m := range s.Metrics {
+			sub.Metrics = append(sub.Metrics, store.Metric{Key: m.Key, IntValue: m.Int, TextValue: m.Text})
+		}
+		imp.Submissions = append(imp.Submissions, sub)
+	}
+	for _, a := range b.Analyses {
+		imp.Analyses = append(imp.Analyses, store.Analysis{
+			TeamNumber: a.TeamNumber, CachedText: store.CachedText{Text: a.Text, NotesHash: a.NotesHash}, CreatedAt: a.CreatedAt,
+		})
+	}
+	for _, p := range b.MatchPlans {
+		imp.MatchPlans = append(imp.MatchPlans, store.MatchPlan{
+			MatchKey: p.MatchKey, TeamNumber: p.TeamNumber, CachedText: store.CachedText{Text: p.Text, NotesHash: p.NotesHash}, CreatedAt: p.CreatedAt,
+		})
+	}
+	for _, pl := range b.PickLists {
+		var entries []pickListEntry
+		if err := json.Unmarshal(pl.Entries, &entries); err != nil {
+			return store.EventImport{}, fmt.Errorf("pick list revision %d is corrupt", pl.Revision)
+		}
+		imp.PickLists = append(imp.PickLists, store.PickList{
+			Revision: pl.Revision, Entries: string(pl.Entries), Author: pl.Author, Source: pl.Source, CreatedAt: pl.CreatedAt,
+		})
+	}
+	return imp, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"vibe-scout/templates"
+)
+
+// SVG chart layout for the team comparison. The server works out every
+// coordinate so the charts need no JavaScript and print as drawn.
+
+// chartColors tells up to four compared teams apart.
+var chartColors = []string{"#8D6E63", "#2E7D9A", "#E08E2B", "#7B5EA7"}
+
+const (
+	radarSize   = 340.0
+	radarRadius = 115.0
+)
+
+// radarChart plots each series' values, 0 to max on every axis. values[i][j]
+// is series i on axis j.
+func radarChart(axes []string, teams, colors []string, values [][]float64, max float64) *templates.RadarChart {
+	c := &templates.RadarChart{Size: radarSize}
+	center := radarSize / 2
+	point := func(axis int, frac float64) (float64, float64) {
+		angle := -math.Pi/2 + 2*math.Pi*float64(axis)/float64(len(axes))
+		return center + frac*radarRadius*math.Cos(angle), center + frac*radarRadius*math.Sin(angle)
+	}
+	polygon := func(frac func(axis int) float64) string {
+		pts := make([]string, len(axes))
+		for j := range axes {
+			x, y := point(j, frac(j))
+			pts[j] = svgNum(x) + "," + svgNum(y)
+		}
+		return strings.Join(pts, " ")
+	}
+
+	for _, ring := range []float64{0.25, 0.5, 0.75, 1} {
+		c.Rings = append(c.Rings, polygon(func(int) float64 { return ring }))
+	}
+	for j, label := range axes {
+		x, y := point(j, 1)
+		lx, ly := point(j, 1.15)
+		anchor := "middle"
+		switch dx := lx - center; {
+		case dx > 1:
+			anchor = "start"
+		case dx < -1:
+			anchor = "end"
+		}
+		c.Axes = append(c.Axes, templates.RadarAxis{Label: label, X: x, Y: y, LabelX: lx, LabelY: ly + 4, Anchor: anchor})
+	}
+	for i, team := range teams {
+		c.Series = append(c.Series, templates.ChartSeries{
+			Team:  team,
+			Color: colors[i],
+			Points: polygon(func(j int) float64 {
+				return math.Max(0, math.Min(values[i][j]/max, 1))
+			}),
+		})
+	}
+	return c
+}
+
+const (
+	barWidth    = 22.0
+	barGroupGap = 26.0
+	barHeight   = 160.0
+	barTop      = 24.0 // room for value labels
+	barLeft     = 36.0 // room for grid labels
+	barBottom   = 40.0 // room for group labels
+)
+
+// barChart draws one group per label with a bar per team. values[i][j] is
+// team i in group j; NaN leaves the bar out.
+func barChart(groups []string, teams, colors []string, values [][]float64) *templates.BarChart {
+	top := 0.0
+	for _, row := range values {
+		for _, v := range row {
+			if !math.IsNaN(v) && v > top {
+				top = v
+			}
+		}
+	}
+	step := niceStep(top / 4)
+	top = step * math.Ceil(top/step)
+	if top == 0 {
+		top = 1
+	}
+
+	groupWidth := float64(len(teams))*barWidth + barGroupGap
+	c := &templates.BarChart{
+		Width:    barLeft + float64(len(groups))*groupWidth,
+		Height:   barTop + barHeight + barBottom,
+		Left:     barLeft,
+		Baseline: barTop + barHeight,
+	}
+	for v := 0.0; v <= top+step/2; v += step {
+		c.Grid = append(c.Grid, templates.GridLine{Y: c.Baseline - v/top*barHeight, Label: formatChartValue(v)})
+	}
+	for j, label := range groups {
+		x0 := barLeft + barGroupGap/2 + float64(j)*groupWidth
+		g := templates.BarGroup{Label: label, LabelX: x0 + float64(len(teams))*barWidth/2}
+		for i, team := range teams {
+			v := values[i][j]
+			if math.IsNaN(v) {
+				continue
+			}
+			h := math.Max(v, 0) / top * barHeight
+			g.Bars = append(g.Bars, templates.Bar{
+				Team:  team,
+				Color: colors[i],
+				X:     x0 + float64(i)*barWidth,
+				Y:     c.Baseline - h,
+				W:     barWidth - 3,
+				H:     h,
+				Value: formatChartValue(v),
+			})
+		}
+		c.Groups = append(c.Groups, g)
+	}
+	return c
+}
+
+// niceStep rounds a grid step up to 1, 2 or 5 times a power of ten.
+func niceStep(raw float64) float64 {
+	if raw <= 0 {
+		return 1
+	}
+	pow := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*pow >= raw {
+			return m * pow
+		}
+	}
+	return 10 * pow
+}
+
+func formatChartValue(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.Itoa(int(v))
+	}
+	return fmt.Sprintf("%.1f", v)
+}
+
+func svgNum(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
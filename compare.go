@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/a-h/templ"
+
+	"vibe-scout/templates"
+)
+
+// The comparison page puts 2-4 teams side by side: cached analysis scores
+// and EPA on a radar, EPA point components and note counts as bars, and on
+// request an LLM paragraph weighing them against each other.
+
+const (
+	minCompareTeams = 2
+	maxCompareTeams = 4
+)
+
+var teamNumberInput = regexp.MustCompile(`^[1-9][0-9]{0,5}$`)
+
+// radarEPAAxes are the EPA components put on the radar, scaled so the best
+// of the compared teams reaches the edge.
+var radarEPAAxes = []struct{ Key, Label string }{
+	{"auto_points", "Auto EPA"},
+	{"teleop_points", "Teleop EPA"},
+	{"endgame_points", "Endgame EPA"},
+}
+
+// compareTeamsParam reads the team boxes, dropping blanks and repeats.
+func compareTeamsParam(inputs []string) ([]string, error) {
+	var teams []string
+	for _, t := range inputs {
+		t = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(t), "frc"))
+		if t == "" || slices.Contains(teams, t) {
+			continue
+		}
+		if !teamNumberInput.MatchString(t) {
+			return nil, fmt.Errorf("%q isn't a team number", t)
+		}
+		teams = append(teams, t)
+	}
+	if len(teams) < minCompareTeams || len(teams) > maxCompareTeams {
+		return nil, fmt.Errorf("pick %d to %d different teams", minCompareTeams, maxCompareTeams)
+	}
+	return teams, nil
+}
+
+// comparePageHandler serves /compare?event_key=…&teams=…&teams=….
+func comparePageHandler(w http.ResponseWriter, r *http.Request) {
+	eventMap, err := currentEventMap(r.Context())
+	if err != nil {
+		http.Error(w, "Could not load events", 500)
+		return
+	}
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	data := templates.ComparePageData{
+		Events:   eventMap,
+		EventKey: q.Get("event_key"),
+		Inputs:   make([]string, maxCompareTeams),
+	}
+	copy(data.Inputs, q["teams"])
+	if data.EventKey == "" || len(q["teams"]) == 0 {
+		templ.Handler(templates.ComparePage(data)).ServeHTTP(w, r)
+		return
+	}
+	teams, err := compareTeamsParam(q["teams"])
+	if err != nil {
+		data.Error = "Can't compare: " + err.Error()
+		templ.Handler(templates.ComparePage(data)).ServeHTTP(w, r)
+		return
+	}
+
+	breakdowns := fetchEPABreakdowns(ctx, teams)
+	for i, team := range teams {
+		ct := templates.CompareTeam{TeamNumber: team, Color: chartColors[i]}
+		if info, err := getTeamCached(ctx, team); err == nil {
+			ct.Nickname = info.Nickname
+		}
+		ct.Analysis, err = cachedAnalysisCard(ctx, data.EventKey, team)
+		if err == nil {
+			var notes []string
+			notes, err = repo.Submissions.NoteTexts(ctx, data.EventKey, team)
+			ct.NoteCount = len(notes)
+		}
+		if err != nil {
+			log.Printf("compare %s: %v", team, err)
+			http.Error(w, "DB error", http.StatusInternalServerError)
+			return
+		}
+		if b, ok := breakdowns[team]; ok {
+			ct.EPATotal, ct.HasEPA = b["total_points"], true
+		}
+		data.Teams = append(data.Teams, ct)
+	}
+	data.Radar = compareRadar(data.Teams, breakdowns)
+	data.EPABars = compareEPABars(data.Teams, breakdowns)
+
+	counts := make([][]float64, len(teams))
+	for i, t := range data.Teams {
+		counts[i] = []float64{float64(t.NoteCount)}
+	}
+	data.NoteBars = barChart([]string{"Scouted notes"}, teams, chartColors[:len(teams)], counts)
+
+	templ.Handler(templates.ComparePage(data)).ServeHTTP(w, r)
+}
+
+// compareRadar has the analysis scores out of 10 and the EPA components
+// relative to the best team shown. It's nil if there's nothing to plot.
+func compareRadar(teams []templates.CompareTeam, breakdowns map[string]map[string]float64) *templates.RadarChart {
+	axes := []string{"Scoring", "Reliability", "Defense"}
+	var epaKeys []string
+	best := map[string]float64{}
+	for _, a := range radarEPAAxes {
+		for _, t := range teams {
+			if v, ok := breakdowns[t.TeamNumber][a.Key]; ok && v > best[a.Key] {
+				best[a.Key] = v
+			}
+		}
+		if best[a.Key] > 0 {
+			axes = append(axes, a.Label)
+			epaKeys = append(epaKeys, a.Key)
+		}
+	}
+
+	plotted := false
+	names := make([]string, len(teams))
+	values := make([][]float64, len(teams))
+	for i, t := range teams {
+		names[i] = t.TeamNumber
+		row := make([]float64, 0, len(axes))
+		if a := t.Analysis; a != nil {
+			row = append(row, float64(a.Scoring), float64(a.Reliability), float64(a.Defense))
+			plotted = true
+		} else {
+			row = append(row, 0, 0, 0)
+		}
+		for _, k := range epaKeys {
+			row = append(row, breakdowns[t.TeamNumber][k]/best[k]*10)
+			plotted = true
+		}
+		values[i] = row
+	}
+	if !plotted {
+		return nil
+	}
+	return radarChart(axes, names, chartColors[:len(teams)], values, 10)
+}
+
+// compareEPABars groups the *_points components of the EPA breakdown. It's
+// nil if none of the teams has an EPA.
+func compareEPABars(teams []templates.CompareTeam, breakdowns map[string]map[string]float64) *templates.BarChart {
+	var keys []string
+	for _, t := range teams {
+		for k := range breakdowns[t.TeamNumber] {
+			if strings.HasSuffix(k, "_points") && !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sortEPAKeys(keys)
+
+	names := make([]string, len(teams))
+	labels := make([]string, len(keys))
+	values := make([][]float64, len(teams))
+	for j, k := range keys {
+		labels[j] = strings.TrimSuffix(k, "_points")
+	}
+	for i, t := range teams {
+		names[i] = t.TeamNumber
+		values[i] = make([]float64, len(keys))
+		for j, k := range keys {
+			v, ok := breakdowns[t.TeamNumber][k]
+			if !ok {
+				v = math.NaN()
+			}
+			values[i][j] = v
+		}
+	}
+	return barChart(labels, names, chartColors[:len(teams)], values)
+}
+
+// ── Head-to-head ──────────────────────────────────────────────────────────────
+
+type headToHeadJobParams struct {
+	EventKey string   `json:"event_key"`
+	Teams    []string `json:"teams"`
+}
+
+// apiHeadToHeadHandler queues the LLM head-to-head for the posted teams and
+// returns the card it streams into.
+func apiHeadToHeadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.ParseForm()
+	eventKey := r.FormValue("event_key")
+	teams, err := compareTeamsParam(r.Form["teams"])
+	if eventKey == "" || err != nil {
+		http.Error(w, "event_key and 2-4 teams required", http.StatusBadRequest)
+		return
+	}
+
+	id, err := enqueueJob(r.Context(), jobHeadToHead, eventKey+"/"+strings.Join(teams, ","),
+		headToHeadJobParams{EventKey: eventKey, Teams: teams})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	templates.HeadToHead(templates.HeadToHeadCard{
+		Teams: teams, StatusURL: jobStatusURL(id), EventsURL: jobEventsURL(id),
+	}).Render(r.Context(), w)
+}
+
+func runHeadToHeadJob(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var p headToHeadJobParams
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	text, err := callLLMHeadToHead(ctx, p.EventKey, p.Teams)
+	if err != nil {
+		return nil, err
+	}
+	return templates.HeadToHeadCard{Teams: p.Teams, Text: text}, nil
+}
+
+func renderHeadToHeadJob(w http.ResponseWriter, r *http.Request, j job) {
+	var p headToHeadJobParams
+	json.Unmarshal(j.Params, &p)
+
+	switch j.Status {
+	case jobDone:
+		var card templates.HeadToHeadCard
+		json.Unmarshal(j.Result, &card)
+		templates.HeadToHead(card).Render(r.Context(), w)
+	case jobFailed:
+		templates.HeadToHead(templates.HeadToHeadCard{Teams: p.Teams, Error: "Error generating head-to-head: " + j.Error}).Render(r.Context(), w)
+	default:
+		templates.HeadToHead(templates.HeadToHeadCard{
+			Teams: p.Teams, StatusURL: jobStatusURL(j.ID), EventsURL: jobEventsURL(j.ID),
+		}).Render(r.Context(), w)
+	}
+}
+
+type headToHeadPromptData struct {
+	EventKey string
+	Teams    []headToHeadPromptTeam
+}
+
+type headToHeadPromptTeam struct {
+	TeamNum      string
+	Analysis     string
+	EPABreakdown string
+	NoteCount    int
+	Notes        string
+}
+
+func callLLMHeadToHead(ctx context.Context, eventKey string, teams []string) (string, error) {
+	data := headToHeadPromptData{EventKey: eventKey}
+	for _, team := range teams {
+		notes, err := repo.Submissions.NoteTexts(ctx, eventKey, team)
+		if err != nil {
+			return "", err
+		}
+		t := headToHeadPromptTeam{
+			TeamNum:      team,
+			Analysis:     "not analyzed yet",
+			EPABreakdown: fetchStatboticsEPA(ctx, team),
+			NoteCount:    len(notes),
+			Notes:        strings.Join(notes, "\n"),
+		}
+		if result, ok := cachedAnalysis(ctx, eventKey, team); ok {
+			t.Analysis = fmt.Sprintf("scoring %d/10, reliability %d/10, defense %s. %s",
+				result.Scoring, result.Reliability, defenseLabel(result.Defense), result.Summary)
+		}
+		data.Teams = append(data.Teams, t)
+	}
+
+	tmpl, err := template.New("head_to_head").Parse(headToHeadPromptTmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse head-to-head prompt: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render head-to-head prompt: %w", err)
+	}
+
+	req := LLMRequest{Task: taskHeadToHead, Prompt: buf.String()}
+	if sink := jobTokenSink(ctx); sink != nil {
+		return llm.StreamText(ctx, req, sink)
+	}
+	return llm.GenerateText(ctx, req)
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+)
+
+// Scrimmages, offseason events and week-zero practices often have no TBA
+// schedule. Admins create them as custom events and upload the qualification
+// schedule, which getMatchesCached then reads from custom_matches.
+
+// customEventKeyPattern is the year, a hyphen and a code, e.g. 2026-scrim.
+// TBA keys never have a hyphen, so a custom event can't take the key of a
+// real one and hide its schedule, and match keys still parse.
+var customEventKeyPattern = regexp.MustCompile(`^\d{4}-[a-z0-9]+$`)
+
+const maxScheduleUpload = 1 << 20
+
+// customEventMatches returns the uploaded schedule for eventKey, or false if
+// it isn't a custom event.
+func customEventMatches(ctx context.Context, eventKey string) ([]Match, bool, error) {
+	custom, err := isCustomEvent(ctx, eventKey)
+	if err != nil || !custom {
+		return nil, false, err
+	}
+	stored, err := repo.CustomEvents.Matches(ctx, eventKey)
+	if err != nil {
+		return nil, false, err
+	}
+
+	matches := make([]Match, len(stored))
+	for i, sm := range stored {
+		m := Match{Key: sm.MatchKey, CompLevel: sm.CompLevel, SetNumber: sm.SetNumber, MatchNumber: sm.MatchNumber}
+		m.Alliances.Red.TeamKeys = sm.RedTeams
+		m.Alliances.Blue.TeamKeys = sm.BlueTeams
+		matches[i] = m
+	}
+	sortMatches(matches)
+	return matches, true, nil
+}
+
+func isCustomEvent(ctx context.Context, eventKey string) (bool, error) {
+	return repo.CustomEvents.Exists(ctx, eventKey)
+}
+
+func listCustomEvents(ctx context.Context) ([]templates.CustomEvent, error) {
+	stored, err := repo.CustomEvents.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]templates.CustomEvent, len(stored))
+	for i, e := range stored {
+		events[i] = templates.CustomEvent{Key: e.Key, Name: e.Name, StartDate: e.StartDate, Matches: e.Matches}
+	}
+	return events, nil
+}
+
+// saveCustomEvent creates or renames the event and, if matches isn't nil,
+// replaces its schedule.
+func saveCustomEvent(ctx context.Context, key, name, startDate string, matches []Match) error {
+	var schedule []store.CustomMatch
+	if matches != nil {
+		schedule = make([]store.CustomMatch, len(matches))
+		for i, m := range matches {
+			schedule[i] = store.CustomMatch{
+				MatchKey: m.Key, CompLevel: m.CompLevel, SetNumber: m.SetNumber, MatchNumber: m.MatchNumber,
+				RedTeams: m.Alliances.Red.TeamKeys, BlueTeams: m.Alliances.Blue.TeamKeys,
+			}
+		}
+	}
+	return repo.CustomEvents.Save(ctx, store.CustomEvent{Key: key, Name: name, StartDate: startDate}, schedule)
+}
+
+// ── Schedule parsing ──────────────────────────────────────────────────────────
+
+// parseSchedule reads a qualification schedule as the FMS / FRC Events JSON
+// export or as CSV.
+func parseSchedule(data []byte, eventKey string) ([]Match, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseFMSSchedule(trimmed, eventKey)
+	}
+	return parseScheduleCSV(bytes.NewReader(data), eventKey)
+}
+
+// parseFMSSchedule reads the {"Schedule": [...]} JSON that FMS and the FRC
+// Events API export. Only qualification matches are kept.
+func parseFMSSchedule(data []byte, eventKey string) ([]Match, error) {
+	var export struct {
+		Schedule []struct {
+			Description     string `json:"description"`
+			TournamentLevel string `json:"tournamentLevel"`
+			MatchNumber     int    `json:"matchNumber"`
+			Teams           []struct {
+				TeamNumber int    `json:"teamNumber"`
+				Station    string `json:"station"`
+			} `json:"teams"`
+		} `json:"Schedule"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("not a valid FMS schedule export: %v", err)
+	}
+
+	var rows []scheduleRow
+	for _, s := range export.Schedule {
+		if s.TournamentLevel != "" && !strings.EqualFold(s.TournamentLevel, "Qualification") {
+			continue
+		}
+		row := scheduleRow{label: s.Description, number: s.MatchNumber}
+		for _, t := range s.Teams {
+			station := strings.ToLower(t.Station)
+			i, err := strconv.Atoi(strings.TrimLeft(station, "redblu"))
+			if err != nil || i < 1 || i > 3 || t.TeamNumber == 0 {
+				continue
+			}
+			if strings.HasPrefix(station, "red") {
+				row.red[i-1] = strconv.Itoa(t.TeamNumber)
+			} else if strings.HasPrefix(station, "blue") {
+				row.blue[i-1] = strconv.Itoa(t.TeamNumber)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return buildSchedule(rows, eventKey)
+}
+
+// scheduleHeaders maps normalized CSV headers to columns. Match is optional;
+// without it rows are numbered in order.
+var scheduleHeaders = map[string]string{
+	"match": "match", "matchnumber": "match", "match#": "match", "matchno": "match", "description": "match",
+	"red1": "red1", "red2": "red2", "red3": "red3", "r1": "red1", "r2": "red2", "r3": "red3",
+	"blue1": "blue1", "blue2": "blue2", "blue3": "blue3", "b1": "blue1", "b2": "blue2", "b3": "blue3",
+}
+
+// parseScheduleCSV reads a CSV with Red 1–3 and Blue 1–3 columns, like the
+// FMS schedule report, optionally with a Match or Description column. Title
+// rows above the header are skipped. A file with no header is read as
+// match,red1,red2,red3,blue1,blue2,blue3.
+func parseScheduleCSV(r io.Reader, eventKey string) ([]Match, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("not a valid CSV file: %v", err)
+	}
+
+	cols := map[string]int{}
+	start := -1
+	for i, rec := range records {
+		if i >= 10 {
+			break
+		}
+		found := map[string]int{}
+		for j, cell := range rec {
+			if col, ok := scheduleHeaders[normalizeHeader(cell)]; ok {
+				if _, dup := found[col]; !dup {
+					found[col] = j
+				}
+			}
+		}
+		if hasTeamColumns(found) {
+			cols, start = found, i+1
+			break
+		}
+	}
+	if start < 0 {
+		if len(records) == 0 || len(records[0]) < 7 || !isNumber(records[0][1]) {
+			return nil, errors.New("couldn't find a header row with Red 1, Red 2, Red 3, Blue 1, Blue 2, Blue 3 columns")
+		}
+		cols = map[string]int{"match": 0, "red1": 1, "red2": 2, "red3": 3, "blue1": 4, "blue2": 5, "blue3": 6}
+		start = 0
+	}
+
+	cell := func(rec []string, col string) string {
+		j, ok := cols[col]
+		if !ok || j >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[j])
+	}
+
+	var rows []scheduleRow
+	for i, rec := range records[start:] {
+		row := scheduleRow{line: start + i + 1, label: cell(rec, "match")}
+		empty := true
+		for k := 0; k < 3; k++ {
+			row.red[k] = teamNumberPattern.FindString(cell(rec, "red"+strconv.Itoa(k+1)))
+			row.blue[k] = teamNumberPattern.FindString(cell(rec, "blue"+strconv.Itoa(k+1)))
+			if row.red[k] != "" || row.blue[k] != "" {
+				empty = false
+			}
+		}
+		if empty {
+			continue // blank line, break or field timeout row
+		}
+		if _, ok := cols["match"]; ok {
+			n, err := strconv.Atoi(lastNumberPattern.FindString(row.label))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: no match number in %q", row.line, row.label)
+			}
+			row.number = n
+		} else {
+			row.number = len(rows) + 1
+		}
+		rows = append(rows, row)
+	}
+	return buildSchedule(rows, eventKey)
+}
+
+var (
+	// teamNumberPattern pulls the number out of "254", "frc254" or a
+	// surrogate "254*".
+	teamNumberPattern = regexp.MustCompile(`\d+`)
+	lastNumberPattern = regexp.MustCompile(`\d+$`)
+)
+
+func normalizeHeader(s string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "", ".", "").Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
+func hasTeamColumns(cols map[string]int) bool {
+	for _, c := range []string{"red1", "red2", "red3", "blue1", "blue2", "blue3"} {
+		if _, ok := cols[c]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(strings.TrimSpace(s))
+	return err == nil
+}
+
+type scheduleRow struct {
+	line      int    // CSV line, for errors; 0 for JSON
+	label     string // e.g. "Qualification 12"
+	number    int
+	red, blue [3]string
+}
+
+// buildSchedule checks the rows and turns them into qualification matches.
+func buildSchedule(rows []scheduleRow, eventKey string) ([]Match, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("the schedule has no matches")
+	}
+	seen := map[int]bool{}
+	matches := make([]Match, 0, len(rows))
+	for _, row := range rows {
+		where := fmt.Sprintf("match %d", row.number)
+		if row.line > 0 {
+			where = fmt.Sprintf("line %d", row.line)
+		}
+		if row.number < 1 {
+			return nil, fmt.Errorf("%s: bad match number", where)
+		}
+		if seen[row.number] {
+			return nil, fmt.Errorf("%s: match %d appears twice", where, row.number)
+		}
+		seen[row.number] = true
+
+		teams := map[string]bool{}
+		for _, t := range append(row.red[:], row.blue[:]...) {
+			if t == "" {
+				return nil, fmt.Errorf("%s: every match needs six teams", where)
+			}
+			if teams[t] {
+				return nil, fmt.Errorf("%s: team %s is in the match twice", where, t)
+			}
+			teams[t] = true
+		}
+
+		id := matchID{EventKey: eventKey, CompLevel: "qm", SetNumber: 1, MatchNumber: row.number}
+		m := Match{Key: id.Key(), CompLevel: id.CompLevel, SetNumber: id.SetNumber, MatchNumber: id.MatchNumber}
+		m.Alliances.Red.TeamKeys = withFRC(row.red[:])
+		m.Alliances.Blue.TeamKeys = withFRC(row.blue[:])
+		matches = append(matches, m)
+	}
+	sortMatches(matches)
+	return matches, nil
+}
+
+func withFRC(teams []string) []string {
+	keys := make([]string, len(teams))
+	for i, t := range teams {
+		keys[i] = "frc" + t
+	}
+	return keys
+}
+
+// ── Admin handlers ────────────────────────────────────────────────────────────
+
+// apiCustomEventSaveHandler creates or updates a custom event. An uploaded
+// schedule file, if any, replaces the event's schedule.
+func apiCustomEventSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseMultipartForm(maxScheduleUpload); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		renderCustomEventList(w, r, "Upload failed: "+err.Error())
+		return
+	}
+
+	key := strings.ToLower(strings.TrimSpace(r.FormValue("event_key")))
+	name := strings.TrimSpace(r.FormValue("name"))
+	startDate := strings.TrimSpace(r.FormValue("start_date"))
+	switch {
+	case !customEventKeyPattern.MatchString(key):
+		renderCustomEventList(w, r, "Event key must be a year, a hyphen, then letters or digits, e.g. 2026-scrim.")
+		return
+	case name == "":
+		renderCustomEventList(w, r, "Event name required.")
+		return
+	}
+	if _, err := time.Parse("2006-01-02", startDate); err != nil {
+		renderCustomEventList(w, r, "Start date required.")
+		return
+	}
+
+	var matches []Match
+	if file, _, err := r.FormFile("schedule"); err == nil {
+		defer file.Close()
+		data, err := io.ReadAll(io.LimitReader(file, maxScheduleUpload))
+		if err != nil {
+			renderCustomEventList(w, r, "Upload failed: "+err.Error())
+			return
+		}
+		if len(bytes.TrimSpace(data)) > 0 {
+			if matches, err = parseSchedule(data, key); err != nil {
+				renderCustomEventList(w, r, "Schedule not imported: "+err.Error())
+				return
+			}
+		}
+	}
+
+	if err := saveCustomEvent(r.Context(), key, name, startDate, matches); err != nil {
+		renderCustomEventList(w, r, "DB error: "+err.Error())
+		return
+	}
+	msg := "Saved " + key + "."
+	if matches != nil {
+		msg = fmt.Sprintf("Saved %s with %d qualification matches.", key, len(matches))
+	}
+	renderCustomEventList(w, r, msg)
+}
+
+// apiCustomEventDeleteHandler removes a custom event and its schedule.
+// Scouting data for the event is kept; clear it separately.
+func apiCustomEventDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	key := r.FormValue("event_key")
+	if err := repo.CustomEvents.Delete(r.Context(), key); err != nil {
+		log.Printf("%v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	renderCustomEventList(w, r, "Deleted "+key+".")
+}
+
+func renderCustomEventList(w http.ResponseWriter, r *http.Request, message string) {
+	events, err := listCustomEvents(r.Context())
+	if err != nil {
+		log.Printf("%v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	templates.CustomEventList(events, message).Render(r.Context(), w)
+}
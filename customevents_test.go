@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// scheduleSummary is "qm1: 254 1678 971 v 118 148 2056; qm2: …".
+func scheduleSummary(matches []Match) string {
+	var parts []string
+	for _, m := range matches {
+		_, label, _ := strings.Cut(m.Key, "_")
+		parts = append(parts, fmt.Sprintf("%s: %s v %s", label,
+			strings.Join(stripFRC(m.Alliances.Red.TeamKeys), " "), strings.Join(stripFRC(m.Alliances.Blue.TeamKeys), " ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestParseSchedule(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		data    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "FMS report CSV",
+			data: "Qualification Schedule,,,,,,\n" +
+				"Description,Red 1,Red 2,Red 3,Blue 1,Blue 2,Blue 3\n" +
+				"Qualification 2,frc111,222,333*,444,555,666\n" +
+				"Qualification 1,254,1678,971,118,148,2056\n" +
+				",,,,,,\n",
+			want: "qm1: 254 1678 971 v 118 148 2056; qm2: 111 222 333 v 444 555 666",
+		},
+		{
+			name: "no match column",
+			data: "R1,R2,R3,B1,B2,B3\n254,1678,971,118,148,2056\n111,222,333,444,555,666\n",
+			want: "qm1: 254 1678 971 v 118 148 2056; qm2: 111 222 333 v 444 555 666",
+		},
+		{
+			name: "no header",
+			data: "1,254,1678,971,118,148,2056\n",
+			want: "qm1: 254 1678 971 v 118 148 2056",
+		},
+		{
+			name: "FMS JSON",
+			data: `{"Schedule": [
+				{"description": "Qualification 1", "tournamentLevel": "Qualification", "matchNumber": 1, "teams": [
+					{"teamNumber": 254, "station": "Red1"}, {"teamNumber": 1678, "station": "Red2"}, {"teamNumber": 971, "station": "Red3"},
+					{"teamNumber": 118, "station": "Blue1"}, {"teamNumber": 148, "station": "Blue2"}, {"teamNumber": 2056, "station": "Blue3"}]},
+				{"description": "Playoff 1", "tournamentLevel": "Playoff", "matchNumber": 1, "teams": []}]}`,
+			want: "qm1: 254 1678 971 v 118 148 2056",
+		},
+		{
+			name:    "team twice in a CSV match",
+			data:    "Match,Red 1,Red 2,Red 3,Blue 1,Blue 2,Blue 3\n1,254,1678,971,118,148,2056\n2,254,222,333,444,555,254\n",
+			wantErr: "line 3: team 254 is in the match twice",
+		},
+		{
+			name: "team twice in a JSON match",
+			data: `{"Schedule": [{"matchNumber": 4, "teams": [
+				{"teamNumber": 254, "station": "Red1"}, {"teamNumber": 1678, "station": "Red2"}, {"teamNumber": 971, "station": "Red3"},
+				{"teamNumber": 118, "station": "Blue1"}, {"teamNumber": 254, "station": "Blue2"}, {"teamNumber": 2056, "station": "Blue3"}]}]}`,
+			wantErr: "match 4: team 254 is in the match twice",
+		},
+		{
+			name:    "five teams in a CSV match",
+			data:    "Match,Red 1,Red 2,Red 3,Blue 1,Blue 2,Blue 3\n1,254,1678,971,118,148,\n",
+			wantErr: "line 2: every match needs six teams",
+		},
+		{
+			name: "four teams in a JSON match",
+			data: `{"Schedule": [{"matchNumber": 1, "teams": [
+				{"teamNumber": 254, "station": "Red1"}, {"teamNumber": 1678, "station": "Red2"},
+				{"teamNumber": 118, "station": "Blue1"}, {"teamNumber": 148, "station": "Blue2"}]}]}`,
+			wantErr: "match 1: every match needs six teams",
+		},
+		{
+			name:    "match number twice",
+			data:    "Match,Red 1,Red 2,Red 3,Blue 1,Blue 2,Blue 3\n1,254,1678,971,118,148,2056\n1,111,222,333,444,555,666\n",
+			wantErr: "line 3: match 1 appears twice",
+		},
+		{
+			name:    "no match number",
+			data:    "Match,Red 1,Red 2,Red 3,Blue 1,Blue 2,Blue 3\nFinal,254,1678,971,118,148,2056\n",
+			wantErr: `line 2: no match number in "Final"`,
+		},
+		{
+			name:    "no team columns",
+			data:    "Match,Team,Alliance\n1,254,red\n",
+			wantErr: "couldn't find a header row",
+		},
+		{
+			name:    "header only",
+			data:    "Match,Red 1,Red 2,Red 3,Blue 1,Blue 2,Blue 3\n",
+			wantErr: "the schedule has no matches",
+		},
+		{
+			name:    "broken JSON",
+			data:    `{"Schedule": [`,
+			wantErr: "not a valid FMS schedule export",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			matches, err := parseSchedule([]byte(tc.data), "2026-scrim")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := scheduleSummary(matches); got != tc.want {
+				t.Errorf("got  %s\nwant %s", got, tc.want)
+			}
+			for _, m := range matches {
+				if !strings.HasPrefix(m.Key, "2026-scrim_qm") || m.CompLevel != "qm" || m.SetNumber != 1 {
+					t.Errorf("match %+v isn't a 2026-scrim qual", m)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomEventKeyPattern(t *testing.T) {
+	for key, want := range map[string]bool{
+		"2026-scrim":    true,
+		"2026-week0":    true,
+		"2025-chezy":    true,
+		"2026scrim":     false, // TBA's shape; would hide a real event
+		"2026cmptx":     false,
+		"26-scrim":      false,
+		"2026-":         false,
+		"2026-Scrim":    false,
+		"2026-scrim-2":  false,
+		"2026_scrim":    false,
+		"2026-scr im":   false,
+		" 2026-scrim":   false,
+		"2026-scrim\n":  false,
+		"../2026-scrim": false,
+	} {
+		if got := customEventKeyPattern.MatchString(key); got != want {
+			t.Errorf("%q: got %v, want %v", key, got, want)
+		}
+	}
+}
+
+func postCustomEvent(t *testing.T, key, schedule string) string {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("event_key", key)
+	mw.WriteField("name", "Scrimmage")
+	mw.WriteField("start_date", "2026-10-03")
+	fw, err := mw.CreateFormFile("schedule", "schedule.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(schedule))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/custom-events/save", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	apiCustomEventSaveHandler(w, req)
+	return w.Body.String()
+}
+
+func TestCustomEventSave(t *testing.T) {
+	useTestDB(t)
+	ctx := context.Background()
+	const schedule = "Match,Red 1,Red 2,Red 3,Blue 1,Blue 2,Blue 3\n1,254,1678,971,118,148,2056\n"
+
+	for _, key := range []string{"2026cmptx", "2026-", "scrim"} {
+		if out := postCustomEvent(t, key, schedule); !strings.Contains(out, "Event key must be") {
+			t.Errorf("%q: accepted: %s", key, out)
+		}
+		if custom, err := isCustomEvent(ctx, key); err != nil || custom {
+			t.Errorf("%q: saved (%v)", key, err)
+		}
+	}
+
+	if out := postCustomEvent(t, "2026-Scrim", schedule); !strings.Contains(out, "Saved 2026-scrim with 1 qualification matches.") {
+		t.Fatalf("not saved: %s", out)
+	}
+	matches, ok, err := customEventMatches(ctx, "2026-scrim")
+	if err != nil || !ok {
+		t.Fatalf("custom event missing: %v", err)
+	}
+	if got := scheduleSummary(matches); got != "qm1: 254 1678 971 v 118 148 2056" {
+		t.Errorf("stored schedule: %s", got)
+	}
+
+	// A bad schedule leaves the saved one alone.
+	if out := postCustomEvent(t, "2026-scrim", "Match,Red 1,Red 2,Red 3,Blue 1,Blue 2,Blue 3\n1,254,254,971,118,148,2056\n"); !strings.Contains(out, "Schedule not imported") {
+		t.Errorf("bad schedule accepted: %s", out)
+	}
+	if matches, _, _ := customEventMatches(ctx, "2026-scrim"); len(matches) != 1 {
+		t.Errorf("%d matches after a rejected upload, want 1", len(matches))
+	}
+}
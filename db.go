@@ -4,62 +4,39 @@ import (
 	"database/sql"
 	"os"
 	"path/filepath"
+
+	"vibe-scout/store"
 )
 
-var db *sql.DB
+var (
+	db   *sql.DB
+	repo *store.Store
+)
 
-func initDB() {
-	var err error
+// timestampFormat is how pages show when something was saved.
+const timestampFormat = "2006-01-02 15:04"
 
+// dbPath is the Railway volume when one is mounted, else ./vibe_scout.db.
+func dbPath() string {
 	if mountPath := os.Getenv("RAILWAY_VOLUME_MOUNT_PATH"); mountPath != "" {
 		// Only use the Railway path if the directory actually exists
-		if info, statErr := os.Stat(mountPath); statErr == nil && info.IsDir() {
-			db, err = sql.Open("sqlite", filepath.Join(mountPath, "vibescout.db"))
-		} else {
-			db, err = sql.Open("sqlite", "./vibe_scout.db")
+		if info, err := os.Stat(mountPath); err == nil && info.IsDir() {
+			return filepath.Join(mountPath, "vibescout.db")
 		}
-	} else {
-		db, err = sql.Open("sqlite", "./vibe_scout.db")
 	}
+	return "./vibe_scout.db"
+}
 
+// openDB opens the database without migrating it. Every connection gets WAL,
+// so scouting saves don't block on a running job, foreign keys, and a busy
+// timeout for concurrent writers.
+func openDB() error {
+	var err error
+	db, err = sql.Open("sqlite", dbPath()+
+		"?_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
 	if err != nil {
-		panic(err)
+		return err
 	}
-
-	db.Exec(`
-    CREATE TABLE IF NOT EXISTS scout_submissions (
-      id INTEGER PRIMARY KEY AUTOINCREMENT,
-      event_key TEXT,
-      match_num INTEGER,
-      scouter_id INTEGER,
-      team_number TEXT,
-      notes TEXT,
-      created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-    );`)
-
-	db.Exec(`
-    CREATE TABLE IF NOT EXISTS analysis_cache (
-      id INTEGER PRIMARY KEY AUTOINCREMENT,
-      event_key TEXT NOT NULL,
-      team_number TEXT NOT NULL,
-      analysis TEXT NOT NULL,
-      notes_hash TEXT NOT NULL,
-      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-      UNIQUE(event_key, team_number)
-    );`)
-
-	db.Exec(`
-    CREATE TABLE IF NOT EXISTS match_plan_cache (
-      id INTEGER PRIMARY KEY AUTOINCREMENT,
-      event_key TEXT NOT NULL,
-      team_number TEXT NOT NULL,
-      match_num INTEGER NOT NULL,
-      strategy TEXT NOT NULL,
-      notes_hash TEXT NOT NULL,
-      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-      UNIQUE(event_key, team_number, match_num)
-    );`)
-
-	// Idempotent migration: add ai_generated flag if it doesn't exist yet
-	db.Exec(`ALTER TABLE scout_submissions ADD COLUMN ai_generated INTEGER DEFAULT 0`)
+	repo = store.New(db)
+	return db.Ping()
 }
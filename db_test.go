@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"vibe-scout/store"
+)
+
+// useTestDB points db and repo at a fresh, migrated database in a temporary
+// directory for the rest of the test.
+func useTestDB(t *testing.T) {
+	t.Helper()
+	t.Setenv("RAILWAY_VOLUME_MOUNT_PATH", t.TempDir())
+	if err := openDB(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := store.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exports get an event's data out for spreadsheets: one dataset as CSV or
+// newline-delimited JSON, or every dataset as an XLSX workbook with a sheet
+// each. Every dataset has an ai_generated column so LLM output can't be
+// mistaken for what a scouter saw.
+
+// exportTable is one dataset. Cells are string, int, float64, bool or nil.
+type exportTable struct {
+	Name    string
+	Columns []string
+	Rows    [][]any
+}
+
+// exportDatasets are the datasets in workbook sheet order.
+var exportDatasets = []struct {
+	Name  string
+	Build func(ctx context.Context, eventKey string) (exportTable, error)
+}{
+	{"submissions", exportSubmissions},
+	{"analyses", exportAnalyses},
+	{"match_plans", exportMatchPlans},
+	{"results", exportResults},
+	{"epa", exportEPA},
+}
+
+const exportTimeFormat = "2006-01-02 15:04:05"
+
+func exportTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(exportTimeFormat)
+}
+
+func exportSubmissions(ctx context.Context, eventKey string) (exportTable, error) {
+	subs, err := repo.Submissions.ForEvent(ctx, eventKey)
+	if err != nil {
+		return exportTable{}, err
+	}
+
+	// One column per structured field: the season's form order, then any
+	// keys from older schema versions.
+	var metricKeys []string
+	seen := map[string]bool{}
+	if schema, ok := gameSchemaFor(eventKey); ok {
+		for _, f := range schema.Fields {
+			metricKeys = append(metricKeys, f.Key)
+			seen[f.Key] = true
+		}
+	}
+	var extra []string
+	for _, s := range subs {
+		for _, m := range s.Metrics {
+			if !seen[m.Key] {
+				seen[m.Key] = true
+				extra = append(extra, m.Key)
+			}
+		}
+	}
+	sort.Strings(extra)
+	metricKeys = append(metricKeys, extra...)
+
+	t := exportTable{
+		Name: "submissions",
+		Columns: append([]string{
+			"match_key", "match", "team_number", "scouter_id", "scouter", "source", "ai_generated", "created_at", "notes",
+		}, metricKeys...),
+	}
+	for _, s := range subs {
+		label := s.MatchKey
+		if id, err := parseMatchKey(s.MatchKey); err == nil {
+			label = id.Label()
+		}
+		row := []any{s.MatchKey, label, s.TeamNumber, s.ScouterID, s.ScouterName, s.Source, s.AIGenerated, exportTime(s.CreatedAt), s.Notes}
+		values := map[string]any{}
+		for _, m := range s.Metrics {
+			switch {
+			case m.IntValue != nil:
+				values[m.Key] = *m.IntValue
+			case m.TextValue != nil:
+				values[m.Key] = *m.TextValue
+			}
+		}
+		for _, k := range metricKeys {
+			row = append(row, values[k])
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t, nil
+}
+
+func exportAnalyses(ctx context.Context, eventKey string) (exportTable, error) {
+	analyses, err := repo.Analysis.ForEvent(ctx, eventKey)
+	if err != nil {
+		return exportTable{}, err
+	}
+	t := exportTable{
+		Name:    "analyses",
+		Columns: []string{"team_number", "scoring", "reliability", "defense", "summary", "ai_generated", "created_at"},
+	}
+	for _, a := range analyses {
+		var result teamAnalysisJSON
+		if err := json.Unmarshal([]byte(a.Text), &result); err != nil {
+			log.Printf("export: %s team %s analysis: %v", eventKey, a.TeamNumber, err)
+			continue
+		}
+		var defense any = result.Defense
+		if result.Defense == 0 {
+			defense = nil // N/A
+		}
+		t.Rows = append(t.Rows, []any{
+			a.TeamNumber, result.Scoring, result.Reliability, defense, result.Summary, true, exportTime(a.CreatedAt),
+		})
+	}
+	return t, nil
+}
+
+func exportMatchPlans(ctx context.Context, eventKey string) (exportTable, error) {
+	plans, err := repo.MatchPlans.ForEvent(ctx, eventKey)
+	if err != nil {
+		return exportTable{}, err
+	}
+	t := exportTable{
+		Name:    "match_plans",
+		Columns: []string{"match_key", "match", "team_number", "strategy", "ai_generated", "created_at"},
+	}
+	for _, p := range plans {
+		label := p.MatchKey
+		if id, err := parseMatchKey(p.MatchKey); err == nil {
+			label = id.Label()
+		}
+		t.Rows = append(t.Rows, []any{p.MatchKey, label, p.TeamNumber, p.Text, true, exportTime(p.CreatedAt)})
+	}
+	return t, nil
+}
+
+// exportResults is the official score of every played match, with each
+// alliance's TBA score breakdown as JSON.
+func exportResults(ctx context.Context, eventKey string) (exportTable, error) {
+	results, err := repo.Results.ForEvent(ctx, eventKey)
+	if err != nil {
+		return exportTable{}, err
+	}
+	t := exportTable{
+		Name: "results",
+		Columns: []string{
+			"match_key", "match", "red_teams", "blue_teams", "red_score", "blue_score", "winning_alliance",
+			"red_breakdown", "blue_breakdown", "ai_generated", "played_at",
+		},
+	}
+	for _, r := range results {
+		row := matchResultRow(r)
+		t.Rows = append(t.Rows, []any{
+			r.MatchKey, row.Label, strings.Join(r.RedTeams, " "), strings.Join(r.BlueTeams, " "), r.RedScore, r.BlueScore,
+			r.WinningAlliance, exportJSON(r.RedBreakdown), exportJSON(r.BlueBreakdown), false, exportTime(r.PlayedAt),
+		})
+	}
+	return t, nil
+}
+
+func exportJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// exportEPA is each event team's current Statbotics EPA breakdown, as of
+// fetched_at.
+func exportEPA(ctx context.Context, eventKey string) (exportTable, error) {
+	teams := eventTeams(ctx, eventKey)
+	breakdowns := fetchEPABreakdowns(ctx, teams)
+
+	keySet := map[string]bool{}
+	for _, b := range breakdowns {
+		for k := range b {
+			keySet[k] = true
+		}
+	}
+	var keys []string
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sortEPAKeys(keys)
+
+	t := exportTable{
+		Name:    "epa",
+		Columns: append([]string{"team_number", "ai_generated", "fetched_at"}, keys...),
+	}
+	for _, team := range teams {
+		b, ok := breakdowns[team]
+		if !ok {
+			continue
+		}
+		var fetchedAt any
+		c, ok, err := loadCachedResponse(ctx, statboticsTeamYearURL(team))
+		if err != nil {
+			return exportTable{}, err
+		}
+		if ok {
+			fetchedAt = exportTime(c.FetchedAt)
+		}
+		row := []any{team, false, fetchedAt}
+		for _, k := range keys {
+			if v, ok := b[k]; ok {
+				row = append(row, v)
+			} else {
+				row = append(row, nil)
+			}
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t, nil
+}
+
+// ── Handler ───────────────────────────────────────────────────────────────────
+
+// exportHandler serves /api/admin/export?event_key=…&format=csv|ndjson|xlsx
+// &dataset=…; dataset is required for CSV and NDJSON and ignored for XLSX.
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	eventKey := r.URL.Query().Get("event_key")
+	format := r.URL.Query().Get("format")
+	dataset := r.URL.Query().Get("dataset")
+	if eventKey == "" {
+		http.Error(w, "Event key required", http.StatusBadRequest)
+		return
+	}
+	if format != "csv" && format != "ndjson" && format != "xlsx" {
+		http.Error(w, "Format must be csv, ndjson or xlsx", http.StatusBadRequest)
+		return
+	}
+
+	var builders []func(context.Context, string) (exportTable, error)
+	for _, d := range exportDatasets {
+		if format == "xlsx" || d.Name == dataset {
+			builders = append(builders, d.Build)
+		}
+	}
+	if len(builders) == 0 {
+		http.Error(w, "Unknown dataset", http.StatusBadRequest)
+		return
+	}
+
+	var tables []exportTable
+	for _, build := range builders {
+		t, err := build(r.Context(), eventKey)
+		if err != nil {
+			log.Printf("export %s: %v", eventKey, err)
+			http.Error(w, "DB error", http.StatusInternalServerError)
+			return
+		}
+		tables = append(tables, t)
+	}
+
+	// Build the whole file before writing so a failure is still an error page
+	var buf bytes.Buffer
+	var err error
+	var contentType, name string
+	switch format {
+	case "csv":
+		contentType, name = "text/csv; charset=utf-8", eventKey+"-"+dataset+".csv"
+		err = writeCSV(&buf, tables[0])
+	case "ndjson":
+		contentType, name = "application/x-ndjson", eventKey+"-"+dataset+".ndjson"
+		err = writeNDJSON(&buf, tables[0])
+	case "xlsx":
+		contentType, name = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", eventKey+".xlsx"
+		err = writeXLSX(&buf, tables)
+	}
+	if err != nil {
+		log.Printf("export %s: %v", eventKey, err)
+		http.Error(w, "Export failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "vibescout-"+name))
+	w.Write(buf.Bytes())
+}
+
+// ── Writers ───────────────────────────────────────────────────────────────────
+
+func exportCellText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func writeCSV(w io.Writer, t exportTable) error {
+	cw := csv.NewWriter(w)
+	cw.Write(t.Columns)
+	for _, row := range t.Rows {
+		rec := make([]string, len(row))
+		for i, v := range row {
+			rec[i] = exportCellText(v)
+		}
+		cw.Write(rec)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeNDJSON writes one object per row with keys in column order. Notes
+// are left unescaped (no \u003c for <) since nothing renders them as HTML.
+func writeNDJSON(w io.Writer, t exportTable) error {
+	var line bytes.Buffer
+	enc := json.NewEncoder(&line)
+	enc.SetEscapeHTML(false)
+	for _, row := range t.Rows {
+		line.Reset()
+		line.WriteByte('{')
+		for i, col := range t.Columns {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			// Encode appends a newline after each value; trim it
+			enc.Encode(col)
+			line.Truncate(line.Len() - 1)
+			line.WriteByte(':')
+			if err := enc.Encode(row[i]); err != nil {
+				return err
+			}
+			line.Truncate(line.Len() - 1)
+		}
+		line.WriteString("}\n")
+		if _, err := w.Write(line.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeXLSX writes a minimal Office Open XML workbook, one sheet per table,
+// with inline strings so no shared string table is needed.
+func writeXLSX(w io.Writer, tables []exportTable) error {
+	zw := zip.NewWriter(w)
+	add := func(name, content string) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, xml.Header+content)
+		return err
+	}
+
+	var overrides, sheets, rels bytes.Buffer
+	for i, t := range tables {
+		n := i + 1
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(t.Name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			overrides.String() + `</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+	}
+	for _, p := range parts {
+		if err := add(p.name, p.content); err != nil {
+			return err
+		}
+	}
+	for i, t := range tables {
+		if err := add(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxSheet(t)); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func xlsxSheet(t exportTable) string {
+	var b bytes.Buffer
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	writeRow := func(r int, cells []any) {
+		fmt.Fprintf(&b, `<row r="%d">`, r)
+		for c, v := range cells {
+			ref := xlsxColumn(c) + strconv.Itoa(r)
+			switch v := v.(type) {
+			case nil:
+			case int, float64:
+				fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, exportCellText(v))
+			case bool:
+				val := 0
+				if v {
+					val = 1
+				}
+				fmt.Fprintf(&b, `<c r="%s" t="b"><v>%d</v></c>`, ref, val)
+			default:
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(exportCellText(v)))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+
+	header := make([]any, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = c
+	}
+	writeRow(1, header)
+	for i, row := range t.Rows {
+		writeRow(i+2, row)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// xlsxColumn is the spreadsheet column name for a 0-based index: A, B, … Z, AA.
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
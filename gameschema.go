@@ -0,0 +1,189 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"vibe-scout/templates"
+)
+
+//go:embed games/*.json
+var gameSchemaFS embed.FS
+
+// GameSchema describes the structured scouting fields for one FRC season.
+// Bump Version whenever a field is added, removed or changes meaning so stored
+// metrics can be traced back to the form that produced them.
+type GameSchema struct {
+	Season  int               `json:"season"`
+	Version int               `json:"version"`
+	Fields  []GameSchemaField `json:"fields"`
+}
+
+type GameSchemaField struct {
+	Key     string   `json:"key"`
+	Label   string   `json:"label"`
+	Section string   `json:"section"`
+	Type    string   `json:"type"` // "counter", "checkbox" or "enum"
+	Min     int      `json:"min"`
+	Max     int      `json:"max"`
+	Options []string `json:"options"`
+	Default string   `json:"default"`
+}
+
+const (
+	fieldCounter  = "counter"
+	fieldCheckbox = "checkbox"
+	fieldEnum     = "enum"
+)
+
+var gameSchemas = map[int]GameSchema{}
+
+// loadGameSchemas parses every embedded games/<season>.json file. A malformed
+// schema is a deploy bug, so the error is returned for main to abort on.
+func loadGameSchemas() error {
+	entries, err := gameSchemaFS.ReadDir("games")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		raw, err := gameSchemaFS.ReadFile(path.Join("games", e.Name()))
+		if err != nil {
+			return err
+		}
+		var s GameSchema
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("game schema %s: %w", e.Name(), err)
+		}
+		if err := s.check(); err != nil {
+			return fmt.Errorf("game schema %s: %w", e.Name(), err)
+		}
+		gameSchemas[s.Season] = s
+	}
+	return nil
+}
+
+func (s GameSchema) check() error {
+	if s.Season == 0 || s.Version == 0 {
+		return fmt.Errorf("season and version are required")
+	}
+	seen := map[string]bool{}
+	for _, f := range s.Fields {
+		if f.Key == "" || seen[f.Key] {
+			return fmt.Errorf("missing or duplicate field key %q", f.Key)
+		}
+		seen[f.Key] = true
+		switch f.Type {
+		case fieldCounter:
+			if f.Max < f.Min {
+				return fmt.Errorf("field %s: max < min", f.Key)
+			}
+		case fieldCheckbox:
+		case fieldEnum:
+			if len(f.Options) == 0 {
+				return fmt.Errorf("field %s: enum has no options", f.Key)
+			}
+		default:
+			return fmt.Errorf("field %s: unknown type %q", f.Key, f.Type)
+		}
+	}
+	return nil
+}
+
+// gameSchemaFor picks the schema for an event's season (the first four
+// characters of the event key), falling back to the current year.
+func gameSchemaFor(eventKey string) (GameSchema, bool) {
+	if len(eventKey) >= 4 {
+		if year, err := strconv.Atoi(eventKey[:4]); err == nil {
+			if s, ok := gameSchemas[year]; ok {
+				return s, true
+			}
+		}
+	}
+	s, ok := gameSchemas[time.Now().Year()]
+	return s, ok
+}
+
+// formFields converts the schema into the view model used by ScoutPage.
+func (s GameSchema) formFields() []templates.ScoutFormField {
+	fields := make([]templates.ScoutFormField, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		fields = append(fields, templates.ScoutFormField{
+			Key:     f.Key,
+			Label:   f.Label,
+			Section: f.Section,
+			Type:    f.Type,
+			Min:     f.Min,
+			Max:     f.Max,
+			Options: f.Options,
+			Default: f.Default,
+		})
+	}
+	return fields
+}
+
+// scoutMetric is one validated field value ready to be stored in scout_metrics.
+type scoutMetric struct {
+	Key       string
+	IntValue  *int
+	TextValue *string
+}
+
+// validateMetrics checks a team's submitted field values against the schema.
+// Unknown keys are rejected; missing keys are simply not stored.
+func (s GameSchema) validateMetrics(values map[string]json.RawMessage) ([]scoutMetric, error) {
+	byKey := make(map[string]GameSchemaField, len(s.Fields))
+	for _, f := range s.Fields {
+		byKey[f.Key] = f
+	}
+
+	var metrics []scoutMetric
+	for key, raw := range values {
+		f, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", key)
+		}
+		switch f.Type {
+		case fieldCounter:
+			var n int
+			if err := json.Unmarshal(raw, &n); err != nil {
+				return nil, fmt.Errorf("%s must be an integer", key)
+			}
+			if n < f.Min || n > f.Max {
+				return nil, fmt.Errorf("%s must be between %d and %d", key, f.Min, f.Max)
+			}
+			metrics = append(metrics, scoutMetric{Key: key, IntValue: &n})
+		case fieldCheckbox:
+			var b bool
+			if err := json.Unmarshal(raw, &b); err != nil {
+				return nil, fmt.Errorf("%s must be true or false", key)
+			}
+			n := 0
+			if b {
+				n = 1
+			}
+			metrics = append(metrics, scoutMetric{Key: key, IntValue: &n})
+		case fieldEnum:
+			var v string
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return nil, fmt.Errorf("%s must be a string", key)
+			}
+			valid := false
+			for _, o := range f.Options {
+				if o == v {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return nil, fmt.Errorf("%s must be one of %s", key, strings.Join(f.Options, ", "))
+			}
+			metrics = append(metrics, scoutMetric{Key: key, TextValue: &v})
+		}
+	}
+	return metrics, nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	geminiModel   = "gemini-3.1-flash-lite-preview"
+)
+
+// geminiProvider calls the Gemini generateContent API.
+type geminiProvider struct {
+	baseURL string
+	model   string
+	apiKey  string
+}
+
+func newGeminiProvider(baseURL, model, apiKey string) *geminiProvider {
+	if baseURL == "" {
+		baseURL = geminiBaseURL
+	}
+	if model == "" {
+		model = geminiModel
+	}
+	return &geminiProvider{baseURL: strings.TrimSuffix(baseURL, "/"), model: model, apiKey: apiKey}
+}
+
+func (g *geminiProvider) Name() string { return "gemini/" + g.model }
+
+func (g *geminiProvider) GenerateText(ctx context.Context, req LLMRequest) (string, error) {
+	return g.generate(ctx, []map[string]interface{}{{"text": req.Prompt}}, nil)
+}
+
+func (g *geminiProvider) GenerateJSON(ctx context.Context, req LLMRequest, schema json.RawMessage) (string, error) {
+	config := map[string]interface{}{"responseMimeType": "application/json"}
+	if schema != nil {
+		config["responseJsonSchema"] = schema
+	}
+	return g.generate(ctx, []map[string]interface{}{{"text": req.Prompt}}, config)
+}
+
+func (g *geminiProvider) GenerateMultimodal(ctx context.Context, req LLMRequest, media []LLMMedia) (string, error) {
+	var parts []map[string]interface{}
+	for _, m := range media {
+		parts = append(parts, map[string]interface{}{
+			"fileData": map[string]string{"mimeType": m.MimeType, "fileUri": m.URI},
+		})
+	}
+	parts = append(parts, map[string]interface{}{"text": req.Prompt})
+	return g.generate(ctx, parts, nil)
+}
+
+// post sends a generateContent-style request; method is "generateContent"
+// or "streamGenerateContent?alt=sse".
+func (g *geminiProvider) post(ctx context.Context, method string, parts []map[string]interface{}, config map[string]interface{}) (*http.Response, error) {
+	if g.apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY not set")
+	}
+
+	payload := map[string]interface{}{
+		"contents": []map[string]interface{}{{"parts": parts}},
+	}
+	if config != nil {
+		payload["generationConfig"] = config
+	}
+
+	sep := "?"
+	if strings.Contains(method, "?") {
+		sep = "&"
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/models/%s:%s%skey=%s", g.baseURL, g.model, method, sep, g.apiKey), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return outbound.Do(req)
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		FinishReason string `json:"finishReason"`
+		Content      struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+}
+
+func (g *geminiProvider) generate(ctx context.Context, parts []map[string]interface{}, config map[string]interface{}) (string, error) {
+	resp, err := g.post(ctx, "generateContent", parts, config)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var geminiResp geminiResponse
+	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
+		return "", fmt.Errorf("gemini parse error: %v — body: %s", err, string(respBody))
+	}
+	if geminiResp.PromptFeedback.BlockReason != "" {
+		return "", fmt.Errorf("gemini blocked prompt: %s", geminiResp.PromptFeedback.BlockReason)
+	}
+	if len(geminiResp.Candidates) == 0 {
+		return "", fmt.Errorf("gemini returned no candidates — body: %s", string(respBody))
+	}
+	c := geminiResp.Candidates[0]
+	if len(c.Content.Parts) == 0 {
+		return "", fmt.Errorf("gemini candidate has no content (finishReason: %s)", c.FinishReason)
+	}
+	return c.Content.Parts[0].Text, nil
+}
+
+// StreamText uses streamGenerateContent, which sends a partial response per
+// server-sent event.
+func (g *geminiProvider) StreamText(ctx context.Context, req LLMRequest, onChunk func(string)) (string, error) {
+	resp, err := g.post(ctx, "streamGenerateContent?alt=sse", []map[string]interface{}{{"text": req.Prompt}}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("gemini returned %s — body: %s", resp.Status, string(body))
+	}
+
+	var full strings.Builder
+	var finishReason string
+	err = readSSE(resp.Body, func(data []byte) error {
+		var chunk geminiResponse
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("gemini parse error: %v — chunk: %s", err, string(data))
+		}
+		if chunk.PromptFeedback.BlockReason != "" {
+			return fmt.Errorf("gemini blocked prompt: %s", chunk.PromptFeedback.BlockReason)
+		}
+		if len(chunk.Candidates) == 0 {
+			return nil
+		}
+		c := chunk.Candidates[0]
+		if c.FinishReason != "" {
+			finishReason = c.FinishReason
+		}
+		for _, part := range c.Content.Parts {
+			if part.Text != "" {
+				full.WriteString(part.Text)
+				onChunk(part.Text)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if full.Len() == 0 {
+		return "", fmt.Errorf("gemini stream had no content (finishReason: %s)", finishReason)
+	}
+	return full.String(), nil
+}
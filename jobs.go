@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"vibe-scout/store"
+)
+
+// Slow AI work runs as jobs so it survives the browser tab closing and so an
+// event-wide analysis can't fire forty LLM calls at once. Jobs live in the
+// jobs table; a fixed pool of workers claims them oldest first. Pages render
+// a placeholder that fetches /api/jobs/status once the job finishes, told by
+// the /api/jobs/events stream (jobstream.go) or, failing that, by polling.
+
+const (
+	jobQueued  = store.JobQueued
+	jobRunning = store.JobRunning
+	jobDone    = store.JobDone
+	jobFailed  = store.JobFailed
+)
+
+const (
+	defaultJobWorkers = 3
+	jobTimeout        = 10 * time.Minute
+	jobPollInterval   = 2 * time.Second
+)
+
+type job struct {
+	ID          int64
+	Kind        string
+	Params      json.RawMessage
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	Result      json.RawMessage
+	Error       string
+	Ahead       int // queued jobs in front of this one
+}
+
+// jobKind is one kind of background work.
+type jobKind struct {
+	// Role needed to see the job's status and result.
+	Role        role
+	MaxAttempts int
+	// Run does the work; its result is stored as JSON.
+	Run func(ctx context.Context, params json.RawMessage) (interface{}, error)
+	// Render writes the htmx fragment for the job: a polling placeholder
+	// while it's queued or running, the finished card once it's done.
+	Render func(w http.ResponseWriter, r *http.Request, j job)
+}
+
+const (
+	jobTeamAnalysis = "team_analysis"
+	jobMatchPlan    = "match_plan"
+	jobVideoScout   = "video_scout"
+	jobHeadToHead   = "head_to_head"
+)
+
+// jobKinds is filled in by registerJobKinds before the workers start.
+var jobKinds = map[string]jobKind{}
+
+func registerJobKinds() {
+	jobKinds[jobTeamAnalysis] = jobKind{Role: roleStrategist, MaxAttempts: 3, Run: runTeamAnalysisJob, Render: renderTeamAnalysisJob}
+	jobKinds[jobMatchPlan] = jobKind{Role: roleStrategist, MaxAttempts: 3, Run: runMatchPlanJob, Render: renderMatchPlanJob}
+	jobKinds[jobVideoScout] = jobKind{Role: roleAdmin, MaxAttempts: 2, Run: runVideoScoutJob, Render: renderVideoScoutJob}
+	jobKinds[jobHeadToHead] = jobKind{Role: roleStrategist, MaxAttempts: 3, Run: runHeadToHeadJob, Render: renderHeadToHeadJob}
+}
+
+// jobWake nudges an idle worker when a job is enqueued.
+var jobWake = make(chan struct{}, 1)
+
+// enqueueJob queues a job, or returns the existing one if the same kind and
+// dedupeKey is already queued or running.
+func enqueueJob(ctx context.Context, kind, dedupeKey string, params interface{}) (int64, error) {
+	k, ok := jobKinds[kind]
+	if !ok {
+		return 0, fmt.Errorf("unknown job kind %q", kind)
+	}
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := repo.Jobs.Enqueue(ctx, kind, dedupeKey, string(paramsJSON), k.MaxAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	select {
+	case jobWake <- struct{}{}:
+	default:
+	}
+	return id, nil
+}
+
+// loadJob returns store.ErrNotFound if there's no job id.
+func loadJob(ctx context.Context, id int64) (job, error) {
+	stored, err := repo.Jobs.Get(ctx, id)
+	if err != nil {
+		return job{}, err
+	}
+	j := jobFromStore(stored)
+	if j.Status == jobQueued {
+		if j.Ahead, err = repo.Jobs.QueuedAhead(ctx, id); err != nil {
+			return job{}, err
+		}
+	}
+	return j, nil
+}
+
+func jobFromStore(s store.Job) job {
+	j := job{
+		ID:          s.ID,
+		Kind:        s.Kind,
+		Params:      json.RawMessage(s.Params),
+		Status:      s.Status,
+		Attempts:    s.Attempts,
+		MaxAttempts: s.MaxAttempts,
+		Error:       s.Error,
+	}
+	if s.Result != "" {
+		j.Result = json.RawMessage(s.Result)
+	}
+	return j
+}
+
+// StatusText is the placeholder caption, e.g. "Queued (3 ahead)".
+func (j job) StatusText() string {
+	switch j.Status {
+	case jobQueued:
+		if j.Attempts > 0 {
+			return fmt.Sprintf("Retrying (attempt %d of %d failed)", j.Attempts, j.MaxAttempts)
+		}
+		if j.Ahead > 0 {
+			return fmt.Sprintf("Queued (%d ahead)", j.Ahead)
+		}
+		return "Queued"
+	case jobRunning:
+		if j.Attempts > 1 {
+			return fmt.Sprintf("Working (attempt %d of %d)", j.Attempts, j.MaxAttempts)
+		}
+		return "Working"
+	}
+	return j.Status
+}
+
+// claimJob marks the oldest runnable job as running and returns it.
+func claimJob() (job, bool) {
+	stored, err := repo.Jobs.Claim(context.Background())
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Printf("jobs: %v", err)
+		}
+		return job{}, false
+	}
+	j := jobFromStore(stored)
+	publishJobStatus(j)
+	return j, true
+}
+
+func runJob(j job) {
+	k, ok := jobKinds[j.Kind]
+	if !ok {
+		finishJob(j, nil, fmt.Errorf("unknown job kind %q", j.Kind))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, jobCtxKey{}, j.ID)
+
+	result, err := k.Run(ctx, j.Params)
+	finishJob(j, result, err)
+}
+
+// finishJob stores the outcome, requeueing with backoff while attempts remain.
+func finishJob(j job, result interface{}, runErr error) {
+	// The job's own context may have timed out; its outcome is still saved.
+	ctx := context.Background()
+	if runErr == nil {
+		resultJSON, err := json.Marshal(result)
+		if err == nil {
+			if err := repo.Jobs.Finish(ctx, j.ID, string(resultJSON)); err != nil {
+				log.Printf("jobs: %v", err)
+			}
+			j.Status = jobDone
+			publishJobStatus(j)
+			return
+		}
+		runErr = err
+	}
+
+	// Output that already failed validation through the repair loop won't
+	// get better by asking again.
+	var outErr *llmOutputError
+	if j.Attempts < j.MaxAttempts && !errors.As(runErr, &outErr) {
+		delay := backoff(j.Attempts)
+		log.Printf("jobs: %s #%d attempt %d failed, retrying in %s: %v", j.Kind, j.ID, j.Attempts, delay.Round(time.Second), runErr)
+		if err := repo.Jobs.Retry(ctx, j.ID, runErr.Error(), time.Now().Add(delay)); err != nil {
+			log.Printf("jobs: %v", err)
+		}
+		j.Status = jobQueued
+		publishJobStatus(j)
+		return
+	}
+	log.Printf("jobs: %s #%d failed after %d attempts: %v", j.Kind, j.ID, j.Attempts, runErr)
+	if err := repo.Jobs.Fail(ctx, j.ID, runErr.Error()); err != nil {
+		log.Printf("jobs: %v", err)
+	}
+	j.Status = jobFailed
+	publishJobStatus(j)
+}
+
+// startJobWorkers requeues jobs interrupted by a restart and starts
+// JOB_WORKERS workers (default 3).
+func startJobWorkers() {
+	ctx := context.Background()
+	if err := repo.Jobs.RequeueRunning(ctx); err != nil {
+		log.Fatalf("Error requeueing interrupted jobs: %s", err)
+	}
+	if err := repo.Jobs.PruneFinished(ctx); err != nil {
+		log.Printf("jobs: %v", err)
+	}
+
+	n := defaultJobWorkers
+	if v, err := strconv.Atoi(os.Getenv("JOB_WORKERS")); err == nil && v > 0 {
+		n = v
+	}
+	for i := 0; i < n; i++ {
+		go jobWorker()
+	}
+}
+
+func jobWorker() {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			j, ok := claimJob()
+			if !ok {
+				break
+			}
+			runJob(j)
+		}
+		select {
+		case <-jobWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// apiJobStatusHandler renders a job's current fragment; placeholders call it
+// on a timer until the job is done.
+func apiJobStatusHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+	j, err := loadJob(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("job status: %v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	k, ok := jobKinds[j.Kind]
+	if !ok {
+		http.Error(w, "unknown job kind", http.StatusInternalServerError)
+		return
+	}
+	if s, ok := currentSession(r); !ok || s.User.Role < k.Role {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	k.Render(w, r, j)
+}
+
+// jobStatusURL is polled by job placeholders.
+func jobStatusURL(id int64) string {
+	return "/api/jobs/status?id=" + strconv.FormatInt(id, 10)
+}
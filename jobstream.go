@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"vibe-scout/store"
+)
+
+// Live job updates. Workers publish status changes and streamed LLM text to
+// jobHub; /api/jobs/events relays them to the browser as Server-Sent Events.
+
+type jobEvent struct {
+	JobID  int64
+	Status string // set for status changes
+	Text   string // status caption, or a chunk of streamed output
+	Token  bool
+}
+
+type jobHub struct {
+	mu   sync.Mutex
+	subs map[int64]map[*jobSub]struct{}
+	text map[int64]*strings.Builder // output streamed so far by running jobs
+}
+
+var jobEvents = &jobHub{
+	subs: map[int64]map[*jobSub]struct{}{},
+	text: map[int64]*strings.Builder{},
+}
+
+// jobSub is one stream's subscription. Tokens are dropped when the browser
+// falls behind; status changes never are: the latest per job waits in status
+// until the stream takes it, and wake says there's one waiting.
+type jobSub struct {
+	tokens chan jobEvent
+	wake   chan struct{}
+
+	mu     sync.Mutex
+	status map[int64]jobEvent
+}
+
+// takeStatuses returns the status changes waiting since the last call.
+func (s *jobSub) takeStatuses() []jobEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]jobEvent, 0, len(s.status))
+	for _, ev := range s.status {
+		out = append(out, ev)
+	}
+	clear(s.status)
+	return out
+}
+
+// subscribe registers for events on ids and returns the output each running
+// job has streamed so far, taken atomically with the subscription so nothing
+// is missed or repeated.
+func (h *jobHub) subscribe(ids []int64) (*jobSub, map[int64]string, func()) {
+	sub := &jobSub{
+		tokens: make(chan jobEvent, 256),
+		wake:   make(chan struct{}, 1),
+		status: map[int64]jobEvent{},
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	snapshot := map[int64]string{}
+	for _, id := range ids {
+		if h.subs[id] == nil {
+			h.subs[id] = map[*jobSub]struct{}{}
+		}
+		h.subs[id][sub] = struct{}{}
+		if b := h.text[id]; b != nil {
+			snapshot[id] = b.String()
+		}
+	}
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for _, id := range ids {
+			delete(h.subs[id], sub)
+			if len(h.subs[id]) == 0 {
+				delete(h.subs, id)
+			}
+		}
+	}
+	return sub, snapshot, cancel
+}
+
+func (h *jobHub) publish(ev jobEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case ev.Token:
+		if b := h.text[ev.JobID]; b != nil {
+			b.WriteString(ev.Text)
+		}
+	case ev.Status == jobRunning:
+		h.text[ev.JobID] = &strings.Builder{}
+	default:
+		delete(h.text, ev.JobID)
+	}
+	for sub := range h.subs[ev.JobID] {
+		if ev.Token {
+			select {
+			case sub.tokens <- ev:
+			default: // a stalled browser shouldn't hold up the worker
+			}
+			continue
+		}
+		sub.mu.Lock()
+		sub.status[ev.JobID] = ev
+		sub.mu.Unlock()
+		select {
+		case sub.wake <- struct{}{}:
+		default: // already woken
+		}
+	}
+}
+
+func publishJobStatus(j job) {
+	jobEvents.publish(jobEvent{JobID: j.ID, Status: j.Status, Text: j.StatusText()})
+}
+
+type jobCtxKey struct{}
+
+// jobTokenSink returns a func that streams output to the browser when ctx
+// belongs to a running job, or nil otherwise.
+func jobTokenSink(ctx context.Context) func(string) {
+	id, ok := ctx.Value(jobCtxKey{}).(int64)
+	if !ok {
+		return nil
+	}
+	return func(chunk string) {
+		jobEvents.publish(jobEvent{JobID: id, Text: chunk, Token: true})
+	}
+}
+
+// jobStreamPollInterval is how often a stream rereads its unfinished jobs.
+const jobStreamPollInterval = 5 * time.Second
+
+func isTerminal(status string) bool {
+	return status == jobDone || status == jobFailed
+}
+
+// apiJobEventsHandler streams events for the comma-separated job ids:
+//
+//	job       {"id", "status", "text"} when a job changes state
+//	token     {"id", "text"} for each chunk of streamed output
+//	progress  {"done", "total"} after each job finishes
+//	end       once every job has finished
+//
+// Current state is sent first, so a late subscriber still catches up.
+func apiJobEventsHandler(w http.ResponseWriter, r *http.Request) {
+	var ids []int64
+	for _, s := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		http.Error(w, "ids required", http.StatusBadRequest)
+		return
+	}
+	sess, _ := currentSession(r)
+	for _, id := range ids {
+		j, err := loadJob(r.Context(), id)
+		if err != nil {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return
+		}
+		if k, ok := jobKinds[j.Kind]; !ok || sess.User.Role < k.Role {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	sub, snapshot, cancel := jobEvents.subscribe(ids)
+	defer cancel()
+
+	sse, ok := newSSEWriter(w)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	send := func(event string, v interface{}) bool {
+		b, _ := json.Marshal(v)
+		return sse.send(event, string(b)) == nil
+	}
+
+	finished := map[int64]bool{}
+	sendJob := func(id int64, status, text string) bool {
+		if !send("job", map[string]interface{}{"id": id, "status": status, "text": text}) {
+			return false
+		}
+		if isTerminal(status) && !finished[id] {
+			finished[id] = true
+			return send("progress", map[string]int{"done": len(finished), "total": len(ids)})
+		}
+		return true
+	}
+
+	for _, id := range ids {
+		j, err := loadJob(r.Context(), id)
+		if err != nil {
+			// Count it as finished so the stream still ends.
+			text := "Job not found"
+			if !errors.Is(err, store.ErrNotFound) {
+				log.Printf("job stream: %v", err)
+				text = "Couldn't load job"
+			}
+			if !sendJob(id, jobFailed, text) {
+				return
+			}
+			continue
+		}
+		if !sendJob(id, j.Status, j.StatusText()) {
+			return
+		}
+		if text, ok := snapshot[id]; ok && text != "" && j.Status == jobRunning {
+			if !send("token", map[string]interface{}{"id": id, "text": text}) {
+				return
+			}
+		}
+	}
+	if len(finished) == 0 {
+		send("progress", map[string]int{"done": 0, "total": len(ids)})
+	}
+
+	// Events are the fast path; the ticker rereads unfinished jobs in case
+	// a worker died without publishing its last status.
+	ticker := time.NewTicker(jobStreamPollInterval)
+	defer ticker.Stop()
+	for len(finished) < len(ids) {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev := <-sub.tokens:
+			if !send("token", map[string]interface{}{"id": ev.JobID, "text": ev.Text}) {
+				return
+			}
+		case <-sub.wake:
+			for _, ev := range sub.takeStatuses() {
+				if !sendJob(ev.JobID, ev.Status, ev.Text) {
+					return
+				}
+			}
+		case <-ticker.C:
+			for _, id := range ids {
+				if finished[id] {
+					continue
+				}
+				status, text := jobFailed, "Job not found"
+				if j, err := loadJob(r.Context(), id); err == nil {
+					status, text = j.Status, j.StatusText()
+				} else if !errors.Is(err, store.ErrNotFound) {
+					log.Printf("job stream: %v", err)
+					continue
+				}
+				if isTerminal(status) && !sendJob(id, status, text) {
+					return
+				}
+			}
+		}
+	}
+	send("end", map[string]int{"done": len(finished), "total": len(ids)})
+}
+
+// jobEventsURL streams events for ids.
+func jobEventsURL(ids ...int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return "/api/jobs/events?ids=" + strings.Join(parts, ",")
+}
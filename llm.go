@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// LLMProvider is the language model behind team analysis, match plans, video
+// scouting and head-to-head comparisons. Task names the feature making the
+// call ("team_analysis", "match_plan", "video_scout", "head_to_head",
+// matching the prompts/ files) so providers can route or fake per feature.
+type LLMProvider interface {
+	Name() string
+	// GenerateText returns free-form text for a prompt.
+	GenerateText(ctx context.Context, req LLMRequest) (string, error)
+	// StreamText is GenerateText that also hands each piece of output to
+	// onChunk as the model produces it.
+	StreamText(ctx context.Context, req LLMRequest, onChunk func(string)) (string, error)
+	// GenerateJSON returns a JSON document. schema is a JSON Schema the
+	// output should follow, or nil for any JSON.
+	GenerateJSON(ctx context.Context, req LLMRequest, schema json.RawMessage) (string, error)
+	// GenerateMultimodal returns text for a prompt plus media such as a
+	// match video.
+	GenerateMultimodal(ctx context.Context, req LLMRequest, media []LLMMedia) (string, error)
+}
+
+type LLMRequest struct {
+	Task   string
+	Prompt string
+}
+
+type LLMMedia struct {
+	MimeType string
+	URI      string
+}
+
+const (
+	taskTeamAnalysis = "team_analysis"
+	taskMatchPlan    = "match_plan"
+	taskVideoScout   = "video_scout"
+	taskHeadToHead   = "head_to_head"
+)
+
+// llm is the provider every AI feature uses, chosen at startup.
+var llm LLMProvider
+
+var llmTasks = []string{taskTeamAnalysis, taskMatchPlan, taskVideoScout, taskHeadToHead}
+
+// newLLMProvider builds a provider for each task from its PROVIDER setting:
+// "gemini" (default), "openai" for any OpenAI-compatible chat completions
+// server such as Ollama or llama.cpp, or "fake" for offline development.
+func newLLMProvider() (LLMProvider, error) {
+	router := &llmRouter{byTask: map[string]LLMProvider{}}
+	for _, task := range llmTasks {
+		p, err := newTaskProvider(task)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", task, err)
+		}
+		router.byTask[task] = p
+	}
+	return router, nil
+}
+
+// newTaskProvider reads LLM_<TASK>_<setting> (e.g. LLM_MATCH_PLAN_MODEL),
+// falling back to LLM_<setting> so one set of variables can cover every task.
+// A task that names a different provider than LLM_PROVIDER doesn't inherit
+// the shared model, base URL or key, which belong to the other provider.
+func newTaskProvider(task string) (LLMProvider, error) {
+	prefix := "LLM_" + strings.ToUpper(task) + "_"
+	provider := os.Getenv(prefix + "PROVIDER")
+	inherit := provider == "" || provider == os.Getenv("LLM_PROVIDER")
+	if provider == "" {
+		provider = os.Getenv("LLM_PROVIDER")
+	}
+	setting := func(name string) string {
+		if v := os.Getenv(prefix + name); v != "" || !inherit {
+			return v
+		}
+		return os.Getenv("LLM_" + name)
+	}
+	model := setting("MODEL")
+	baseURL := setting("BASE_URL")
+	apiKey := setting("API_KEY")
+
+	switch provider {
+	case "", "gemini":
+		if apiKey == "" {
+			apiKey = os.Getenv("GEMINI_API_KEY")
+		}
+		return newGeminiProvider(baseURL, model, apiKey), nil
+	case "openai":
+		if model == "" {
+			return nil, fmt.Errorf("%sMODEL (or LLM_MODEL) is required for the openai provider", prefix)
+		}
+		return newOpenAIProvider(baseURL, model, apiKey), nil
+	case "fake":
+		return newFakeProvider(os.Getenv("LLM_FIXTURES_DIR"))
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", provider)
+	}
+}
+
+// llmRouter sends each request to the provider configured for its task.
+type llmRouter struct {
+	byTask map[string]LLMProvider
+}
+
+func (r *llmRouter) Name() string {
+	parts := make([]string, 0, len(llmTasks))
+	for _, task := range llmTasks {
+		parts = append(parts, task+"="+r.byTask[task].Name())
+	}
+	return strings.Join(parts, " ")
+}
+
+func (r *llmRouter) provider(task string) (LLMProvider, error) {
+	p, ok := r.byTask[task]
+	if !ok {
+		return nil, fmt.Errorf("no LLM provider for task %q", task)
+	}
+	return p, nil
+}
+
+func (r *llmRouter) GenerateText(ctx context.Context, req LLMRequest) (string, error) {
+	p, err := r.provider(req.Task)
+	if err != nil {
+		return "", err
+	}
+	return p.GenerateText(ctx, req)
+}
+
+func (r *llmRouter) StreamText(ctx context.Context, req LLMRequest, onChunk func(string)) (string, error) {
+	p, err := r.provider(req.Task)
+	if err != nil {
+		return "", err
+	}
+	return p.StreamText(ctx, req, onChunk)
+}
+
+func (r *llmRouter) GenerateJSON(ctx context.Context, req LLMRequest, schema json.RawMessage) (string, error) {
+	p, err := r.provider(req.Task)
+	if err != nil {
+		return "", err
+	}
+	return p.GenerateJSON(ctx, req, schema)
+}
+
+func (r *llmRouter) GenerateMultimodal(ctx context.Context, req LLMRequest, media []LLMMedia) (string, error) {
+	p, err := r.provider(req.Task)
+	if err != nil {
+		return "", err
+	}
+	return p.GenerateMultimodal(ctx, req, media)
+}
+
+// stripCodeFences removes the ```json ... ``` wrapper models like to add.
+func stripCodeFences(raw string) string {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+	return strings.TrimSpace(raw)
+}
+
+// ── Fake provider ─────────────────────────────────────────────────────────────
+
+//go:embed llmfixtures
+var defaultLLMFixtures embed.FS
+
+// fakeProvider answers from fixture files so the AI features run without a
+// key or network. For a request it tries, in order:
+//
+//	<task>/<hash>.txt   exact response for one prompt
+//	<task>/default.txt  response for every other prompt of that task
+//
+// where <hash> is the first 16 hex digits of the SHA-256 of the prompt (and
+// media URIs, one per line after it). Misses are logged with the hash so a
+// fixture can be added for them. Files in LLM_FIXTURES_DIR take precedence
+// over the built-in llmfixtures/ defaults.
+type fakeProvider struct {
+	dirs []fs.FS
+}
+
+func newFakeProvider(dir string) (*fakeProvider, error) {
+	builtin, err := fs.Sub(defaultLLMFixtures, "llmfixtures")
+	if err != nil {
+		return nil, err
+	}
+	p := &fakeProvider{dirs: []fs.FS{builtin}}
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("LLM_FIXTURES_DIR: %w", err)
+		}
+		p.dirs = append([]fs.FS{os.DirFS(dir)}, p.dirs...)
+	}
+	return p, nil
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func fixtureHash(prompt string, media []LLMMedia) string {
+	h := sha256.New()
+	h.Write([]byte(prompt))
+	for _, m := range media {
+		h.Write([]byte("\n" + m.URI))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func (p *fakeProvider) respond(req LLMRequest, media []LLMMedia) (string, error) {
+	hash := fixtureHash(req.Prompt, media)
+	for _, name := range []string{req.Task + "/" + hash + ".txt", req.Task + "/default.txt"} {
+		for _, dir := range p.dirs {
+			if b, err := fs.ReadFile(dir, name); err == nil {
+				return string(b), nil
+			}
+		}
+		if strings.HasSuffix(name, "/"+hash+".txt") {
+			log.Printf("fake LLM: no fixture %s, using default", name)
+		}
+	}
+	return "", fmt.Errorf("fake LLM: no fixture for task %q (prompt hash %s)", req.Task, hash)
+}
+
+func (p *fakeProvider) GenerateText(ctx context.Context, req LLMRequest) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return p.respond(req, nil)
+}
+
+// fakeStreamDelay paces fake streaming so the UI behaves as with a real model.
+const fakeStreamDelay = 40 * time.Millisecond
+
+// StreamText sends the fixture a word at a time.
+func (p *fakeProvider) StreamText(ctx context.Context, req LLMRequest, onChunk func(string)) (string, error) {
+	out, err := p.GenerateText(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	for _, word := range strings.SplitAfter(out, " ") {
+		if err := sleepCtx(ctx, fakeStreamDelay); err != nil {
+			return "", err
+		}
+		onChunk(word)
+	}
+	return out, nil
+}
+
+func (p *fakeProvider) GenerateJSON(ctx context.Context, req LLMRequest, schema json.RawMessage) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return p.respond(req, nil)
+}
+
+func (p *fakeProvider) GenerateMultimodal(ctx context.Context, req LLMRequest, media []LLMMedia) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return p.respond(req, media)
+}
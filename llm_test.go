@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"vibe-scout/store"
+)
+
+// promptRecorder passes requests through, remembering the last prompt.
+type promptRecorder struct {
+	LLMProvider
+	prompt string
+}
+
+func (p *promptRecorder) GenerateJSON(ctx context.Context, req LLMRequest, schema json.RawMessage) (string, error) {
+	p.prompt = req.Prompt
+	return p.LLMProvider.GenerateJSON(ctx, req, schema)
+}
+
+func TestFakeProviderAnalysis(t *testing.T) {
+	useTestDB(t)
+	ctx := context.Background()
+	const team = "254"
+
+	// Statbotics is answered from api_cache, so nothing goes to the network.
+	if err := repo.APICache.Put(ctx, statboticsTeamYearURL(team), store.CachedResponse{
+		Body:      []byte(`{"epa": {"breakdown": {"total_points": 42}}}`),
+		FetchedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Submissions.Save(ctx, []store.Submission{{
+		EventKey: testEventKey, MatchKey: testEventKey + "_qm1", CompLevel: "qm", SetNumber: 1, MatchNumber: 1,
+		TeamNumber: team, Notes: "fast cycles, tipped once",
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures := t.TempDir()
+	t.Setenv("LLM_PROVIDER", "gemini")
+	t.Setenv("LLM_TEAM_ANALYSIS_PROVIDER", "fake")
+	t.Setenv("LLM_FIXTURES_DIR", fixtures)
+	router, err := newLLMProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &promptRecorder{LLMProvider: router}
+	oldLLM := llm
+	llm = rec
+	t.Cleanup(func() { llm = oldLLM })
+
+	// No fixture for this prompt yet: the built-in default.txt answers.
+	card, err := getOrGenerateAnalysis(ctx, testEventKey, team, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(card.Summary, "Offline fixture analysis") || card.Scoring != 6 {
+		t.Errorf("default fixture: got %+v", card)
+	}
+	if !strings.Contains(rec.prompt, "fast cycles") || !strings.Contains(rec.prompt, "total_points") {
+		t.Errorf("prompt is missing the notes or EPA:\n%s", rec.prompt)
+	}
+
+	// A fixture named for the prompt's hash wins over the default.
+	dir := filepath.Join(fixtures, taskTeamAnalysis)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	exact := `{"summary": "Exact fixture for 254.", "scoring": 9, "reliability": 4, "defense": 2}`
+	if err := os.WriteFile(filepath.Join(dir, fixtureHash(rec.prompt, nil)+".txt"), []byte(exact), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Same notes, so the cached answer would be served; make it stale.
+	if err := repo.Analysis.Put(ctx, testEventKey, team, store.CachedText{Text: "{}", NotesHash: "stale"}); err != nil {
+		t.Fatal(err)
+	}
+	card, err = getOrGenerateAnalysis(ctx, testEventKey, team, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card.Summary != "Exact fixture for 254." || card.Scoring != 9 || card.FromCache {
+		t.Errorf("exact fixture: got %+v", card)
+	}
+
+	// Other tasks still go to the provider LLM_PROVIDER names.
+	if name := router.Name(); !strings.Contains(name, "team_analysis=fake") || !strings.Contains(name, "match_plan=gemini") {
+		t.Errorf("router: %s", name)
+	}
+}
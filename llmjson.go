@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+)
+
+// maxJSONRepairs is how many times a response that fails validation is sent
+// back to the model with the error before giving up.
+const maxJSONRepairs = 2
+
+// teamAnalysisSchema mirrors the field rules in prompts/team_analysis.prompt.
+var teamAnalysisSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "summary": {"type": "string", "minLength": 1},
+    "scoring": {"type": "integer", "minimum": 1, "maximum": 10},
+    "reliability": {"type": "integer", "minimum": 1, "maximum": 10},
+    "defense": {"type": "integer", "minimum": 0, "maximum": 10}
+  },
+  "required": ["summary", "scoring", "reliability", "defense"],
+  "additionalProperties": false
+}`)
+
+// parseTeamAnalysis decodes a team analysis response and checks it against
+// teamAnalysisSchema. Errors are phrased for the model to act on.
+func parseTeamAnalysis(raw string) (teamAnalysisJSON, error) {
+	var fields struct {
+		Summary     *string `json:"summary"`
+		Scoring     *int    `json:"scoring"`
+		Reliability *int    `json:"reliability"`
+		Defense     *int    `json:"defense"`
+	}
+	if err := json.Unmarshal([]byte(stripCodeFences(raw)), &fields); err != nil {
+		return teamAnalysisJSON{}, fmt.Errorf("response is not a valid JSON object: %v", err)
+	}
+
+	var problems []string
+	checkRange := func(name string, v *int, min, max int) {
+		switch {
+		case v == nil:
+			problems = append(problems, fmt.Sprintf("%q is missing", name))
+		case *v < min || *v > max:
+			problems = append(problems, fmt.Sprintf("%q is %d but must be an integer from %d to %d", name, *v, min, max))
+		}
+	}
+	if fields.Summary == nil || strings.TrimSpace(*fields.Summary) == "" {
+		problems = append(problems, `"summary" is missing or empty`)
+	}
+	checkRange("scoring", fields.Scoring, 1, 10)
+	checkRange("reliability", fields.Reliability, 1, 10)
+	checkRange("defense", fields.Defense, 0, 10)
+	if len(problems) > 0 {
+		return teamAnalysisJSON{}, errors.New(strings.Join(problems, "; "))
+	}
+
+	return teamAnalysisJSON{
+		Summary:     strings.TrimSpace(*fields.Summary),
+		Scoring:     *fields.Scoring,
+		Reliability: *fields.Reliability,
+		Defense:     *fields.Defense,
+	}, nil
+}
+
+// llmOutputError is returned when a response still fails validation after
+// every repair attempt.
+type llmOutputError struct {
+	Attempts int
+	Response string
+	Err      error
+}
+
+func (e *llmOutputError) Error() string {
+	return fmt.Sprintf("invalid response after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *llmOutputError) Unwrap() error { return e.Err }
+
+type jsonRepairPromptData struct {
+	Prompt   string
+	Response string
+	Error    string
+}
+
+// generateValidJSON asks for JSON matching schema and runs check on it. A
+// response that check rejects is sent back with the error via
+// prompts/json_repair.prompt, up to maxJSONRepairs times.
+func generateValidJSON(ctx context.Context, req LLMRequest, schema json.RawMessage, check func(string) error) (string, error) {
+	tmpl, err := template.New("json_repair").Parse(jsonRepairPromptTmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse JSON repair prompt: %w", err)
+	}
+
+	attempt := req
+	for i := 0; ; i++ {
+		raw, err := llm.GenerateJSON(ctx, attempt, schema)
+		if err != nil {
+			return "", err
+		}
+		checkErr := check(raw)
+		if checkErr == nil {
+			return raw, nil
+		}
+		if i == maxJSONRepairs {
+			return "", &llmOutputError{Attempts: i + 1, Response: raw, Err: checkErr}
+		}
+		log.Printf("%s: repairing invalid response (attempt %d): %v", req.Task, i+1, checkErr)
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, jsonRepairPromptData{
+			Prompt:   req.Prompt,
+			Response: raw,
+			Error:    checkErr.Error(),
+		}); err != nil {
+			return "", fmt.Errorf("failed to render JSON repair prompt: %w", err)
+		}
+		attempt = LLMRequest{Task: req.Task, Prompt: buf.String()}
+	}
+}
+
+// recordLLMFailure keeps a failed generation for the admin panel. Cancelled
+// requests aren't failures of the model and are skipped.
+func recordLLMFailure(ctx context.Context, task, eventKey, teamNum string, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	f := store.LLMFailure{Task: task, EventKey: eventKey, TeamNumber: teamNum, Attempts: 1, Error: err.Error()}
+	var outErr *llmOutputError
+	if errors.As(err, &outErr) {
+		f.Attempts, f.Response = outErr.Attempts, outErr.Response
+	}
+	log.Printf("%s failed for team %s at %s: %v", task, teamNum, eventKey, err)
+	// The request may have timed out, which is often why it failed.
+	if dbErr := repo.LLMFailures.Record(context.WithoutCancel(ctx), f); dbErr != nil {
+		log.Printf("%v", dbErr)
+	}
+}
+
+func listLLMFailures(ctx context.Context, limit int) ([]templates.LLMFailure, error) {
+	stored, err := repo.LLMFailures.Recent(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	failures := make([]templates.LLMFailure, len(stored))
+	for i, f := range stored {
+		failures[i] = templates.LLMFailure{
+			Task:       f.Task,
+			EventKey:   f.EventKey,
+			TeamNumber: f.TeamNumber,
+			Attempts:   f.Attempts,
+			Error:      f.Error,
+			Response:   f.Response,
+			CreatedAt:  f.CreatedAt.Local().Format(timestampFormat),
+		}
+	}
+	return failures, nil
+}
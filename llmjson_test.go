@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseTeamAnalysis(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		raw     string
+		want    teamAnalysisJSON
+		wantErr string
+	}{
+		{
+			name: "valid",
+			raw:  `{"summary": " Fast. ", "scoring": 7, "reliability": 10, "defense": 0}`,
+			want: teamAnalysisJSON{Summary: "Fast.", Scoring: 7, Reliability: 10, Defense: 0},
+		},
+		{
+			name: "code fences",
+			raw:  "```json\n{\"summary\": \"Fast.\", \"scoring\": 1, \"reliability\": 1, \"defense\": 10}\n```",
+			want: teamAnalysisJSON{Summary: "Fast.", Scoring: 1, Reliability: 1, Defense: 10},
+		},
+		{name: "scoring too low", raw: `{"summary": "x", "scoring": 0, "reliability": 5, "defense": 0}`, wantErr: `"scoring" is 0`},
+		{name: "scoring too high", raw: `{"summary": "x", "scoring": 11, "reliability": 5, "defense": 0}`, wantErr: `"scoring" is 11`},
+		{name: "negative defense", raw: `{"summary": "x", "scoring": 5, "reliability": 5, "defense": -1}`, wantErr: `"defense" is -1`},
+		{name: "missing reliability", raw: `{"summary": "x", "scoring": 5, "defense": 0}`, wantErr: `"reliability" is missing`},
+		{name: "blank summary", raw: `{"summary": "  ", "scoring": 5, "reliability": 5, "defense": 0}`, wantErr: `"summary" is missing or empty`},
+		{name: "every problem", raw: `{"scoring": 12, "reliability": 0}`, wantErr: `"summary" is missing or empty; "scoring" is 12 but must be an integer from 1 to 10; "reliability" is 0 but must be an integer from 1 to 10; "defense" is missing`},
+		{name: "not JSON", raw: `Team 254 is great`, wantErr: "not a valid JSON object"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseTeamAnalysis(tc.raw)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// stubLLM answers GenerateJSON with responses in turn and keeps the prompts
+// it was sent.
+type stubLLM struct {
+	LLMProvider
+	responses []string
+	prompts   []string
+}
+
+func (s *stubLLM) GenerateJSON(ctx context.Context, req LLMRequest, schema json.RawMessage) (string, error) {
+	s.prompts = append(s.prompts, req.Prompt)
+	if len(s.prompts) > len(s.responses) {
+		return "", errors.New("stub LLM: out of responses")
+	}
+	return s.responses[len(s.prompts)-1], nil
+}
+
+func useStubLLM(t *testing.T, responses ...string) *stubLLM {
+	t.Helper()
+	stub := &stubLLM{responses: responses}
+	oldLLM := llm
+	llm = stub
+	t.Cleanup(func() { llm = oldLLM })
+	return stub
+}
+
+func TestGenerateValidJSON(t *testing.T) {
+	const valid = `{"summary": "ok", "scoring": 5, "reliability": 5, "defense": 0}`
+	const invalid = `{"summary": "ok", "scoring": 50, "reliability": 5, "defense": 0}`
+	tooMany := make([]string, maxJSONRepairs+2)
+	for i := range tooMany {
+		tooMany[i] = invalid
+	}
+
+	for _, tc := range []struct {
+		name      string
+		responses []string
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "valid first time", responses: []string{valid}, wantCalls: 1},
+		{name: "repaired", responses: []string{"not json", invalid, valid}, wantCalls: 3},
+		{name: "gives up", responses: tooMany, wantCalls: maxJSONRepairs + 1, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := useStubLLM(t, tc.responses...)
+			raw, err := generateValidJSON(context.Background(), LLMRequest{Task: taskTeamAnalysis, Prompt: "Analyze 254"},
+				teamAnalysisSchema, func(raw string) error {
+					_, err := parseTeamAnalysis(raw)
+					return err
+				})
+			if len(stub.prompts) != tc.wantCalls {
+				t.Errorf("%d calls, want %d", len(stub.prompts), tc.wantCalls)
+			}
+			if tc.wantErr {
+				var outErr *llmOutputError
+				if !errors.As(err, &outErr) {
+					t.Fatalf("got %v, want an llmOutputError", err)
+				}
+				if outErr.Attempts != maxJSONRepairs+1 || outErr.Response != invalid {
+					t.Errorf("got %+v", outErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if raw != valid {
+				t.Errorf("got %q, want the valid response", raw)
+			}
+			// Each repair shows the model the original prompt, what it said
+			// and why that was rejected.
+			for i, p := range stub.prompts[1:] {
+				if !strings.Contains(p, "Analyze 254") || !strings.Contains(p, tc.responses[i]) {
+					t.Errorf("repair %d prompt is missing the prompt or response:\n%s", i+1, p)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordLLMFailure(t *testing.T) {
+	useTestDB(t)
+	ctx := context.Background()
+
+	recordLLMFailure(ctx, taskTeamAnalysis, "2026cmp", "254", &llmOutputError{
+		Attempts: 3, Response: `{"scoring": 50}`, Err: errors.New(`"scoring" is 50`),
+	})
+	recordLLMFailure(ctx, taskMatchPlan, "2026cmp", "1678", errors.New("upstream timed out"))
+	// Not the model's fault, so not kept
+	recordLLMFailure(ctx, taskTeamAnalysis, "2026cmp", "971", fmt.Errorf("generate: %w", context.Canceled))
+
+	failures, err := listLLMFailures(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("got %d failures, want 2: %+v", len(failures), failures)
+	}
+	plan, analysis := failures[0], failures[1] // newest first
+	if plan.Task != taskMatchPlan || plan.TeamNumber != "1678" || plan.Attempts != 1 || plan.Response != "" {
+		t.Errorf("plain error: got %+v", plan)
+	}
+	if analysis.Task != taskTeamAnalysis || analysis.EventKey != "2026cmp" || analysis.TeamNumber != "254" ||
+		analysis.Attempts != 3 || analysis.Response != `{"scoring": 50}` || !strings.Contains(analysis.Error, `"scoring" is 50`) {
+		t.Errorf("invalid output: got %+v", analysis)
+	}
+	if analysis.CreatedAt == "" {
+		t.Error("no created time")
+	}
+}
@@ -4,11 +4,12 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -16,10 +17,10 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"text/template"
 	"time"
 
+	"vibe-scout/store"
 	"vibe-scout/templates"
 
 	"github.com/a-h/templ"
@@ -37,16 +38,25 @@ var matchPlanPromptTmpl string
 //go:embed prompts/video_scout.prompt
 var videoScoutPromptTmpl string
 
+//go:embed prompts/head_to_head.prompt
+var headToHeadPromptTmpl string
+
+//go:embed prompts/json_repair.prompt
+var jsonRepairPromptTmpl string
+
 type ScoutSubmission struct {
-	EventKey  string          `json:"event_key"`
-	MatchNum  int             `json:"match_num"`
-	ScouterID int             `json:"scouter_id"`
-	Teams     []TeamScoutData `json:"teams"`
+	SubmissionID string          `json:"submission_id"` // client-generated UUID; replays with the same ID are ignored
+	EventKey     string          `json:"event_key"`
+	MatchKey     string          `json:"match_key"` // TBA match key; older clients send only match_num (quals)
+	MatchNum     int             `json:"match_num"`
+	ScouterID    int             `json:"scouter_id"`
+	Teams        []TeamScoutData `json:"teams"`
 }
 
 type TeamScoutData struct {
-	TeamNumber string `json:"team_number"`
-	Notes      string `json:"notes"`
+	TeamNumber string                     `json:"team_number"`
+	Notes      string                     `json:"notes"`
+	Fields     map[string]json.RawMessage `json:"fields,omitempty"` // structured form values keyed by GameSchemaField.Key
 }
 
 // Event struct matches the TBA 'simple' model
@@ -61,63 +71,168 @@ func main() {
 		log.Fatalf("Error loading .env file: %s", err)
 	}
 
-	initDB()
+	// Subcommands; with none, run the server.
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "migrate":
+			err = migrateCommand(os.Args[2:])
+		case "replay-webhooks":
+			err = replayWebhooks(os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command %q (want migrate or replay-webhooks)", os.Args[1])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	http.Handle("/", http.HandlerFunc(homeHandler))
-	http.HandleFunc("/scout", scoutHandler)
-	http.HandleFunc("/api/save-scout", saveScoutDataHandler)
-	http.HandleFunc("/analysis", geminiAnalysisPageHandler)
-	http.HandleFunc("/api/run-analysis", apiRunAnalysisHandler)
-	http.HandleFunc("/api/analyze-team", apiAnalyzeTeamHandler)
-	http.HandleFunc("/api/team-notes", apiTeamNotesHandler)
-	http.HandleFunc("/match-planner", matchPlannerPageHandler)
-	http.HandleFunc("/api/match-plan", apiMatchPlanHandler)
-	http.HandleFunc("/510c53c3", adminHandler)
-	http.HandleFunc("/api/admin/clear-event", clearEventHandler)
-	http.HandleFunc("/api/admin/clear-all", clearAllHandler)
-	http.HandleFunc("/api/admin/seed-test", seedTestHandler)
-	http.HandleFunc("/api/admin/fill-ai-scout", apiFillAIScoutHandler)
-	http.HandleFunc("/api/admin/fill-ai-scout-team", apiFillAIScoutTeamHandler)
+	if err := loadGameSchemas(); err != nil {
+		log.Fatalf("Error loading game schemas: %s", err)
+	}
+
+	var err error
+	if llm, err = newLLMProvider(); err != nil {
+		log.Fatalf("Error configuring LLM provider: %s", err)
+	}
+	log.Printf("LLM provider: %s", llm.Name())
+
+	initDB()
+	cleanRestoreDir()
+	ensureAdminUser()
+	registerJobKinds()
+	startJobWorkers()
+
+	// Public
+	http.HandleFunc("/login", loginHandler)
+	http.HandleFunc("/webhooks/tba", tbaWebhookHandler)
+	http.HandleFunc("/logout", logoutHandler)
+	http.HandleFunc("/sw.js", serviceWorkerHandler)
+	http.Handle("/static/", http.FileServer(http.FS(staticFS)))
+
+	// Scouters
+	http.Handle("/", requireRole(roleScouter, homeHandler))
+	http.Handle("/scout", requireRole(roleScouter, scoutHandler))
+	http.Handle("/api/save-scout", requireRole(roleScouter, saveScoutDataHandler))
+	http.Handle("/api/schedule", requireRole(roleScouter, apiScheduleHandler))
+	http.Handle("/api/roster/scouter-options", requireRole(roleScouter, apiScouterOptionsHandler))
+	http.Handle("/api/reauth", requireRole(roleScouter, apiReauthHandler))
+
+	// Strategists
+	http.Handle("/analysis", requireRole(roleStrategist, geminiAnalysisPageHandler))
+	http.Handle("/api/run-analysis", requireRole(roleStrategist, apiRunAnalysisHandler))
+	http.Handle("/api/analyze-team", requireRole(roleStrategist, apiAnalyzeTeamHandler))
+	http.Handle("/api/jobs/status", requireRole(roleScouter, apiJobStatusHandler))
+	http.Handle("/api/jobs/events", requireRole(roleScouter, apiJobEventsHandler))
+	http.Handle("/api/team-notes", requireRole(roleStrategist, apiTeamNotesHandler))
+	http.Handle("/api/team-history", requireRole(roleStrategist, apiTeamHistoryHandler))
+	http.Handle("/team/{number}", requireRole(roleStrategist, teamPageHandler))
+	http.Handle("/compare", requireRole(roleStrategist, comparePageHandler))
+	http.Handle("/results", requireRole(roleStrategist, resultsPageHandler))
+	http.Handle("/api/compare/head-to-head", requireRole(roleStrategist, apiHeadToHeadHandler))
+	http.Handle("/match-planner", requireRole(roleStrategist, matchPlannerPageHandler))
+	http.Handle("/api/match-plan", requireRole(roleStrategist, apiMatchPlanHandler))
+	http.Handle("/picklist", requireRole(roleStrategist, pickListPageHandler))
+	http.Handle("/api/picklist/board", requireRole(roleStrategist, apiPickListBoardHandler))
+	http.Handle("/api/picklist/revision", requireRole(roleStrategist, apiPickListRevisionHandler))
+	http.Handle("/api/picklist/save", requireRole(roleStrategist, apiPickListSaveHandler))
+	http.Handle("/api/picklist/seed", requireRole(roleStrategist, apiPickListSeedHandler))
+	http.Handle("/api/picklist/history", requireRole(roleStrategist, apiPickListHistoryHandler))
+	http.Handle("/api/picklist/restore", requireRole(roleStrategist, apiPickListRestoreHandler))
+	http.Handle("/alliance-selection", requireRole(roleStrategist, allianceSelectionPageHandler))
+	http.Handle("/api/alliance-selection/board", requireRole(roleStrategist, apiAllianceSelectionBoardHandler))
+	http.Handle("/api/alliance-selection/record", requireRole(roleStrategist, apiAllianceSelectionRecordHandler))
+	http.Handle("/roster", requireRole(roleStrategist, rosterPageHandler))
+	http.Handle("/api/roster/board", requireRole(roleStrategist, apiRosterBoardHandler))
+	http.Handle("/api/roster/add", requireRole(roleStrategist, apiRosterAddHandler))
+	http.Handle("/api/roster/remove", requireRole(roleStrategist, apiRosterRemoveHandler))
+	http.Handle("/api/roster/generate", requireRole(roleStrategist, apiRosterGenerateHandler))
+	http.Handle("/admin/scan", requireRole(roleStrategist, qrScanPageHandler))
+	http.Handle("/api/admin/qr-import", requireRole(roleStrategist, apiQRImportHandler))
+
+	// Admins; anything that deletes data needs the password re-entered
+	http.Handle("/admin", requireRole(roleAdmin, adminHandler))
+	http.Handle("/510c53c3", requireRole(roleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/admin", http.StatusMovedPermanently)
+	}))
+	http.Handle("/api/admin/users/save", requireRole(roleAdmin, requireFreshAuth(apiSaveUserHandler)))
+	http.Handle("/api/admin/users/delete", requireRole(roleAdmin, requireFreshAuth(apiDeleteUserHandler)))
+	http.Handle("/api/admin/export", requireRole(roleAdmin, exportHandler))
+	http.Handle("/api/admin/bundle", requireRole(roleAdmin, bundleExportHandler))
+	http.Handle("/api/admin/bundle/import", requireRole(roleAdmin, requireFreshAuth(apiBundleImportHandler)))
+	http.Handle("/api/admin/backup", requireRole(roleAdmin, requireFreshAuth(backupHandler)))
+	http.Handle("/api/admin/restore", requireRole(roleAdmin, apiRestoreUploadHandler))
+	http.Handle("/api/admin/restore/confirm", requireRole(roleAdmin, requireFreshAuth(apiRestoreConfirmHandler)))
+	http.Handle("/api/admin/clear-event", requireRole(roleAdmin, requireFreshAuth(clearEventHandler)))
+	http.Handle("/api/admin/clear-all", requireRole(roleAdmin, requireFreshAuth(clearAllHandler)))
+	http.Handle("/api/admin/seed-test", requireRole(roleAdmin, requireFreshAuth(seedTestHandler)))
+	http.Handle("/api/admin/fill-ai-scout", requireRole(roleAdmin, apiFillAIScoutHandler))
+	http.Handle("/api/admin/fill-ai-scout-team", requireRole(roleAdmin, apiFillAIScoutTeamHandler))
+	http.Handle("/api/admin/custom-events/save", requireRole(roleAdmin, requireFreshAuth(apiCustomEventSaveHandler)))
+	http.Handle("/api/admin/custom-events/delete", requireRole(roleAdmin, requireFreshAuth(apiCustomEventDeleteHandler)))
 
 	fmt.Println("Vibe Scout v2 running on http://localhost:8080")
 	http.ListenAndServe(":8080", nil)
 }
 
 func homeHandler(w http.ResponseWriter, r *http.Request) {
-	eventMap, err := currentEventMap()
+	eventMap, err := currentEventMap(r.Context())
 	if err != nil {
 		http.Error(w, "Could not load events", 500)
 		return
 	}
 
-	component := templates.Home(eventMap)
+	component := templates.Home(eventMap, compLevelOptions())
 	templ.Handler(component).ServeHTTP(w, r)
 }
 
 func scoutHandler(w http.ResponseWriter, r *http.Request) {
 	eventKey := r.URL.Query().Get("event_key")
-	matchNum, _ := strconv.Atoi(r.URL.Query().Get("match_num"))
+	id := matchIDFromForm(eventKey, r.URL.Query().Get)
 	scouterID, _ := strconv.Atoi(r.URL.Query().Get("scouter_id"))
 	allianceOverride := r.URL.Query().Get("alliance")
 
-	matches, err := getMatchesCached(eventKey)
+	matches, err := getMatchesCached(r.Context(), eventKey)
 	if err != nil {
 		http.Error(w, "Failed to fetch schedule", 500)
 		return
 	}
 
-	var currentMatch Match
-	found := false
-	for _, m := range matches {
-		if m.CompLevel == "qm" && m.MatchNumber == matchNum {
-			currentMatch = m
-			found = true
-			break
+	// Rostered scouters with assignments follow their own queue; everyone
+	// else takes an alliance by match/scouter parity.
+	scouter, onRoster, err := findScouter(r.Context(), eventKey, scouterID)
+	var queue []store.Assignment
+	if err == nil && onRoster {
+		queue, err = scouterQueue(r.Context(), eventKey, scouterID, matches)
+	}
+	if err != nil {
+		log.Printf("scout page: %v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	if id.MatchNumber == 0 {
+		if len(queue) > 0 {
+			next, ok, err := nextAssignment(r.Context(), eventKey, scouterID, matches)
+			if err != nil {
+				log.Printf("scout page: %v", err)
+				http.Error(w, "DB error", http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				http.Error(w, "No more assignments for "+scouter.Name, 404)
+				return
+			}
+			nextID, _ := parseMatchKey(next.MatchKey)
+			http.Redirect(w, r, scoutURL(nextID, scouterID, ""), http.StatusSeeOther)
+			return
 		}
+		id.MatchNumber = 1
 	}
 
+	currentMatch, found := findMatch(matches, id.Key())
 	if !found {
-		http.Error(w, fmt.Sprintf("Match %d not found", matchNum), 404)
+		http.Error(w, fmt.Sprintf("%s not found", id.Label()), 404)
 		return
 	}
 
@@ -130,8 +245,15 @@ func scoutHandler(w http.ResponseWriter, r *http.Request) {
 	} else if allianceOverride == "Blue" {
 		allianceName = "Blue"
 		teamKeys = currentMatch.Alliances.Blue.TeamKeys
+	} else if team, ok := assignedTeam(queue, currentMatch.Key); ok {
+		teamKeys = []string{"frc" + team}
+		for _, tk := range currentMatch.Alliances.Blue.TeamKeys {
+			if tk == "frc"+team {
+				allianceName = "Blue"
+			}
+		}
 	} else {
-		isEvenMatch := matchNum%2 == 0
+		isEvenMatch := id.MatchNumber%2 == 0
 		isEvenScouter := scouterID%2 == 0
 		if isEvenMatch != isEvenScouter {
 			allianceName = "Blue"
@@ -150,12 +272,77 @@ func scoutHandler(w http.ResponseWriter, r *http.Request) {
 
 	teamDataCounts := map[string]int{}
 	for _, team := range teams {
-		var count int
-		db.QueryRow(`SELECT COUNT(*) FROM scout_submissions WHERE event_key = ? AND team_number = ? AND TRIM(notes) != ''`, eventKey, team).Scan(&count)
+		count, err := repo.Submissions.CountWithNotes(r.Context(), eventKey, team)
+		if err != nil {
+			log.Printf("scout page: %v", err)
+			http.Error(w, "DB error", http.StatusInternalServerError)
+			return
+		}
 		teamDataCounts[team] = count
 	}
 
-	templates.ScoutPage(eventKey, strconv.Itoa(matchNum), strconv.Itoa(scouterID), allianceName, teams, teamDataCounts).Render(r.Context(), w)
+	var formFields []templates.ScoutFormField
+	if schema, ok := gameSchemaFor(eventKey); ok {
+		formFields = schema.formFields()
+	}
+
+	// Upcoming matches in play order (or this scouter's upcoming assignments):
+	// the first is where "Next Match" goes, the rest are precached for offline use.
+	sorted := append([]Match(nil), matches...)
+	sortMatches(sorted)
+	var upcoming []string
+	for i, m := range sorted {
+		if m.Key != currentMatch.Key {
+			continue
+		}
+		for _, next := range sorted[i+1:] {
+			if len(upcoming) == 15 {
+				break
+			}
+			if len(queue) > 0 {
+				if _, ok := assignedTeam(queue, next.Key); !ok {
+					continue
+				}
+			}
+			upcoming = append(upcoming, scoutURL(next.ID(eventKey), scouterID, allianceOverride))
+		}
+		break
+	}
+
+	nextURL := ""
+	if len(upcoming) > 0 {
+		nextURL = upcoming[0]
+	}
+
+	templates.ScoutPage(templates.ScoutPageData{
+		Event:          eventKey,
+		MatchKey:       id.Key(),
+		MatchLabel:     id.Label(),
+		MatchNum:       id.MatchNumber,
+		ScouterID:      strconv.Itoa(scouterID),
+		ScouterName:    scouter.Name,
+		Alliance:       allianceName,
+		Teams:          teams,
+		TeamDataCounts: teamDataCounts,
+		Fields:         formFields,
+		NextURL:        nextURL,
+		UpcomingURLs:   upcoming,
+		PrevResult:     previousMatchResult(r.Context(), eventKey, sorted, currentMatch.Key),
+	}).Render(r.Context(), w)
+}
+
+// scoutURL links to the scout page for a match, keeping the scouter's seat.
+func scoutURL(id matchID, scouterID int, alliance string) string {
+	q := url.Values{}
+	q.Set("event_key", id.EventKey)
+	q.Set("comp_level", id.CompLevel)
+	q.Set("set_number", strconv.Itoa(id.SetNumber))
+	q.Set("match_num", strconv.Itoa(id.MatchNumber))
+	q.Set("scouter_id", strconv.Itoa(scouterID))
+	if alliance != "" {
+		q.Set("alliance", alliance)
+	}
+	return "/scout?" + q.Encode()
 }
 
 func saveScoutDataHandler(w http.ResponseWriter, r *http.Request) {
@@ -169,28 +356,118 @@ func saveScoutDataHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
+	if _, err := submissionMatchID(&sub); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	for _, teamData := range sub.Teams {
-		db.Exec(`
-			INSERT INTO scout_submissions (event_key, match_num, scouter_id, team_number, notes)
-			VALUES (?, ?, ?, ?, ?)`,
-			sub.EventKey, sub.MatchNum, sub.ScouterID, teamData.TeamNumber, teamData.Notes)
-
-		// Bust team analysis cache
-		db.Exec(`DELETE FROM analysis_cache WHERE event_key = ? AND team_number = ?`,
-			sub.EventKey, teamData.TeamNumber)
+	saved, err := saveScoutSubmission(r.Context(), sub)
+	if err != nil {
+		var invalid invalidSubmissionError
+		if errors.As(err, &invalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			log.Printf("save scout data: %v", err)
+			http.Error(w, "DB error", http.StatusInternalServerError)
+		}
+		return
 	}
 
-	fmt.Printf("Saved match %d, scouter %d, %d teams\n", sub.MatchNum, sub.ScouterID, len(sub.Teams))
+	log.Printf("Saved %s, scouter %d, %d/%d teams", sub.MatchKey, sub.ScouterID, saved, len(sub.Teams))
 	w.WriteHeader(http.StatusOK)
 }
 
+// invalidSubmissionError marks problems the client has to fix, as opposed to
+// database failures.
+type invalidSubmissionError struct{ msg string }
+
+func (e invalidSubmissionError) Error() string { return e.msg }
+
+// submissionMatchID resolves which match a submission is for, filling in
+// MatchKey for legacy clients that only send a qual match number.
+func submissionMatchID(sub *ScoutSubmission) (matchID, error) {
+	if sub.MatchKey == "" {
+		if sub.MatchNum <= 0 {
+			return matchID{}, invalidSubmissionError{"match_key or match_num required"}
+		}
+		sub.MatchKey = matchID{EventKey: sub.EventKey, CompLevel: "qm", SetNumber: 1, MatchNumber: sub.MatchNum}.Key()
+	}
+	id, err := parseMatchKey(sub.MatchKey)
+	if err != nil {
+		return matchID{}, invalidSubmissionError{err.Error()}
+	}
+	if id.EventKey != sub.EventKey {
+		return matchID{}, invalidSubmissionError{fmt.Sprintf("match %s is not in event %s", sub.MatchKey, sub.EventKey)}
+	}
+	sub.MatchNum = id.MatchNumber
+	return id, nil
+}
+
+// saveScoutSubmission validates and stores one scouter's match submission and
+// returns how many team rows were newly written. It is the single insert path
+// for the scout page, the offline outbox and QR imports. The teams are saved
+// together or not at all.
+func saveScoutSubmission(ctx context.Context, sub ScoutSubmission) (int, error) {
+	id, err := submissionMatchID(&sub)
+	if err != nil {
+		return 0, err
+	}
+
+	// Validate every team's structured fields before writing anything
+	schema, hasSchema := gameSchemaFor(sub.EventKey)
+	teamMetrics := make([][]scoutMetric, len(sub.Teams))
+	for i, teamData := range sub.Teams {
+		if len(teamData.Fields) == 0 {
+			continue
+		}
+		if !hasSchema {
+			return 0, invalidSubmissionError{"No scouting form configured for this season"}
+		}
+		metrics, err := schema.validateMetrics(teamData.Fields)
+		if err != nil {
+			return 0, invalidSubmissionError{fmt.Sprintf("Team %s: %v", teamData.TeamNumber, err)}
+		}
+		teamMetrics[i] = metrics
+	}
+
+	// Rows from a rostered scouter carry their name; seats stay anonymous.
+	scouter, _, err := findScouter(ctx, sub.EventKey, sub.ScouterID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Offline clients may replay the same submission; the unique index on
+	// (submission_uuid, team_number) turns repeats into no-ops.
+	rows := make([]store.Submission, len(sub.Teams))
+	for i, teamData := range sub.Teams {
+		rows[i] = store.Submission{
+			EventKey:      sub.EventKey,
+			MatchKey:      sub.MatchKey,
+			CompLevel:     id.CompLevel,
+			SetNumber:     id.SetNumber,
+			MatchNumber:   id.MatchNumber,
+			ScouterID:     sub.ScouterID,
+			ScouterName:   scouter.Name,
+			TeamNumber:    teamData.TeamNumber,
+			Notes:         teamData.Notes,
+			UUID:          sub.SubmissionID,
+			SchemaSeason:  schema.Season,
+			SchemaVersion: schema.Version,
+		}
+		for _, m := range teamMetrics[i] {
+			rows[i].Metrics = append(rows[i].Metrics, store.Metric(m))
+		}
+	}
+	return repo.Submissions.Save(ctx, rows)
+}
+
 // currentEventMap returns events within ±7 days of today, always including the
 // test event so it's easy to find during development.
-func currentEventMap() (map[string]string, error) {
-	events, err := getEventsCached("2026")
+func currentEventMap(ctx context.Context) (map[string]string, error) {
+	// Test and custom events don't need TBA, so still offer them without it.
+	events, err := getEventsCached(ctx, "2026")
 	if err != nil {
-		return nil, err
+		log.Printf("Event list unavailable: %v", err)
 	}
 
 	now := time.Now()
@@ -200,6 +477,14 @@ func currentEventMap() (map[string]string, error) {
 	m := map[string]string{
 		testEventKey: "★ " + testEventName, // always first-ish and easy to spot
 	}
+	// Custom events are listed whatever their date; admins delete them when done.
+	custom, err := listCustomEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range custom {
+		m[e.Key] = e.Name
+	}
 	for _, e := range events {
 		if e.Key == testEventKey {
 			continue
@@ -218,7 +503,7 @@ func currentEventMap() (map[string]string, error) {
 // ── Analysis ──────────────────────────────────────────────────────────────────
 
 func geminiAnalysisPageHandler(w http.ResponseWriter, r *http.Request) {
-	eventMap, err := currentEventMap()
+	eventMap, err := currentEventMap(r.Context())
 	if err != nil {
 		http.Error(w, "Could not load events", 500)
 		return
@@ -242,26 +527,32 @@ func apiRunAnalysisHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Event required", http.StatusBadRequest)
 		return
 	}
+	withHistory := r.FormValue("with_history") != ""
 
-	rows, err := db.Query(`
-		SELECT DISTINCT team_number FROM scout_submissions
-		WHERE event_key = ?
-		ORDER BY team_number`, eventKey)
+	teams, err := repo.Submissions.Teams(r.Context(), eventKey)
 	if err != nil {
+		log.Printf("run analysis: %v", err)
 		http.Error(w, "DB error", 500)
 		return
 	}
-	var teams []string
-	for rows.Next() {
-		var t string
-		rows.Scan(&t)
-		teams = append(teams, t)
+
+	var slots []templates.JobSlot
+	var ids []int64
+	for _, team := range teams {
+		slot, err := enqueueTeamAnalysis(r.Context(), eventKey, team, withHistory)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		slots = append(slots, slot)
+		ids = append(ids, slot.ID)
 	}
-	rows.Close()
 
-	templates.GeminiAnalysisProgressContainer(teams, eventKey).Render(r.Context(), w)
+	templates.GeminiAnalysisProgressContainer(slots, jobEventsURL(ids...)).Render(r.Context(), w)
 }
 
+// apiAnalyzeTeamHandler queues analysis for one team and returns its
+// placeholder.
 func apiAnalyzeTeamHandler(w http.ResponseWriter, r *http.Request) {
 	eventKey := r.URL.Query().Get("event_key")
 	teamNum := r.URL.Query().Get("team_number")
@@ -270,16 +561,63 @@ func apiAnalyzeTeamHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	card, err := getOrGenerateAnalysis(eventKey, teamNum)
+	slot, err := enqueueTeamAnalysis(r.Context(), eventKey, teamNum, r.URL.Query().Get("with_history") != "")
 	if err != nil {
-		card = templates.TeamAnalysisCard{
-			EventKey:   eventKey,
-			TeamNumber: teamNum,
-			Summary:    "Error generating analysis: " + err.Error(),
-		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	templates.AnalysisJobSlot(slot).Render(r.Context(), w)
+}
+
+type teamAnalysisJobParams struct {
+	EventKey    string `json:"event_key"`
+	TeamNumber  string `json:"team_number"`
+	WithHistory bool   `json:"with_history,omitempty"`
+}
+
+// enqueueTeamAnalysis queues analysis for one team. withHistory adds what was
+// scouted of the team at earlier events to the prompt.
+func enqueueTeamAnalysis(ctx context.Context, eventKey, teamNum string, withHistory bool) (templates.JobSlot, error) {
+	dedupeKey := eventKey + "/" + teamNum
+	if withHistory {
+		dedupeKey += "/history"
+	}
+	id, err := enqueueJob(ctx, jobTeamAnalysis, dedupeKey,
+		teamAnalysisJobParams{EventKey: eventKey, TeamNumber: teamNum, WithHistory: withHistory})
+	if err != nil {
+		return templates.JobSlot{}, err
+	}
+	return templates.JobSlot{ID: id, Team: teamNum, StatusURL: jobStatusURL(id), Status: "Queued"}, nil
+}
+
+func runTeamAnalysisJob(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var p teamAnalysisJobParams
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
 	}
+	return getOrGenerateAnalysis(ctx, p.EventKey, p.TeamNumber, p.WithHistory)
+}
 
-	templates.SingleTeamAnalysisCard(card).Render(r.Context(), w)
+func renderTeamAnalysisJob(w http.ResponseWriter, r *http.Request, j job) {
+	var p teamAnalysisJobParams
+	json.Unmarshal(j.Params, &p)
+
+	switch j.Status {
+	case jobDone:
+		var card templates.TeamAnalysisCard
+		json.Unmarshal(j.Result, &card)
+		templates.SingleTeamAnalysisCard(card).Render(r.Context(), w)
+	case jobFailed:
+		templates.SingleTeamAnalysisCard(templates.TeamAnalysisCard{
+			EventKey:   p.EventKey,
+			TeamNumber: p.TeamNumber,
+			Summary:    "Error generating analysis: " + j.Error,
+		}).Render(r.Context(), w)
+	default:
+		templates.AnalysisJobSlot(templates.JobSlot{
+			ID: j.ID, Team: p.TeamNumber, StatusURL: jobStatusURL(j.ID), Status: j.StatusText(),
+		}).Render(r.Context(), w)
+	}
 }
 
 func apiTeamNotesHandler(w http.ResponseWriter, r *http.Request) {
@@ -290,27 +628,22 @@ func apiTeamNotesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rows, err := db.Query(`
-		SELECT match_num, notes FROM scout_submissions
-		WHERE event_key = ? AND team_number = ?
-		ORDER BY match_num ASC`, eventKey, teamNum)
+	stored, err := repo.Submissions.Notes(r.Context(), eventKey, teamNum)
 	if err != nil {
+		log.Printf("team notes: %v", err)
 		http.Error(w, "db error", http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 
 	var notes []templates.TeamNote
-	for rows.Next() {
-		var n templates.TeamNote
-		rows.Scan(&n.MatchNum, &n.Notes)
-		notes = append(notes, n)
+	for _, n := range stored {
+		notes = append(notes, teamNoteView(n))
 	}
 
 	templates.TeamNotesPanel(notes).Render(r.Context(), w)
 }
 
-// teamAnalysisJSON is the structured response Gemini returns for team analysis.
+// teamAnalysisJSON is the structured response the LLM returns for team analysis.
 type teamAnalysisJSON struct {
 	Summary     string `json:"summary"`
 	Scoring     int    `json:"scoring"`
@@ -318,35 +651,50 @@ type teamAnalysisJSON struct {
 	Defense     int    `json:"defense"` // 0 = N/A
 }
 
-func getOrGenerateAnalysis(eventKey, teamNum string) (templates.TeamAnalysisCard, error) {
-	rows, err := db.Query(`
-		SELECT notes FROM scout_submissions
-		WHERE event_key = ? AND team_number = ?
-		ORDER BY match_num ASC`, eventKey, teamNum)
+// cachedTeamAnalysis is what analysis_cache holds: the LLM's answer and the
+// earlier events that were in its prompt.
+type cachedTeamAnalysis struct {
+	teamAnalysisJSON
+	PriorEvents []string `json:"prior_events,omitempty"`
+}
+
+func getOrGenerateAnalysis(ctx context.Context, eventKey, teamNum string, withHistory bool) (templates.TeamAnalysisCard, error) {
+	notesList, err := repo.Submissions.NoteTexts(ctx, eventKey, teamNum)
 	if err != nil {
 		return templates.TeamAnalysisCard{}, err
 	}
-	var notesList []string
-	for rows.Next() {
-		var n string
-		rows.Scan(&n)
-		notesList = append(notesList, n)
+	var prior string
+	var priorEvents []string
+	if withHistory {
+		prior, priorEvents, err = priorEventContext(ctx, eventKey, teamNum)
+		if err != nil {
+			return templates.TeamAnalysisCard{}, err
+		}
+	}
+
+	results, err := teamResultsContext(ctx, eventKey, teamNum)
+	if err != nil {
+		return templates.TeamAnalysisCard{}, err
 	}
-	rows.Close()
 
 	combined := strings.Join(notesList, "\n")
-	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(combined)))
+	hashed := combined
+	if results != "" {
+		hashed += "\n\n" + results
+	}
+	if prior != "" {
+		hashed += "\n\n" + prior
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(hashed)))
 
 	// Check cache
-	var cachedJSON, cachedHash string
-	err = db.QueryRow(`
-		SELECT analysis, notes_hash FROM analysis_cache
-		WHERE event_key = ? AND team_number = ?`,
-		eventKey, teamNum).Scan(&cachedJSON, &cachedHash)
-
-	if err == nil && cachedHash == hash {
-		var result teamAnalysisJSON
-		if jsonErr := json.Unmarshal([]byte(cachedJSON), &result); jsonErr == nil {
+	cached, err := repo.Analysis.Get(ctx, eventKey, teamNum)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return templates.TeamAnalysisCard{}, err
+	}
+	if err == nil && cached.NotesHash == hash {
+		var result cachedTeamAnalysis
+		if jsonErr := json.Unmarshal([]byte(cached.Text), &result); jsonErr == nil {
 			return templates.TeamAnalysisCard{
 				EventKey:    eventKey,
 				TeamNumber:  teamNum,
@@ -354,26 +702,25 @@ func getOrGenerateAnalysis(eventKey, teamNum string) (templates.TeamAnalysisCard
 				Scoring:     result.Scoring,
 				Reliability: result.Reliability,
 				Defense:     result.Defense,
+				PriorEvents: result.PriorEvents,
 				FromCache:   true,
 			}, nil
 		}
 		// If JSON parse fails, fall through to regenerate
 	}
 
-	result, err := callGeminiTeamAnalysis(teamNum, eventKey, combined)
+	result, err := callLLMTeamAnalysis(ctx, teamNum, eventKey, combined, results, prior)
 	if err != nil {
 		return templates.TeamAnalysisCard{}, err
 	}
 
-	resultJSON, _ := json.Marshal(result)
-	db.Exec(`
-		INSERT INTO analysis_cache (event_key, team_number, analysis, notes_hash)
-		VALUES (?, ?, ?, ?)
-		ON CONFLICT(event_key, team_number) DO UPDATE SET
-			analysis = excluded.analysis,
-			notes_hash = excluded.notes_hash,
-			created_at = CURRENT_TIMESTAMP`,
-		eventKey, teamNum, string(resultJSON), hash)
+	resultJSON, err := json.Marshal(cachedTeamAnalysis{teamAnalysisJSON: result, PriorEvents: priorEvents})
+	if err != nil {
+		return templates.TeamAnalysisCard{}, err
+	}
+	if err := repo.Analysis.Put(ctx, eventKey, teamNum, store.CachedText{Text: string(resultJSON), NotesHash: hash}); err != nil {
+		return templates.TeamAnalysisCard{}, err
+	}
 
 	return templates.TeamAnalysisCard{
 		EventKey:    eventKey,
@@ -382,6 +729,7 @@ func getOrGenerateAnalysis(eventKey, teamNum string) (templates.TeamAnalysisCard
 		Scoring:     result.Scoring,
 		Reliability: result.Reliability,
 		Defense:     result.Defense,
+		PriorEvents: priorEvents,
 		FromCache:   false,
 	}, nil
 }
@@ -389,13 +737,13 @@ func getOrGenerateAnalysis(eventKey, teamNum string) (templates.TeamAnalysisCard
 // ── Match Planner ─────────────────────────────────────────────────────────────
 
 func matchPlannerPageHandler(w http.ResponseWriter, r *http.Request) {
-	eventMap, err := currentEventMap()
+	eventMap, err := currentEventMap(r.Context())
 	if err != nil {
 		http.Error(w, "Could not load events", 500)
 		return
 	}
 
-	data := templates.MatchPlannerPageData{Events: eventMap}
+	data := templates.MatchPlannerPageData{Events: eventMap, Levels: compLevelOptions()}
 	templ.Handler(templates.MatchPlannerPage(data)).ServeHTTP(w, r)
 }
 
@@ -407,30 +755,20 @@ func apiMatchPlanHandler(w http.ResponseWriter, r *http.Request) {
 
 	eventKey := r.FormValue("event_key")
 	teamNumber := strings.TrimSpace(r.FormValue("team_number"))
-	matchNum, _ := strconv.Atoi(r.FormValue("match_num"))
-	if eventKey == "" || eventKey == "none" || teamNumber == "" || matchNum == 0 {
+	id := matchIDFromForm(eventKey, r.FormValue)
+	if eventKey == "" || eventKey == "none" || teamNumber == "" || id.MatchNumber == 0 {
 		http.Error(w, "Event, team number, and match number required", http.StatusBadRequest)
 		return
 	}
 
-	matches, err := getMatchesCached(eventKey)
+	matches, err := getMatchesCached(r.Context(), eventKey)
 	if err != nil {
 		http.Error(w, "Failed to fetch schedule", 500)
 		return
 	}
 
-	// Find the specific quals match
 	frcTeam := "frc" + teamNumber
-	var targetMatch Match
-	found := false
-	for _, m := range matches {
-		if m.CompLevel == "qm" && m.MatchNumber == matchNum {
-			targetMatch = m
-			found = true
-			break
-		}
-	}
-
+	targetMatch, found := findMatch(matches, id.Key())
 	if !found {
 		templates.MatchPlannerResults(nil, teamNumber).Render(r.Context(), w)
 		return
@@ -449,41 +787,87 @@ func apiMatchPlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	card, err := getOrGenerateMatchPlan(eventKey, teamNumber, targetMatch)
+	p := matchPlanJobParams{EventKey: eventKey, TeamNumber: teamNumber, MatchKey: targetMatch.Key}
+	jobID, err := enqueueJob(r.Context(), jobMatchPlan, eventKey+"/"+teamNumber+"/"+targetMatch.Key, p)
 	if err != nil {
-		redTeams := stripFRC(targetMatch.Alliances.Red.TeamKeys)
-		blueTeams := stripFRC(targetMatch.Alliances.Blue.TeamKeys)
-		ourAlliance := "Red"
-		for _, tk := range targetMatch.Alliances.Blue.TeamKeys {
-			if tk == frcTeam {
-				ourAlliance = "Blue"
-				break
-			}
-		}
-		card = templates.MatchPlanCard{
-			MatchNum:    targetMatch.MatchNumber,
-			OurAlliance: ourAlliance,
-			RedTeams:    redTeams,
-			BlueTeams:   blueTeams,
-			Strategy:    "Error generating strategy: " + err.Error(),
-		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-
+	// Render the card straight away; the strategy streams into it.
+	card := matchPlanCard(eventKey, teamNumber, targetMatch)
+	card.StatusURL = jobStatusURL(jobID)
+	card.EventsURL = jobEventsURL(jobID)
 	templates.MatchPlannerResults([]templates.MatchPlanCard{card}, teamNumber).Render(r.Context(), w)
 }
 
-func getOrGenerateMatchPlan(eventKey, teamNumber string, m Match) (templates.MatchPlanCard, error) {
-	frcTeam := "frc" + teamNumber
-	redTeams := stripFRC(m.Alliances.Red.TeamKeys)
-	blueTeams := stripFRC(m.Alliances.Blue.TeamKeys)
+type matchPlanJobParams struct {
+	EventKey   string `json:"event_key"`
+	TeamNumber string `json:"team_number"`
+	MatchKey   string `json:"match_key"`
+}
+
+func runMatchPlanJob(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var p matchPlanJobParams
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	matches, err := getMatchesCached(ctx, p.EventKey)
+	if err != nil {
+		return nil, err
+	}
+	m, found := findMatch(matches, p.MatchKey)
+	if !found {
+		return nil, fmt.Errorf("%s is no longer in the schedule", p.MatchKey)
+	}
+	return getOrGenerateMatchPlan(ctx, p.EventKey, p.TeamNumber, m)
+}
 
-	ourAlliance := "Red"
+func renderMatchPlanJob(w http.ResponseWriter, r *http.Request, j job) {
+	var p matchPlanJobParams
+	json.Unmarshal(j.Params, &p)
+
+	switch j.Status {
+	case jobDone:
+		var card templates.MatchPlanCard
+		json.Unmarshal(j.Result, &card)
+		templates.MatchPlannerResults([]templates.MatchPlanCard{card}, p.TeamNumber).Render(r.Context(), w)
+	case jobFailed:
+		card := templates.MatchPlanCard{MatchLabel: p.MatchKey}
+		if matches, err := getMatchesCached(r.Context(), p.EventKey); err == nil {
+			if m, found := findMatch(matches, p.MatchKey); found {
+				card = matchPlanCard(p.EventKey, p.TeamNumber, m)
+			}
+		}
+		card.Strategy = "Error generating strategy: " + j.Error
+		templates.MatchPlannerResults([]templates.MatchPlanCard{card}, p.TeamNumber).Render(r.Context(), w)
+	default:
+		templates.MatchPlanJobSlot(templates.JobSlot{
+			ID: j.ID, Team: p.TeamNumber, StatusURL: jobStatusURL(j.ID), Status: j.StatusText(),
+		}).Render(r.Context(), w)
+	}
+}
+
+// matchPlanCard is the card for teamNumber's plan for m, minus the strategy.
+func matchPlanCard(eventKey, teamNumber string, m Match) templates.MatchPlanCard {
+	card := templates.MatchPlanCard{
+		MatchLabel:  m.ID(eventKey).Label(),
+		OurAlliance: "Red",
+		RedTeams:    stripFRC(m.Alliances.Red.TeamKeys),
+		BlueTeams:   stripFRC(m.Alliances.Blue.TeamKeys),
+	}
 	for _, tk := range m.Alliances.Blue.TeamKeys {
-		if tk == frcTeam {
-			ourAlliance = "Blue"
+		if tk == "frc"+teamNumber {
+			card.OurAlliance = "Blue"
 			break
 		}
 	}
+	return card
+}
+
+func getOrGenerateMatchPlan(ctx context.Context, eventKey, teamNumber string, m Match) (templates.MatchPlanCard, error) {
+	card := matchPlanCard(eventKey, teamNumber, m)
+	redTeams, blueTeams := card.RedTeams, card.BlueTeams
+	ourAlliance, matchLabel := card.OurAlliance, card.MatchLabel
 
 	// Collect notes for all 5 other teams, build hash
 	allTeams := append(redTeams, blueTeams...)
@@ -495,21 +879,14 @@ func getOrGenerateMatchPlan(eventKey, teamNumber string, m Match) (templates.Mat
 		if t == teamNumber {
 			continue
 		}
-		rows, _ := db.Query(`
-			SELECT notes FROM scout_submissions
-			WHERE event_key = ? AND team_number = ?
-			ORDER BY match_num ASC`, eventKey, t)
-		var notes []string
-		for rows.Next() {
-			var n string
-			rows.Scan(&n)
-			notes = append(notes, n)
-		}
-		rows.Close()
+		notes, err := repo.Submissions.NoteTexts(ctx, eventKey, t)
+		if err != nil {
+			return templates.MatchPlanCard{}, err
+		}
 		noteLine := fmt.Sprintf("Team %s: %s", t, strings.Join(notes, " | "))
 		noteParts = append(noteParts, noteLine)
 		contextParts = append(contextParts, fmt.Sprintf("Team %s:\n  EPA:\n%s\n  Notes: %s",
-			t, fetchStatboticsEPA(t), strings.Join(notes, " | ")))
+			t, fetchStatboticsEPA(ctx, t), strings.Join(notes, " | ")))
 	}
 
 	combinedNotes := strings.Join(noteParts, "\n")
@@ -517,45 +894,27 @@ func getOrGenerateMatchPlan(eventKey, teamNumber string, m Match) (templates.Mat
 	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(combinedNotes)))
 
 	// Check cache
-	var cachedStrategy, cachedHash string
-	err := db.QueryRow(`
-		SELECT strategy, notes_hash FROM match_plan_cache
-		WHERE event_key = ? AND team_number = ? AND match_num = ?`,
-		eventKey, teamNumber, m.MatchNumber).Scan(&cachedStrategy, &cachedHash)
-
-	if err == nil && cachedHash == hash {
-		return templates.MatchPlanCard{
-			MatchNum:    m.MatchNumber,
-			OurAlliance: ourAlliance,
-			RedTeams:    redTeams,
-			BlueTeams:   blueTeams,
-			Strategy:    cachedStrategy,
-			FromCache:   true,
-		}, nil
+	cached, err := repo.MatchPlans.Get(ctx, eventKey, teamNumber, m.Key)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return templates.MatchPlanCard{}, err
+	}
+	if err == nil && cached.NotesHash == hash {
+		card.Strategy = cached.Text
+		card.FromCache = true
+		return card, nil
 	}
 
-	strategy, err := callGeminiMatchPlan(teamNumber, eventKey, m.MatchNumber, ourAlliance, redTeams, blueTeams, notesContext)
+	strategy, err := callLLMMatchPlan(ctx, teamNumber, eventKey, matchLabel, ourAlliance, redTeams, blueTeams, notesContext)
 	if err != nil {
 		return templates.MatchPlanCard{}, err
 	}
 
-	db.Exec(`
-		INSERT INTO match_plan_cache (event_key, team_number, match_num, strategy, notes_hash)
-		VALUES (?, ?, ?, ?, ?)
-		ON CONFLICT(event_key, team_number, match_num) DO UPDATE SET
-			strategy = excluded.strategy,
-			notes_hash = excluded.notes_hash,
-			created_at = CURRENT_TIMESTAMP`,
-		eventKey, teamNumber, m.MatchNumber, strategy, hash)
-
-	return templates.MatchPlanCard{
-		MatchNum:    m.MatchNumber,
-		OurAlliance: ourAlliance,
-		RedTeams:    redTeams,
-		BlueTeams:   blueTeams,
-		Strategy:    strategy,
-		FromCache:   false,
-	}, nil
+	if err := repo.MatchPlans.Put(ctx, eventKey, teamNumber, m.Key, store.CachedText{Text: strategy, NotesHash: hash}); err != nil {
+		return templates.MatchPlanCard{}, err
+	}
+
+	card.Strategy = strategy
+	return card, nil
 }
 
 func stripFRC(keys []string) []string {
@@ -570,125 +929,88 @@ func stripFRC(keys []string) []string {
 
 // ── Statbotics EPA ────────────────────────────────────────────────────────────
 
-var (
-	epaCache   = map[string]string{}
-	epaCacheMu sync.RWMutex
-	httpClient = &http.Client{Timeout: 5 * time.Second}
-)
-
-func fetchStatboticsEPA(teamNum string) string {
-	epaCacheMu.RLock()
-	if v, ok := epaCache[teamNum]; ok {
-		epaCacheMu.RUnlock()
-		return v
-	}
-	epaCacheMu.RUnlock()
+// epaMaxAge is how long a team's EPA is used before revalidating. Webhook
+// scores expire it sooner (forgetEPA).
+const epaMaxAge = time.Hour
 
-	year := time.Now().Year()
-	url := fmt.Sprintf("https://api.statbotics.io/v3/team_year/%s/%d", teamNum, year)
-	resp, err := httpClient.Get(url)
-	if err != nil || resp.StatusCode != 200 {
-		return "unavailable"
-	}
-	defer resp.Body.Close()
+func statboticsTeamYearURL(teamNum string) string {
+	return fmt.Sprintf("https://api.statbotics.io/v3/team_year/%s/%d", teamNum, time.Now().Year())
+}
 
+// fetchStatboticsBreakdown returns the team's current-season EPA breakdown
+// (e.g. "total_points", "auto_points"), or false if Statbotics has none.
+func fetchStatboticsBreakdown(ctx context.Context, teamNum string) (map[string]float64, bool) {
 	var data struct {
 		EPA struct {
 			Breakdown map[string]float64 `json:"breakdown"`
 		} `json:"epa"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil || len(data.EPA.Breakdown) == 0 {
-		return "unavailable"
+	err := cachedGet(ctx, statboticsTeamYearURL(teamNum), nil, epaMaxAge, "Statbotics EPA", &data)
+	if err != nil {
+		// 404 just means no data for the team this season
+		if !errors.Is(err, errUpstreamNotFound) {
+			log.Printf("Statbotics EPA for %s: %v", teamNum, err)
+		}
+		return nil, false
 	}
-
-	keys := make([]string, 0, len(data.EPA.Breakdown))
-	for k := range data.EPA.Breakdown {
-		keys = append(keys, k)
+	if len(data.EPA.Breakdown) == 0 {
+		return nil, false
 	}
-	sort.Strings(keys)
-
-	var lines []string
-	for _, k := range keys {
-		lines = append(lines, fmt.Sprintf("  %s: %.2f", k, data.EPA.Breakdown[k]))
-	}
-	result := strings.Join(lines, "\n")
-
-	epaCacheMu.Lock()
-	epaCache[teamNum] = result
-	epaCacheMu.Unlock()
-
-	return result
+	return data.EPA.Breakdown, true
 }
 
-// ── Gemini helpers ────────────────────────────────────────────────────────────
-
-const geminiURL = "https://generativelanguage.googleapis.com/v1beta/models/gemini-3.1-flash-lite-preview:generateContent"
-
-func geminiPost(prompt string) (string, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return "", fmt.Errorf("GEMINI_API_KEY not set")
+// forgetEPA makes the next read of each team's EPA revalidate, e.g. after
+// they play a match.
+func forgetEPA(teams []string) {
+	for _, t := range teams {
+		expireCachedResponse(statboticsTeamYearURL(t))
 	}
+}
 
-	payload := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{"parts": []map[string]string{{"text": prompt}}},
-		},
-	}
+// sortEPAKeys puts total_points first since it's what people sort by, then
+// the rest A-Z.
+func sortEPAKeys(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i] == "total_points") != (keys[j] == "total_points") {
+			return keys[i] == "total_points"
+		}
+		return keys[i] < keys[j]
+	})
+}
 
-	body, _ := json.Marshal(payload)
-	resp, err := http.Post(
-		fmt.Sprintf("%s?key=%s", geminiURL, apiKey),
-		"application/json",
-		bytes.NewBuffer(body),
-	)
-	if err != nil {
-		return "", err
+// fetchStatboticsEPA formats the EPA breakdown for prompts, one "key: value"
+// per line.
+func fetchStatboticsEPA(ctx context.Context, teamNum string) string {
+	breakdown, ok := fetchStatboticsBreakdown(ctx, teamNum)
+	if !ok {
+		return "unavailable"
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	keys := make([]string, 0, len(breakdown))
+	for k := range breakdown {
+		keys = append(keys, k)
 	}
+	sortEPAKeys(keys)
 
-	var geminiResp struct {
-		Candidates []struct {
-			FinishReason string `json:"finishReason"`
-			Content      struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
-		PromptFeedback struct {
-			BlockReason string `json:"blockReason"`
-		} `json:"promptFeedback"`
-	}
-	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
-		return "", fmt.Errorf("gemini parse error: %v — body: %s", err, string(respBody))
-	}
-	if geminiResp.PromptFeedback.BlockReason != "" {
-		return "", fmt.Errorf("gemini blocked prompt: %s", geminiResp.PromptFeedback.BlockReason)
-	}
-	if len(geminiResp.Candidates) == 0 {
-		return "", fmt.Errorf("gemini returned no candidates — body: %s", string(respBody))
-	}
-	c := geminiResp.Candidates[0]
-	if len(c.Content.Parts) == 0 {
-		return "", fmt.Errorf("gemini candidate has no content (finishReason: %s)", c.FinishReason)
+	var lines []string
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("  %s: %.2f", k, breakdown[k]))
 	}
-	return c.Content.Parts[0].Text, nil
+	return strings.Join(lines, "\n")
 }
 
+// ── LLM prompts ───────────────────────────────────────────────────────────────
+
 type teamAnalysisPromptData struct {
 	TeamNum      string
 	EventKey     string
 	Notes        string
 	EPABreakdown string
+	Results      string // official results of the team's matches, once it has played
+	PriorEvents  string // scouting from earlier events, if asked for
 }
 
-func callGeminiTeamAnalysis(teamNum, eventKey, notes string) (teamAnalysisJSON, error) {
+func callLLMTeamAnalysis(ctx context.Context, teamNum, eventKey, notes, results, priorEvents string) (teamAnalysisJSON, error) {
 	tmpl, err := template.New("team_analysis").Parse(teamAnalysisPromptTmpl)
 	if err != nil {
 		return teamAnalysisJSON{}, fmt.Errorf("failed to parse team analysis prompt: %w", err)
@@ -698,33 +1020,29 @@ func callGeminiTeamAnalysis(teamNum, eventKey, notes string) (teamAnalysisJSON,
 		TeamNum:      teamNum,
 		EventKey:     eventKey,
 		Notes:        notes,
-		EPABreakdown: fetchStatboticsEPA(teamNum),
+		EPABreakdown: fetchStatboticsEPA(ctx, teamNum),
+		Results:      results,
+		PriorEvents:  priorEvents,
 	}); err != nil {
 		return teamAnalysisJSON{}, fmt.Errorf("failed to render team analysis prompt: %w", err)
 	}
 
-	raw, err := geminiPost(buf.String())
+	var result teamAnalysisJSON
+	_, err = generateValidJSON(ctx, LLMRequest{Task: taskTeamAnalysis, Prompt: buf.String()}, teamAnalysisSchema,
+		func(raw string) (err error) {
+			result, err = parseTeamAnalysis(raw)
+			return err
+		})
 	if err != nil {
+		recordLLMFailure(ctx, taskTeamAnalysis, eventKey, teamNum, err)
 		return teamAnalysisJSON{}, err
 	}
-
-	// Strip markdown code fences if present
-	raw = strings.TrimSpace(raw)
-	raw = strings.TrimPrefix(raw, "```json")
-	raw = strings.TrimPrefix(raw, "```")
-	raw = strings.TrimSuffix(raw, "```")
-	raw = strings.TrimSpace(raw)
-
-	var result teamAnalysisJSON
-	if err := json.Unmarshal([]byte(raw), &result); err != nil {
-		return teamAnalysisJSON{}, fmt.Errorf("failed to parse analysis JSON: %v — raw: %s", err, raw)
-	}
 	return result, nil
 }
 
 type matchPlanPromptData struct {
 	TeamNum          string
-	MatchNum         int
+	MatchLabel       string
 	EventKey         string
 	OurAlliance      string
 	OpponentAlliance string
@@ -734,7 +1052,7 @@ type matchPlanPromptData struct {
 	NotesContext     string
 }
 
-func callGeminiMatchPlan(teamNum, eventKey string, matchNum int, ourAlliance string, redTeams, blueTeams []string, notesContext string) (string, error) {
+func callLLMMatchPlan(ctx context.Context, teamNum, eventKey, matchLabel, ourAlliance string, redTeams, blueTeams []string, notesContext string) (string, error) {
 	alliancePartners := redTeams
 	opponents := blueTeams
 	if ourAlliance == "Blue" {
@@ -761,116 +1079,53 @@ func callGeminiMatchPlan(teamNum, eventKey string, matchNum int, ourAlliance str
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, matchPlanPromptData{
 		TeamNum:          teamNum,
-		MatchNum:         matchNum,
+		MatchLabel:       matchLabel,
 		EventKey:         eventKey,
 		OurAlliance:      ourAlliance,
 		OpponentAlliance: opponentAlliance,
 		Partners:         strings.Join(partners, ", "),
 		Opponents:        strings.Join(opponents, ", "),
-		OurEPA:           fetchStatboticsEPA(teamNum),
+		OurEPA:           fetchStatboticsEPA(ctx, teamNum),
 		NotesContext:     notesContext,
 	}); err != nil {
 		return "", fmt.Errorf("failed to render match plan prompt: %w", err)
 	}
 
-	return geminiPost(buf.String())
+	req := LLMRequest{Task: taskMatchPlan, Prompt: buf.String()}
+	if sink := jobTokenSink(ctx); sink != nil {
+		return llm.StreamText(ctx, req, sink)
+	}
+	return llm.GenerateText(ctx, req)
 }
 
 // ── AI Fill-in Scout ──────────────────────────────────────────────────────────
 
-const geminiVideoURL = "https://generativelanguage.googleapis.com/v1beta/models/gemini-3.1-flash-lite-preview:generateContent"
-
-func geminiVideoPost(videoURI, prompt string) (string, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return "", fmt.Errorf("GEMINI_API_KEY not set")
-	}
-
-	payload := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{
-				"parts": []map[string]interface{}{
-					{
-						"fileData": map[string]string{
-							"mimeType": "video/mp4",
-							"fileUri":  videoURI,
-						},
-					},
-					{"text": prompt},
-				},
-			},
-		},
-	}
-
-	body, _ := json.Marshal(payload)
-	resp, err := http.Post(
-		fmt.Sprintf("%s?key=%s", geminiVideoURL, apiKey),
-		"application/json",
-		bytes.NewBuffer(body),
-	)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	var geminiResp struct {
-		Candidates []struct {
-			FinishReason string `json:"finishReason"`
-			Content      struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
-		PromptFeedback struct {
-			BlockReason string `json:"blockReason"`
-		} `json:"promptFeedback"`
-	}
-	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
-		return "", fmt.Errorf("gemini parse error: %v — body: %s", err, string(respBody))
-	}
-	if geminiResp.PromptFeedback.BlockReason != "" {
-		return "", fmt.Errorf("gemini blocked prompt: %s", geminiResp.PromptFeedback.BlockReason)
-	}
-	if len(geminiResp.Candidates) == 0 {
-		return "", fmt.Errorf("gemini returned no candidates — body: %s", string(respBody))
-	}
-	c := geminiResp.Candidates[0]
-	if len(c.Content.Parts) == 0 {
-		return "", fmt.Errorf("gemini candidate has no content (finishReason: %s)", c.FinishReason)
-	}
-	return c.Content.Parts[0].Text, nil
-}
-
 type videoScoutPromptData struct {
-	TeamNum  string
-	MatchNum int
-	EventKey string
+	TeamNum    string
+	MatchLabel string
+	EventKey   string
 }
 
-func callGeminiVideoScout(teamNum, eventKey string, matchNum int, videoURI string) (string, error) {
+func callLLMVideoScout(ctx context.Context, teamNum, eventKey, matchLabel, videoURI string) (string, error) {
 	tmpl, err := template.New("video_scout").Parse(videoScoutPromptTmpl)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse video scout prompt: %w", err)
 	}
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, videoScoutPromptData{
-		TeamNum:  teamNum,
-		MatchNum: matchNum,
-		EventKey: eventKey,
+		TeamNum:    teamNum,
+		MatchLabel: matchLabel,
+		EventKey:   eventKey,
 	}); err != nil {
 		return "", fmt.Errorf("failed to render video scout prompt: %w", err)
 	}
-	return geminiVideoPost(videoURI, buf.String())
+	return llm.GenerateMultimodal(ctx, LLMRequest{Task: taskVideoScout, Prompt: buf.String()},
+		[]LLMMedia{{MimeType: "video/mp4", URI: videoURI}})
 }
 
-// apiFillAIScoutHandler receives event_key, match_num, youtube_url and returns
-// a progress container with per-team htmx slots.
+// apiFillAIScoutHandler receives event_key, the match (comp_level, set_number,
+// match_num) and youtube_url, queues a video job per team and returns
+// a progress container with their placeholders.
 func apiFillAIScoutHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
@@ -878,96 +1133,172 @@ func apiFillAIScoutHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	eventKey := r.FormValue("event_key")
-	matchNum, _ := strconv.Atoi(r.FormValue("match_num"))
+	id := matchIDFromForm(eventKey, r.FormValue)
 	youtubeURL := strings.TrimSpace(r.FormValue("youtube_url"))
 
-	if eventKey == "" || matchNum == 0 || youtubeURL == "" {
+	if eventKey == "" || id.MatchNumber == 0 || youtubeURL == "" {
 		http.Error(w, "event_key, match_num, and youtube_url are required", http.StatusBadRequest)
 		return
 	}
 
-	matches, err := getMatchesCached(eventKey)
+	matches, err := getMatchesCached(r.Context(), eventKey)
 	if err != nil {
 		http.Error(w, "Failed to fetch match schedule: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	var targetMatch Match
-	found := false
-	for _, m := range matches {
-		if m.CompLevel == "qm" && m.MatchNumber == matchNum {
-			targetMatch = m
-			found = true
-			break
-		}
-	}
+	targetMatch, found := findMatch(matches, id.Key())
 	if !found {
-		http.Error(w, fmt.Sprintf("Match %d not found in event %s", matchNum, eventKey), http.StatusNotFound)
+		http.Error(w, fmt.Sprintf("%s not found in event %s", id.Label(), eventKey), http.StatusNotFound)
 		return
 	}
 
 	allTeamKeys := append(targetMatch.Alliances.Red.TeamKeys, targetMatch.Alliances.Blue.TeamKeys...)
 	allTeams := stripFRC(allTeamKeys)
 
-	encodedURL := url.QueryEscape(youtubeURL)
-	var slots []templates.AiFillSlot
+	var slots []templates.JobSlot
 	for _, team := range allTeams {
-		slots = append(slots, templates.AiFillSlot{
-			Team: team,
-			HXURL: fmt.Sprintf(
-				"/api/admin/fill-ai-scout-team?event_key=%s&match_num=%d&team_number=%s&youtube_url=%s",
-				url.QueryEscape(eventKey), matchNum, url.QueryEscape(team), encodedURL,
-			),
+		slot, err := enqueueVideoScout(r.Context(), videoScoutJobParams{
+			EventKey: eventKey, MatchKey: targetMatch.Key, TeamNumber: team, YouTubeURL: youtubeURL,
 		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		slots = append(slots, slot)
 	}
 
 	templates.AiFillProgressContainer(slots).Render(r.Context(), w)
 }
 
-// apiFillAIScoutTeamHandler processes one team: checks for existing data, calls
-// Gemini video analysis, saves with ai_generated=1 if no prior data exists.
+// apiFillAIScoutTeamHandler queues video analysis for one team and returns
+// its placeholder.
 func apiFillAIScoutTeamHandler(w http.ResponseWriter, r *http.Request) {
-	eventKey := r.URL.Query().Get("event_key")
-	matchNum, _ := strconv.Atoi(r.URL.Query().Get("match_num"))
-	teamNum := r.URL.Query().Get("team_number")
-	youtubeURL := r.URL.Query().Get("youtube_url")
-
-	if eventKey == "" || matchNum == 0 || teamNum == "" || youtubeURL == "" {
+	p := videoScoutJobParams{
+		EventKey:   r.URL.Query().Get("event_key"),
+		MatchKey:   r.URL.Query().Get("match_key"),
+		TeamNumber: r.URL.Query().Get("team_number"),
+		YouTubeURL: r.URL.Query().Get("youtube_url"),
+	}
+	id, err := parseMatchKey(p.MatchKey)
+	if p.EventKey == "" || err != nil || id.EventKey != p.EventKey || p.TeamNumber == "" || p.YouTubeURL == "" {
 		http.Error(w, "missing parameters", http.StatusBadRequest)
 		return
 	}
 
-	notes, err := callGeminiVideoScout(teamNum, eventKey, matchNum, youtubeURL)
+	slot, err := enqueueVideoScout(r.Context(), p)
 	if err != nil {
-		templates.AiFillTeamResult(templates.AiFillTeamResultData{Team: teamNum, Notes: err.Error(), Success: false}).Render(r.Context(), w)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	templates.AiFillJobSlot(slot).Render(r.Context(), w)
+}
+
+type videoScoutJobParams struct {
+	EventKey   string `json:"event_key"`
+	MatchKey   string `json:"match_key"`
+	TeamNumber string `json:"team_number"`
+	YouTubeURL string `json:"youtube_url"`
+}
+
+func enqueueVideoScout(ctx context.Context, p videoScoutJobParams) (templates.JobSlot, error) {
+	id, err := enqueueJob(ctx, jobVideoScout, p.MatchKey+"/"+p.TeamNumber+"/"+p.YouTubeURL, p)
+	if err != nil {
+		return templates.JobSlot{}, err
+	}
+	return templates.JobSlot{ID: id, Team: p.TeamNumber, StatusURL: jobStatusURL(id), Status: "Queued"}, nil
+}
+
+// runVideoScoutJob runs LLM video analysis for one team and saves the notes
+// with ai_generated=1.
+func runVideoScoutJob(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var p videoScoutJobParams
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	id, err := parseMatchKey(p.MatchKey)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := callLLMVideoScout(ctx, p.TeamNumber, p.EventKey, id.Label(), p.YouTubeURL)
+	if err != nil {
+		return nil, err
+	}
 
-	db.Exec(`
-		INSERT INTO scout_submissions (event_key, match_num, scouter_id, team_number, notes, ai_generated)
-		VALUES (?, ?, ?, ?, ?, 1)`,
-		eventKey, matchNum, 0, teamNum, strings.TrimSpace(notes))
+	// Saving also drops the team's cached analysis so it's redone with these notes
+	if _, err := repo.Submissions.Save(ctx, []store.Submission{{
+		EventKey:    p.EventKey,
+		MatchKey:    id.Key(),
+		CompLevel:   id.CompLevel,
+		SetNumber:   id.SetNumber,
+		MatchNumber: id.MatchNumber,
+		TeamNumber:  p.TeamNumber,
+		Notes:       strings.TrimSpace(notes),
+		AIGenerated: true,
+	}}); err != nil {
+		return nil, err
+	}
 
-	// Bust analysis cache so this team gets re-analyzed with new data
-	db.Exec(`DELETE FROM analysis_cache WHERE event_key = ? AND team_number = ?`, eventKey, teamNum)
+	return templates.AiFillTeamResultData{Team: p.TeamNumber, Notes: notes, Success: true}, nil
+}
 
-	templates.AiFillTeamResult(templates.AiFillTeamResultData{Team: teamNum, Notes: notes, Success: true}).Render(r.Context(), w)
+func renderVideoScoutJob(w http.ResponseWriter, r *http.Request, j job) {
+	var p videoScoutJobParams
+	json.Unmarshal(j.Params, &p)
+
+	switch j.Status {
+	case jobDone:
+		var result templates.AiFillTeamResultData
+		json.Unmarshal(j.Result, &result)
+		templates.AiFillTeamResult(result).Render(r.Context(), w)
+	case jobFailed:
+		templates.AiFillTeamResult(templates.AiFillTeamResultData{Team: p.TeamNumber, Notes: j.Error}).Render(r.Context(), w)
+	default:
+		templates.AiFillJobSlot(templates.JobSlot{
+			ID: j.ID, Team: p.TeamNumber, StatusURL: jobStatusURL(j.ID), Status: j.StatusText(),
+		}).Render(r.Context(), w)
+	}
 }
 
 // ── Admin ─────────────────────────────────────────────────────────────────────
 
 func adminHandler(w http.ResponseWriter, r *http.Request) {
-	var events []string
-	if rows, err := db.Query("SELECT DISTINCT event_key FROM scout_submissions"); err == nil {
-		for rows.Next() {
-			var eventKey string
-			rows.Scan(&eventKey)
-			events = append(events, eventKey)
-		}
-		rows.Close()
+	events, err := repo.Submissions.EventKeys(r.Context())
+	if err != nil {
+		log.Printf("admin page: %v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
 	}
 
-	component := templates.AdminPage(events)
+	users, err := listUsers(r.Context())
+	if err != nil {
+		log.Printf("admin page: %v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	failures, err := listLLMFailures(r.Context(), 20)
+	if err != nil {
+		log.Printf("admin page: %v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	custom, err := listCustomEvents(r.Context())
+	if err != nil {
+		log.Printf("admin page: %v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+
+	s, _ := currentSession(r)
+	component := templates.AdminPage(templates.AdminPageData{
+		Events:       events,
+		Levels:       compLevelOptions(),
+		Users:        users,
+		Username:     s.User.Username,
+		LLMFailures:  failures,
+		CustomEvents: custom,
+	})
 	templ.Handler(component).ServeHTTP(w, r)
 }
 
@@ -987,9 +1318,11 @@ func clearEventHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db.Exec("DELETE FROM scout_submissions WHERE event_key = ?", req.EventKey)
-	db.Exec("DELETE FROM analysis_cache WHERE event_key = ?", req.EventKey)
-	db.Exec("DELETE FROM match_plan_cache WHERE event_key = ?", req.EventKey)
+	if err := repo.ClearEvent(r.Context(), req.EventKey); err != nil {
+		log.Printf("clear event: %v", err)
+		http.Error(w, "Could not delete event data", http.StatusInternalServerError)
+		return
+	}
 
 	fmt.Fprintf(w, "Deleted all data for event: %s", req.EventKey)
 }
@@ -999,7 +1332,11 @@ func seedTestHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	seedTestData()
+	if err := seedTestData(r.Context()); err != nil {
+		log.Printf("seed test event: %v", err)
+		http.Error(w, "Could not seed test event", http.StatusInternalServerError)
+		return
+	}
 	fmt.Fprintf(w, "Test event seeded: %s (%d observations across 9 teams)", testEventKey, len(testObservations))
 }
 
@@ -1009,9 +1346,11 @@ func clearAllHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db.Exec("DELETE FROM scout_submissions")
-	db.Exec("DELETE FROM analysis_cache")
-	db.Exec("DELETE FROM match_plan_cache")
+	if err := repo.ClearAll(r.Context()); err != nil {
+		log.Printf("clear all: %v", err)
+		http.Error(w, "Could not delete data", http.StatusInternalServerError)
+		return
+	}
 
 	fmt.Fprintf(w, "Deleted all data from database")
 }
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"vibe-scout/templates"
+)
+
+// compLevel describes one TBA comp_level. Practice ("pm") is not published by
+// TBA but is used for schedules we enter ourselves.
+type compLevel struct {
+	Code string
+	Name string
+}
+
+// compLevels is in play order; index doubles as the sort key.
+var compLevels = []compLevel{
+	{"pm", "Practice"},
+	{"qm", "Qualification"},
+	{"ef", "Octofinal"},
+	{"qf", "Quarterfinal"},
+	{"sf", "Semifinal"},
+	{"f", "Final"},
+}
+
+func compLevelOrder(code string) int {
+	for i, l := range compLevels {
+		if l.Code == code {
+			return i
+		}
+	}
+	return len(compLevels)
+}
+
+func compLevelName(code string) string {
+	for _, l := range compLevels {
+		if l.Code == code {
+			return l.Name
+		}
+	}
+	return strings.ToUpper(code)
+}
+
+// compLevelOptions is the view model for the level selectors.
+func compLevelOptions() []templates.CompLevelOption {
+	opts := make([]templates.CompLevelOption, 0, len(compLevels))
+	for _, l := range compLevels {
+		opts = append(opts, templates.CompLevelOption{Code: l.Code, Name: l.Name})
+	}
+	return opts
+}
+
+// matchID identifies a match the same way TBA match keys do.
+type matchID struct {
+	EventKey    string
+	CompLevel   string
+	SetNumber   int
+	MatchNumber int
+}
+
+// hasSets reports whether the level numbers its matches within sets
+// (2026miket_sf2m1) rather than straight through (2026miket_qm12).
+func hasSets(level string) bool {
+	return level != "qm" && level != "pm"
+}
+
+// Key renders the TBA match key, e.g. 2026miket_qm12 or 2026miket_sf2m1.
+func (id matchID) Key() string {
+	if !hasSets(id.CompLevel) {
+		return fmt.Sprintf("%s_%s%d", id.EventKey, id.CompLevel, id.MatchNumber)
+	}
+	return fmt.Sprintf("%s_%s%dm%d", id.EventKey, id.CompLevel, id.SetNumber, id.MatchNumber)
+}
+
+// Label is the human name, e.g. "Qualification 12" or "Semifinal 2 Match 1".
+func (id matchID) Label() string {
+	name := compLevelName(id.CompLevel)
+	if !hasSets(id.CompLevel) || id.CompLevel == "f" {
+		return fmt.Sprintf("%s %d", name, id.MatchNumber)
+	}
+	return fmt.Sprintf("%s %d Match %d", name, id.SetNumber, id.MatchNumber)
+}
+
+var matchKeyPattern = regexp.MustCompile(`^(.+)_(pm|qm|ef|qf|sf|f)(\d+)(?:m(\d+))?$`)
+
+func parseMatchKey(key string) (matchID, error) {
+	parts := matchKeyPattern.FindStringSubmatch(key)
+	if parts == nil {
+		return matchID{}, fmt.Errorf("invalid match key %q", key)
+	}
+	id := matchID{EventKey: parts[1], CompLevel: parts[2]}
+	first, _ := strconv.Atoi(parts[3])
+	if hasSets(id.CompLevel) {
+		if parts[4] == "" {
+			return matchID{}, fmt.Errorf("invalid match key %q: missing match number", key)
+		}
+		id.SetNumber = first
+		id.MatchNumber, _ = strconv.Atoi(parts[4])
+	} else {
+		if parts[4] != "" {
+			return matchID{}, fmt.Errorf("invalid match key %q", key)
+		}
+		id.SetNumber = 1
+		id.MatchNumber = first
+	}
+	return id, nil
+}
+
+// matchIDFromForm reads comp_level, set_number and match_num form values,
+// defaulting to a qualification match so old links keep working.
+func matchIDFromForm(eventKey string, get func(string) string) matchID {
+	id := matchID{EventKey: eventKey, CompLevel: get("comp_level"), SetNumber: 1}
+	if compLevelOrder(id.CompLevel) == len(compLevels) {
+		id.CompLevel = "qm"
+	}
+	if hasSets(id.CompLevel) {
+		if set, err := strconv.Atoi(get("set_number")); err == nil && set > 0 {
+			id.SetNumber = set
+		}
+	}
+	id.MatchNumber, _ = strconv.Atoi(get("match_num"))
+	return id
+}
+
+func (m Match) ID(eventKey string) matchID {
+	set := m.SetNumber
+	if set == 0 || !hasSets(m.CompLevel) {
+		set = 1
+	}
+	return matchID{EventKey: eventKey, CompLevel: m.CompLevel, SetNumber: set, MatchNumber: m.MatchNumber}
+}
+
+// findMatch looks a match up in the schedule by key.
+func findMatch(matches []Match, key string) (Match, bool) {
+	for _, m := range matches {
+		if m.Key == key {
+			return m, true
+		}
+	}
+	return Match{}, false
+}
+
+// sortMatches orders a schedule by level, set and match number.
+func sortMatches(matches []Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if la, lb := compLevelOrder(a.CompLevel), compLevelOrder(b.CompLevel); la != lb {
+			return la < lb
+		}
+		if a.SetNumber != b.SetNumber {
+			return a.SetNumber < b.SetNumber
+		}
+		return a.MatchNumber < b.MatchNumber
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"vibe-scout/store"
+)
+
+// initDB opens the database and applies pending migrations, exiting if any
+// fail: running against a half-migrated schema would only fail later and
+// less clearly.
+func initDB() {
+	if err := openDB(); err != nil {
+		log.Fatalf("Error opening database: %s", err)
+	}
+	if err := store.Migrate(db); err != nil {
+		log.Fatalf("Error migrating database: %s", err)
+	}
+}
+
+// migrateCommand is `vibe-scout migrate [status|up]`.
+func migrateCommand(args []string) error {
+	cmd := "status"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+	if err := openDB(); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch cmd {
+	case "status":
+		return printMigrationStatus()
+	case "up":
+		if err := store.Migrate(db); err != nil {
+			return err
+		}
+		return printMigrationStatus()
+	}
+	return fmt.Errorf("usage: vibe-scout migrate [status|up]")
+}
+
+func printMigrationStatus() error {
+	applied, err := store.AppliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	var journal string
+	var foreignKeys int
+	if err := db.QueryRow(`PRAGMA journal_mode`).Scan(&journal); err != nil {
+		return err
+	}
+	if err := db.QueryRow(`PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil {
+		return err
+	}
+
+	current, pending := 0, 0
+	for _, m := range store.Migrations {
+		if _, ok := applied[m.Version]; ok {
+			current = m.Version
+		} else {
+			pending++
+		}
+	}
+	fmt.Printf("Database:     %s\n", dbPath())
+	fmt.Printf("Journal mode: %s, foreign keys: %v\n", journal, foreignKeys == 1)
+	fmt.Printf("Version:      %d (%d pending)\n\n", current, pending)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, m := range store.Migrations {
+		at, ok := applied[m.Version]
+		if !ok {
+			at = "pending"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, m.Name, at)
+	}
+	return w.Flush()
+}
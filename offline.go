@@ -0,0 +1,62 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"net/http"
+)
+
+//go:embed static
+var staticFS embed.FS
+
+// serviceWorkerHandler serves the service worker from the site root so its
+// scope covers every page, not just /static/.
+func serviceWorkerHandler(w http.ResponseWriter, r *http.Request) {
+	js, err := staticFS.ReadFile("static/sw.js")
+	if err != nil {
+		http.Error(w, "service worker missing", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(js)
+}
+
+// scheduleEntry is the trimmed match shape the scout page caches for offline use.
+type scheduleEntry struct {
+	Key         string   `json:"key"`
+	CompLevel   string   `json:"comp_level"`
+	SetNumber   int      `json:"set_number"`
+	MatchNumber int      `json:"match_number"`
+	Red         []string `json:"red"`
+	Blue        []string `json:"blue"`
+}
+
+func apiScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	eventKey := r.URL.Query().Get("event_key")
+	if eventKey == "" {
+		http.Error(w, "event_key required", http.StatusBadRequest)
+		return
+	}
+
+	matches, err := getMatchesCached(r.Context(), eventKey)
+	if err != nil {
+		http.Error(w, "Failed to fetch schedule", http.StatusBadGateway)
+		return
+	}
+
+	schedule := make([]scheduleEntry, 0, len(matches))
+	for _, m := range matches {
+		schedule = append(schedule, scheduleEntry{
+			Key:         m.Key,
+			CompLevel:   m.CompLevel,
+			SetNumber:   m.ID(eventKey).SetNumber,
+			MatchNumber: m.MatchNumber,
+			Red:         stripFRC(m.Alliances.Red.TeamKeys),
+			Blue:        stripFRC(m.Alliances.Blue.TeamKeys),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
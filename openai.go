@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ollamaBaseURL is where a local Ollama serves its OpenAI-compatible API.
+const ollamaBaseURL = "http://localhost:11434/v1"
+
+// openAIProvider speaks the OpenAI chat completions protocol, which Ollama,
+// llama.cpp's server, vLLM and OpenAI itself all implement. The prompt is
+// sent as a single user message, exactly as rendered from prompts/.
+type openAIProvider struct {
+	baseURL string
+	model   string
+	apiKey  string // optional; local servers usually don't check it
+}
+
+func newOpenAIProvider(baseURL, model, apiKey string) *openAIProvider {
+	if baseURL == "" {
+		baseURL = ollamaBaseURL
+	}
+	return &openAIProvider{baseURL: strings.TrimSuffix(baseURL, "/"), model: model, apiKey: apiKey}
+}
+
+func (o *openAIProvider) Name() string { return "openai/" + o.model + "@" + o.baseURL }
+
+func (o *openAIProvider) GenerateText(ctx context.Context, req LLMRequest) (string, error) {
+	return o.complete(ctx, req.Prompt, nil)
+}
+
+// GenerateJSON asks for a json_schema response when a schema is given and
+// plain JSON mode otherwise.
+func (o *openAIProvider) GenerateJSON(ctx context.Context, req LLMRequest, schema json.RawMessage) (string, error) {
+	format := map[string]interface{}{"type": "json_object"}
+	if schema != nil {
+		format = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   req.Task,
+				"schema": schema,
+			},
+		}
+	}
+	return o.complete(ctx, req.Prompt, map[string]interface{}{"response_format": format})
+}
+
+// GenerateMultimodal sends images as image_url parts and videos as
+// video_url parts. Not every server accepts video; those that don't will
+// return an error, so point the video task at a model that does.
+func (o *openAIProvider) GenerateMultimodal(ctx context.Context, req LLMRequest, media []LLMMedia) (string, error) {
+	var content []map[string]interface{}
+	for _, m := range media {
+		switch {
+		case strings.HasPrefix(m.MimeType, "image/"):
+			content = append(content, map[string]interface{}{
+				"type": "image_url", "image_url": map[string]string{"url": m.URI},
+			})
+		case strings.HasPrefix(m.MimeType, "video/"):
+			content = append(content, map[string]interface{}{
+				"type": "video_url", "video_url": map[string]string{"url": m.URI},
+			})
+		default:
+			return "", fmt.Errorf("openai provider: unsupported media type %q", m.MimeType)
+		}
+	}
+	content = append(content, map[string]interface{}{"type": "text", "text": req.Prompt})
+	return o.complete(ctx, content, nil)
+}
+
+// StreamText sets "stream": true and reads the delta from each event until
+// the [DONE] sentinel.
+func (o *openAIProvider) StreamText(ctx context.Context, req LLMRequest, onChunk func(string)) (string, error) {
+	resp, err := o.post(ctx, req.Prompt, map[string]interface{}{"stream": true})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("openai returned status %d — body: %s", resp.StatusCode, string(body))
+	}
+
+	var full strings.Builder
+	var finishReason string
+	err = readSSE(resp.Body, func(data []byte) error {
+		if string(data) == "[DONE]" {
+			return nil
+		}
+		var chunk struct {
+			Choices []struct {
+				FinishReason string `json:"finish_reason"`
+				Delta        struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("openai parse error: %v — chunk: %s", err, string(data))
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("openai error: %s", chunk.Error.Message)
+		}
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+		c := chunk.Choices[0]
+		if c.FinishReason != "" {
+			finishReason = c.FinishReason
+		}
+		if c.Delta.Content != "" {
+			full.WriteString(c.Delta.Content)
+			onChunk(c.Delta.Content)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if full.Len() == 0 {
+		return "", fmt.Errorf("openai stream had no content (finish_reason: %s)", finishReason)
+	}
+	return full.String(), nil
+}
+
+// post sends one user message (a string or a list of content parts).
+func (o *openAIProvider) post(ctx context.Context, content interface{}, extra map[string]interface{}) (*http.Response, error) {
+	payload := map[string]interface{}{
+		"model":    o.model,
+		"messages": []map[string]interface{}{{"role": "user", "content": content}},
+	}
+	for k, v := range extra {
+		payload[k] = v
+	}
+
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	return outbound.Do(req)
+}
+
+// complete posts one user message and returns the first choice's text.
+func (o *openAIProvider) complete(ctx context.Context, content interface{}, extra map[string]interface{}) (string, error) {
+	resp, err := o.post(ctx, content, extra)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var chatResp struct {
+		Choices []struct {
+			FinishReason string `json:"finish_reason"`
+			Message      struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &chatResp); err != nil {
+		return "", fmt.Errorf("openai parse error: %v — status %d, body: %s", err, resp.StatusCode, string(respBody))
+	}
+	if chatResp.Error != nil {
+		return "", fmt.Errorf("openai error (status %d): %s", resp.StatusCode, chatResp.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("openai returned status %d — body: %s", resp.StatusCode, string(respBody))
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("openai returned no choices — body: %s", string(respBody))
+	}
+	c := chatResp.Choices[0]
+	if c.Message.Content == "" {
+		return "", fmt.Errorf("openai choice has no content (finish_reason: %s)", c.FinishReason)
+	}
+	return c.Message.Content, nil
+}
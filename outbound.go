@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// hostPolicy is how hard we're willing to hit one upstream API.
+type hostPolicy struct {
+	Rate       float64       // sustained requests per second; 0 = unlimited
+	Burst      int           // requests allowed back to back
+	Timeout    time.Duration // per attempt, including reading the body
+	MaxRetries int
+}
+
+// hostPolicies are tuned for an event-wide analysis fanning out to ~40 teams.
+// Hosts not listed (e.g. a local OpenAI-compatible server) get defaultPolicy.
+var hostPolicies = map[string]hostPolicy{
+	"www.thebluealliance.com":           {Rate: 5, Burst: 10, Timeout: 10 * time.Second, MaxRetries: 3},
+	"api.statbotics.io":                 {Rate: 5, Burst: 10, Timeout: 5 * time.Second, MaxRetries: 3},
+	"generativelanguage.googleapis.com": {Rate: 2, Burst: 4, Timeout: 2 * time.Minute, MaxRetries: 4},
+}
+
+var defaultPolicy = hostPolicy{Timeout: 5 * time.Minute, MaxRetries: 2}
+
+const (
+	backoffBase     = 500 * time.Millisecond
+	backoffMax      = 30 * time.Second
+	breakerTrips    = 5                // consecutive failures that open the circuit
+	breakerCooldown = 30 * time.Second // how long an open circuit rejects requests
+)
+
+// After the cooldown the circuit is half open: one request goes through as a
+// probe while the rest are still refused. If the probe succeeds the circuit
+// closes and the failure count starts over; if it fails the circuit opens for
+// another cooldown.
+
+// errCircuitOpen is returned without calling a host that has been failing.
+var errCircuitOpen = errors.New("circuit open")
+
+// hostState is the limiter and breaker for one host.
+type hostState struct {
+	tokens    float64
+	refilled  time.Time
+	notBefore time.Time // set by Retry-After; holds every request to the host
+	failures  int
+	openUntil time.Time
+	// probeUntil is when the half-open probe under way is given up on, so a
+	// probe whose caller went away doesn't hold the host shut.
+	probeUntil time.Time
+}
+
+// outboundClient is the one way the server calls other APIs. Each request is
+// rate limited per host, retried with exponential backoff on network errors,
+// 429 and 5xx (honouring Retry-After), and refused outright while the host's
+// circuit is open. Every wait stops as soon as the request context is done.
+type outboundClient struct {
+	client *http.Client
+	mu     sync.Mutex
+	hosts  map[string]*hostState
+}
+
+var outbound = &outboundClient{client: &http.Client{}, hosts: map[string]*hostState{}}
+
+func policyFor(host string) hostPolicy {
+	if p, ok := hostPolicies[host]; ok {
+		return p
+	}
+	return defaultPolicy
+}
+
+func (c *outboundClient) state(host string, p hostPolicy) *hostState {
+	s, ok := c.hosts[host]
+	if !ok {
+		s = &hostState{tokens: float64(p.Burst), refilled: time.Now()}
+		c.hosts[host] = s
+	}
+	return s
+}
+
+// reserve takes a token for host and returns how long to wait before sending,
+// or errCircuitOpen.
+func (c *outboundClient) reserve(host string, p hostPolicy) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.state(host, p)
+	now := time.Now()
+
+	if now.Before(s.openUntil) {
+		return 0, fmt.Errorf("%s: %w for %s", host, errCircuitOpen, s.openUntil.Sub(now).Round(time.Second))
+	}
+
+	halfOpen := s.failures >= breakerTrips
+	if halfOpen && now.Before(s.probeUntil) {
+		return 0, fmt.Errorf("%s: %w until a probe succeeds", host, errCircuitOpen)
+	}
+
+	var wait time.Duration
+	if now.Before(s.notBefore) {
+		wait = s.notBefore.Sub(now)
+	}
+	if p.Rate > 0 {
+		s.tokens += now.Sub(s.refilled).Seconds() * p.Rate
+		if s.tokens > float64(p.Burst) {
+			s.tokens = float64(p.Burst)
+		}
+		s.refilled = now
+		s.tokens--
+		if s.tokens < 0 {
+			if d := time.Duration(-s.tokens / p.Rate * float64(time.Second)); d > wait {
+				wait = d
+			}
+		}
+	}
+	if halfOpen {
+		s.probeUntil = now.Add(wait + p.Timeout)
+	}
+	return wait, nil
+}
+
+// record updates the breaker after an attempt. Throttling (429) says nothing
+// about the host's health and doesn't count.
+func (c *outboundClient) record(host string, p hostPolicy, failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.state(host, p)
+	halfOpen := s.failures >= breakerTrips
+	s.probeUntil = time.Time{}
+	if !failed {
+		if halfOpen {
+			log.Printf("outbound: %s is answering again", host)
+		}
+		s.failures = 0
+		return
+	}
+	s.failures++
+	if s.failures >= breakerTrips {
+		s.openUntil = time.Now().Add(breakerCooldown)
+		if halfOpen {
+			log.Printf("outbound: %s still failing, pausing for another %s", host, breakerCooldown)
+		} else {
+			log.Printf("outbound: %s failed %d times in a row, pausing for %s", host, s.failures, breakerCooldown)
+		}
+	}
+}
+
+// holdHost makes every request to host wait until t, for Retry-After.
+func (c *outboundClient) holdHost(host string, p hostPolicy, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s := c.state(host, p); t.After(s.notBefore) {
+		s.notBefore = t
+	}
+}
+
+// Do sends req, retrying per the host's policy. A request with a body must
+// have GetBody set (http.NewRequest does this for bytes and strings readers).
+// The final response is returned whatever its status, as http.Client does.
+func (c *outboundClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Host
+	p := policyFor(host)
+
+	for attempt := 0; ; attempt++ {
+		wait, err := c.reserve(host, p)
+		if err != nil {
+			return nil, err
+		}
+		if err := sleepCtx(ctx, wait); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.send(req, p)
+		last := attempt == p.MaxRetries
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.record(host, p, true)
+			if last {
+				return nil, err
+			}
+			log.Printf("outbound: %s %s: %v (retrying)", req.Method, host, err)
+			if err := sleepCtx(ctx, backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		c.record(host, p, resp.StatusCode >= 500)
+		if !retryable || last {
+			return resp, nil
+		}
+
+		delay := backoff(attempt)
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			delay = d
+			if resp.StatusCode == http.StatusTooManyRequests {
+				c.holdHost(host, p, time.Now().Add(d))
+			}
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		log.Printf("outbound: %s %s: %s (retrying in %s)", req.Method, host, resp.Status, delay.Round(time.Millisecond))
+		if err := sleepCtx(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send makes one attempt with the policy's timeout. The timeout covers
+// reading the body, so it's released when the body is closed.
+func (c *outboundClient) send(req *http.Request, p hostPolicy) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), p.Timeout)
+	attempt := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		attempt.Body = body
+	}
+	resp, err := c.client.Do(attempt)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// backoff is exponential with full jitter, capped at backoffMax.
+func backoff(attempt int) time.Duration {
+	d := backoffBase << attempt
+	if d > backoffMax || d <= 0 {
+		d = backoffMax
+	}
+	return time.Duration(rand.Int63n(int64(d))) + backoffBase/2
+}
+
+// retryAfter parses a Retry-After header in seconds or HTTP-date form.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return min(time.Duration(secs)*time.Second, backoffMax*4), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return min(max(time.Until(t), 0), backoffMax*4), true
+	}
+	return 0, false
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		header   string
+		want     time.Duration
+		wantSlop time.Duration // HTTP dates only have whole seconds
+		ok       bool
+	}{
+		{name: "missing", header: ""},
+		{name: "seconds", header: "3", want: 3 * time.Second, ok: true},
+		{name: "zero", header: "0", want: 0, ok: true},
+		{name: "negative", header: "-5"},
+		{name: "garbage", header: "soon"},
+		{name: "seconds capped", header: "86400", want: 4 * backoffMax, ok: true},
+		{name: "date", header: time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), want: 10 * time.Second, wantSlop: time.Second, ok: true},
+		{name: "date passed", header: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), want: 0, ok: true},
+		{name: "date capped", header: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), want: 4 * backoffMax, ok: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := retryAfter(tc.header)
+			if ok != tc.ok {
+				t.Fatalf("retryAfter(%q) ok = %v, want %v", tc.header, ok, tc.ok)
+			}
+			if got > tc.want || got < tc.want-tc.wantSlop {
+				t.Errorf("retryAfter(%q) = %s, want %s", tc.header, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 70; attempt++ {
+		ceiling := backoffMax
+		if attempt < 16 && backoffBase<<attempt < backoffMax {
+			ceiling = backoffBase << attempt
+		}
+		for range 20 {
+			d := backoff(attempt)
+			if d < backoffBase/2 || d >= ceiling+backoffBase/2 {
+				t.Fatalf("backoff(%d) = %s, want [%s, %s)", attempt, d, backoffBase/2, ceiling+backoffBase/2)
+			}
+		}
+	}
+}
+
+// testOutbound is a fresh client for srv, whose host gets policy.
+func testOutbound(t *testing.T, srv *httptest.Server, policy hostPolicy) (*outboundClient, string) {
+	t.Helper()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostPolicies[u.Host] = policy
+	t.Cleanup(func() { delete(hostPolicies, u.Host) })
+	return &outboundClient{client: srv.Client(), hosts: map[string]*hostState{}}, u.Host
+}
+
+func getStatus(t *testing.T, c *outboundClient, rawURL string) (int, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, rawURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestOutboundRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		statuses   []int // served in turn, then 200
+		retryAfter string
+		want       int
+		wantHits   int
+	}{
+		{name: "5xx retried", statuses: []int{503, 502}, retryAfter: "0", want: 200, wantHits: 3},
+		{name: "429 with a past date", statuses: []int{429}, retryAfter: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), want: 200, wantHits: 2},
+		{name: "gives up", statuses: []int{500, 500, 500, 500}, retryAfter: "0", want: 500, wantHits: 3},
+		{name: "4xx not retried", statuses: []int{404}, want: 404, wantHits: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(hits.Add(1))
+				if n <= len(tc.statuses) {
+					if tc.retryAfter != "" {
+						w.Header().Set("Retry-After", tc.retryAfter)
+					}
+					w.WriteHeader(tc.statuses[n-1])
+				}
+			}))
+			defer srv.Close()
+			c, _ := testOutbound(t, srv, hostPolicy{Timeout: 5 * time.Second, MaxRetries: 2})
+
+			status, err := getStatus(t, c, srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != tc.want || int(hits.Load()) != tc.wantHits {
+				t.Errorf("got %d after %d requests, want %d after %d", status, hits.Load(), tc.want, tc.wantHits)
+			}
+		})
+	}
+}
+
+func TestOutboundRetryAfterHoldsHost(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+	c, _ := testOutbound(t, srv, hostPolicy{Timeout: 5 * time.Second, MaxRetries: 1})
+
+	start := time.Now()
+	if status, err := getStatus(t, c, srv.URL); err != nil || status != 200 {
+		t.Fatalf("got %d, %v", status, err)
+	}
+	if waited := time.Since(start); waited < time.Second {
+		t.Errorf("retried after %s, want the 1s Retry-After", waited)
+	}
+}
+
+func TestOutboundBreaker(t *testing.T) {
+	var failing atomic.Bool
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	c, host := testOutbound(t, srv, hostPolicy{Timeout: 5 * time.Second})
+	endCooldown := func() {
+		c.mu.Lock()
+		c.hosts[host].openUntil = time.Now().Add(-time.Millisecond)
+		c.mu.Unlock()
+	}
+
+	failing.Store(true)
+	for range breakerTrips {
+		if status, err := getStatus(t, c, srv.URL); err != nil || status != 500 {
+			t.Fatalf("got %d, %v before the circuit opened", status, err)
+		}
+	}
+	if _, err := getStatus(t, c, srv.URL); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("got %v once open, want errCircuitOpen", err)
+	}
+	if n := hits.Load(); n != breakerTrips {
+		t.Errorf("%d requests reached the host, want %d", n, breakerTrips)
+	}
+
+	// Half open: one probe goes out and the rest wait for it.
+	endCooldown()
+	if _, err := c.reserve(host, policyFor(host)); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if _, err := c.reserve(host, policyFor(host)); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("got %v during the probe, want errCircuitOpen", err)
+	}
+	c.record(host, policyFor(host), true)
+	if _, err := getStatus(t, c, srv.URL); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("got %v after a failed probe, want errCircuitOpen", err)
+	}
+
+	// A good probe closes the circuit and the count starts over, so a
+	// single failure afterwards doesn't open it again.
+	endCooldown()
+	failing.Store(false)
+	if status, err := getStatus(t, c, srv.URL); err != nil || status != 200 {
+		t.Fatalf("probe: got %d, %v", status, err)
+	}
+	failing.Store(true)
+	for range breakerTrips - 1 {
+		if status, err := getStatus(t, c, srv.URL); err != nil || status != 500 {
+			t.Fatalf("got %d, %v after the circuit closed", status, err)
+		}
+	}
+	failing.Store(false)
+	if status, err := getStatus(t, c, srv.URL); err != nil || status != 200 {
+		t.Fatalf("got %d, %v; the circuit opened again too soon", status, err)
+	}
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+
+	"github.com/a-h/templ"
+)
+
+// pickListTiers is how many tiers the board shows; seeding splits the ranked
+// teams evenly across them.
+const pickListTiers = 3
+
+const (
+	pickStatusAvailable = ""
+	pickStatusDNP       = "dnp"    // do not pick
+	pickStatusPicked    = "picked" // already on another alliance
+)
+
+type pickListEntry struct {
+	Team   string `json:"team"`
+	Tier   int    `json:"tier"`
+	Status string `json:"status"`
+
+	// SelectionPicked is set when alliance selection marked the team picked,
+	// so an undo can clear the mark without touching ones set by hand.
+	SelectionPicked bool `json:"selection_picked,omitempty"`
+}
+
+// pickList is one revision of an event's pick list. Every save inserts a new
+// row in pick_lists, so the table doubles as the revision history.
+type pickList struct {
+	EventKey  string
+	Revision  int
+	Entries   []pickListEntry
+	Author    string
+	CreatedAt time.Time
+}
+
+// pickListAuthor credits a revision imported from a partner team's bundle to
+// that team as well as its author.
+func pickListAuthor(author, source string) string {
+	switch {
+	case source == "":
+		return author
+	case author == "":
+		return "from " + source
+	}
+	return author + " from " + source
+}
+
+// errPickListConflict means someone else saved a newer revision first.
+var errPickListConflict = errors.New("pick list was changed by someone else")
+
+// loadPickList returns one revision of the event's pick list, or the latest
+// if revision is 0. It's store.ErrNotFound if there's no such revision.
+func loadPickList(ctx context.Context, eventKey string, revision int) (pickList, error) {
+	var stored store.PickList
+	var err error
+	if revision > 0 {
+		stored, err = repo.PickLists.Get(ctx, eventKey, revision)
+	} else {
+		stored, err = repo.PickLists.Latest(ctx, eventKey)
+	}
+	if err != nil {
+		return pickList{}, err
+	}
+
+	pl := pickList{
+		EventKey:  eventKey,
+		Revision:  stored.Revision,
+		Author:    pickListAuthor(stored.Author, stored.Source),
+		CreatedAt: stored.CreatedAt,
+	}
+	if err := json.Unmarshal([]byte(stored.Entries), &pl.Entries); err != nil {
+		return pickList{}, fmt.Errorf("corrupt pick list revision %d: %w", pl.Revision, err)
+	}
+	return pl, nil
+}
+
+// savePickList stores entries as the next revision. baseRevision must be the
+// latest revision the caller saw; a stale base returns errPickListConflict.
+func savePickList(ctx context.Context, eventKey string, baseRevision int, entries []pickListEntry, author string) (int, error) {
+	entriesJSON, err := json.Marshal(entries)
+	if err != nil {
+		return 0, err
+	}
+	revision, err := repo.PickLists.Save(ctx, eventKey, baseRevision, string(entriesJSON), author)
+	if errors.Is(err, store.ErrConflict) {
+		return 0, errPickListConflict
+	}
+	return revision, err
+}
+
+// cachedAnalysis returns the last stored Gemini analysis for a team without
+// generating a new one.
+func cachedAnalysis(ctx context.Context, eventKey, teamNum string) (teamAnalysisJSON, bool) {
+	cached, err := repo.Analysis.Get(ctx, eventKey, teamNum)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Printf("pick list: %v", err)
+		}
+		return teamAnalysisJSON{}, false
+	}
+	var result teamAnalysisJSON
+	if err := json.Unmarshal([]byte(cached.Text), &result); err != nil {
+		return teamAnalysisJSON{}, false
+	}
+	return result, true
+}
+
+// eventTeams lists every team on the event schedule plus any team that has
+// scouting data, sorted numerically.
+func eventTeams(ctx context.Context, eventKey string) []string {
+	seen := map[string]bool{}
+	if matches, err := getMatchesCached(ctx, eventKey); err == nil {
+		for _, m := range matches {
+			for _, t := range stripFRC(append(m.Alliances.Red.TeamKeys, m.Alliances.Blue.TeamKeys...)) {
+				seen[t] = true
+			}
+		}
+	}
+	scouted, err := repo.Submissions.Teams(ctx, eventKey)
+	if err != nil {
+		log.Printf("pick list: %v", err)
+	}
+	for _, t := range scouted {
+		seen[t] = true
+	}
+
+	teams := make([]string, 0, len(seen))
+	for t := range seen {
+		teams = append(teams, t)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		a, _ := strconv.Atoi(teams[i])
+		b, _ := strconv.Atoi(teams[j])
+		return a < b
+	})
+	return teams
+}
+
+// fetchEPATotals looks up total_points EPA for many teams.
+func fetchEPATotals(ctx context.Context, teams []string) map[string]float64 {
+	totals := map[string]float64{}
+	for team, b := range fetchEPABreakdowns(ctx, teams) {
+		totals[team] = b["total_points"]
+	}
+	return totals
+}
+
+// fetchEPABreakdowns looks up the EPA breakdown for many teams, a few at a
+// time. Teams without one are left out.
+func fetchEPABreakdowns(ctx context.Context, teams []string) map[string]map[string]float64 {
+	breakdowns := map[string]map[string]float64{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 8)
+	for _, t := range teams {
+		wg.Add(1)
+		go func(team string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if b, ok := fetchStatboticsBreakdown(ctx, team); ok {
+				mu.Lock()
+				breakdowns[team] = b
+				mu.Unlock()
+			}
+		}(t)
+	}
+	wg.Wait()
+	return breakdowns
+}
+
+// seedPickList ranks every team at the event by a 0-10 blend of the cached
+// analysis (scoring weighted double, plus reliability) and EPA relative to the
+// best EPA at the event. Teams with neither signal sink to the bottom.
+func seedPickList(ctx context.Context, eventKey string) []pickListEntry {
+	teams := eventTeams(ctx, eventKey)
+	epa := fetchEPATotals(ctx, teams)
+
+	var maxEPA float64
+	for _, v := range epa {
+		if v > maxEPA {
+			maxEPA = v
+		}
+	}
+
+	score := map[string]float64{}
+	for _, t := range teams {
+		var sum, weight float64
+		if a, ok := cachedAnalysis(ctx, eventKey, t); ok {
+			sum += float64(a.Scoring*2+a.Reliability) / 3
+			weight++
+		}
+		if v, ok := epa[t]; ok && maxEPA > 0 {
+			sum += v / maxEPA * 10
+			weight++
+		}
+		if weight > 0 {
+			score[t] = sum / weight
+		}
+	}
+
+	sort.SliceStable(teams, func(i, j int) bool { return score[teams[i]] > score[teams[j]] })
+
+	entries := make([]pickListEntry, len(teams))
+	perTier := (len(teams) + pickListTiers - 1) / pickListTiers
+	for i, t := range teams {
+		entries[i] = pickListEntry{Team: t, Tier: i/perTier + 1, Status: pickStatusAvailable}
+	}
+	return entries
+}
+
+// pickListBoard builds the view model for a pick list revision.
+func pickListBoard(ctx context.Context, pl pickList) templates.PickListBoard {
+	board := templates.PickListBoard{
+		EventKey:  pl.EventKey,
+		Revision:  pl.Revision,
+		Author:    pl.Author,
+		UpdatedAt: pl.CreatedAt.Local().Format(timestampFormat),
+	}
+	for n := 1; n <= pickListTiers; n++ {
+		board.Tiers = append(board.Tiers, templates.PickListTier{Number: n})
+	}
+
+	var teams []string
+	for _, e := range pl.Entries {
+		teams = append(teams, e.Team)
+	}
+	epa := fetchEPATotals(ctx, teams)
+
+	rank := 0
+	for _, e := range pl.Entries {
+		tier := e.Tier
+		if tier < 1 || tier > pickListTiers {
+			tier = pickListTiers
+		}
+		team := templates.PickListTeam{Team: e.Team, Status: e.Status}
+		if e.Status == pickStatusAvailable {
+			rank++
+			team.Rank = rank
+		}
+		if a, ok := cachedAnalysis(ctx, pl.EventKey, e.Team); ok {
+			team.HasAnalysis = true
+			team.Scoring = a.Scoring
+			team.Reliability = a.Reliability
+			team.Defense = a.Defense
+		}
+		if v, ok := epa[e.Team]; ok {
+			team.HasEPA = true
+			team.EPA = v
+		}
+		board.Tiers[tier-1].Teams = append(board.Tiers[tier-1].Teams, team)
+	}
+	return board
+}
+
+// ── Handlers ──────────────────────────────────────────────────────────────────
+
+func pickListPageHandler(w http.ResponseWriter, r *http.Request) {
+	eventMap, err := currentEventMap(r.Context())
+	if err != nil {
+		http.Error(w, "Could not load events", 500)
+		return
+	}
+
+	data := templates.PickListPageData{
+		Events:        eventMap,
+		SelectedEvent: r.URL.Query().Get("event_key"),
+	}
+	templ.Handler(templates.PickListPage(data)).ServeHTTP(w, r)
+}
+
+// apiPickListBoardHandler renders the current board, or the "create" prompt
+// when the event has no list yet.
+func apiPickListBoardHandler(w http.ResponseWriter, r *http.Request) {
+	eventKey := r.FormValue("event_key")
+	if eventKey == "" || eventKey == "none" {
+		http.Error(w, "event_key required", http.StatusBadRequest)
+		return
+	}
+
+	pl, err := loadPickList(r.Context(), eventKey, 0)
+	if errors.Is(err, store.ErrNotFound) {
+		templates.PickListEmpty(eventKey).Render(r.Context(), w)
+		return
+	}
+	if err != nil {
+		log.Printf("pick list %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	templates.PickListBoardView(pickListBoard(r.Context(), pl)).Render(r.Context(), w)
+}
+
+// apiPickListRevisionHandler lets open boards poll cheaply for other
+// strategists' changes.
+func apiPickListRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	revision, err := repo.PickLists.LatestRevision(r.Context(), r.URL.Query().Get("event_key"))
+	if err != nil {
+		log.Printf("pick list: %v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(w, revision)
+}
+
+func apiPickListSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		EventKey     string          `json:"event_key"`
+		BaseRevision int             `json:"base_revision"`
+		Entries      []pickListEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.EventKey == "" {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, e := range req.Entries {
+		if e.Team == "" || seen[e.Team] {
+			http.Error(w, "Each team must appear exactly once", http.StatusBadRequest)
+			return
+		}
+		seen[e.Team] = true
+		if e.Tier < 1 || e.Tier > pickListTiers {
+			http.Error(w, fmt.Sprintf("Team %s has invalid tier %d", e.Team, e.Tier), http.StatusBadRequest)
+			return
+		}
+		if e.Status != pickStatusAvailable && e.Status != pickStatusDNP && e.Status != pickStatusPicked {
+			http.Error(w, fmt.Sprintf("Team %s has invalid status %q", e.Team, e.Status), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The board doesn't send SelectionPicked; keep it for teams still picked.
+	selected := map[string]bool{}
+	if base, err := loadPickList(r.Context(), req.EventKey, req.BaseRevision); err == nil {
+		for _, e := range base.Entries {
+			selected[e.Team] = e.SelectionPicked
+		}
+	} else if !errors.Is(err, store.ErrNotFound) {
+		log.Printf("pick list %s: %v", req.EventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	for i, e := range req.Entries {
+		req.Entries[i].SelectionPicked = selected[e.Team] && e.Status == pickStatusPicked
+	}
+
+	s, _ := currentSession(r)
+	revision, err := savePickList(r.Context(), req.EventKey, req.BaseRevision, req.Entries, s.User.Username)
+	if errors.Is(err, errPickListConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("pick list %s: %v", req.EventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(w, revision)
+}
+
+// apiPickListSeedHandler builds a fresh ordering from analysis and EPA and
+// saves it as a new revision, so reseeding can always be undone from history.
+func apiPickListSeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	eventKey := r.FormValue("event_key")
+	if eventKey == "" || eventKey == "none" {
+		http.Error(w, "Event required", http.StatusBadRequest)
+		return
+	}
+
+	entries := seedPickList(r.Context(), eventKey)
+	if len(entries) == 0 {
+		templates.PickListEmpty(eventKey).Render(r.Context(), w)
+		return
+	}
+
+	latest, err := repo.PickLists.LatestRevision(r.Context(), eventKey)
+	if err != nil {
+		log.Printf("pick list %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	s, _ := currentSession(r)
+	_, err = savePickList(r.Context(), eventKey, latest, entries, s.User.Username)
+	if errors.Is(err, errPickListConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("pick list %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	apiPickListBoardHandler(w, r)
+}
+
+func apiPickListHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	eventKey := r.URL.Query().Get("event_key")
+	history, err := repo.PickLists.History(r.Context(), eventKey, 50)
+	if err != nil {
+		log.Printf("pick list %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+
+	revisions := make([]templates.PickListRevision, len(history))
+	for i, pl := range history {
+		revisions[i] = templates.PickListRevision{
+			Revision:  pl.Revision,
+			Author:    pickListAuthor(pl.Author, pl.Source),
+			CreatedAt: pl.CreatedAt.Local().Format(timestampFormat),
+		}
+	}
+	templates.PickListHistory(eventKey, revisions).Render(r.Context(), w)
+}
+
+// apiPickListRestoreHandler copies an old revision forward as the newest one.
+func apiPickListRestoreHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	eventKey := r.FormValue("event_key")
+	revision, _ := strconv.Atoi(r.FormValue("revision"))
+	old, err := loadPickList(r.Context(), eventKey, revision)
+	if errors.Is(err, store.ErrNotFound) {
+		http.Error(w, "Revision not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("pick list %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+
+	latest, err := repo.PickLists.LatestRevision(r.Context(), eventKey)
+	if err != nil {
+		log.Printf("pick list %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	s, _ := currentSession(r)
+	_, err = savePickList(r.Context(), eventKey, latest, old.Entries, s.User.Username)
+	if errors.Is(err, errPickListConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("pick list %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	apiPickListBoardHandler(w, r)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"vibe-scout/templates"
+
+	"github.com/a-h/templ"
+)
+
+// QR transfer moves queued submissions from a tablet to the pit laptop when
+// there is no network at all. Each QR code holds one chunk:
+//
+//	VS1|<submission_id>|<part>|<total>|<data>
+//
+// Joining the <data> of parts 1..total in order gives base64url (no padding)
+// of the JSON qrSubmission below. The field names are short to keep codes
+// small; bump the "VS" version whenever the layout changes. Optional fields
+// may be added without a bump: "k" (match key) is absent from codes made
+// before playoff support and then means a qualification match.
+const qrPayloadVersion = "VS1"
+
+type qrSubmission struct {
+	Version   int      `json:"v"`
+	ID        string   `json:"id"`
+	EventKey  string   `json:"e"`
+	MatchKey  string   `json:"k,omitempty"`
+	MatchNum  int      `json:"m"`
+	ScouterID int      `json:"s"`
+	Teams     []qrTeam `json:"t"`
+}
+
+type qrTeam struct {
+	TeamNumber string                     `json:"n"`
+	Notes      string                     `json:"x"`
+	Fields     map[string]json.RawMessage `json:"f,omitempty"`
+}
+
+type qrChunk struct {
+	ID    string
+	Part  int
+	Total int
+	Data  string
+}
+
+func parseQRChunk(s string) (qrChunk, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), "|", 5)
+	if len(parts) != 5 {
+		return qrChunk{}, errors.New("not a Vibe Scout QR code")
+	}
+	if parts[0] != qrPayloadVersion {
+		return qrChunk{}, fmt.Errorf("unsupported QR payload version %q", parts[0])
+	}
+	part, err1 := strconv.Atoi(parts[2])
+	total, err2 := strconv.Atoi(parts[3])
+	if err1 != nil || err2 != nil || total < 1 || part < 1 || part > total {
+		return qrChunk{}, errors.New("bad QR chunk numbering")
+	}
+	return qrChunk{ID: parts[1], Part: part, Total: total, Data: parts[4]}, nil
+}
+
+// decodeQRSubmission reassembles the chunks of one submission. Duplicate
+// chunks (the same code scanned twice) are fine; missing ones are an error.
+func decodeQRSubmission(raw []string) (ScoutSubmission, error) {
+	if len(raw) == 0 {
+		return ScoutSubmission{}, errors.New("no QR chunks")
+	}
+
+	var id string
+	var data []string
+	for _, s := range raw {
+		c, err := parseQRChunk(s)
+		if err != nil {
+			return ScoutSubmission{}, err
+		}
+		if data == nil {
+			id = c.ID
+			data = make([]string, c.Total)
+		}
+		if c.ID != id || c.Total != len(data) {
+			return ScoutSubmission{}, errors.New("QR chunks belong to different submissions")
+		}
+		data[c.Part-1] = c.Data
+	}
+	for i, d := range data {
+		if d == "" {
+			return ScoutSubmission{}, fmt.Errorf("missing QR part %d of %d", i+1, len(data))
+		}
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.Join(data, ""))
+	if err != nil {
+		return ScoutSubmission{}, fmt.Errorf("QR data is corrupt: %w", err)
+	}
+	var q qrSubmission
+	if err := json.Unmarshal(decoded, &q); err != nil {
+		return ScoutSubmission{}, fmt.Errorf("QR data is corrupt: %w", err)
+	}
+	if q.Version != 1 || q.ID != id || q.EventKey == "" || (q.MatchKey == "" && q.MatchNum == 0) {
+		return ScoutSubmission{}, errors.New("QR submission is missing required fields")
+	}
+
+	sub := ScoutSubmission{
+		SubmissionID: q.ID,
+		EventKey:     q.EventKey,
+		MatchKey:     q.MatchKey,
+		MatchNum:     q.MatchNum,
+		ScouterID:    q.ScouterID,
+	}
+	for _, t := range q.Teams {
+		sub.Teams = append(sub.Teams, TeamScoutData{TeamNumber: t.TeamNumber, Notes: t.Notes, Fields: t.Fields})
+	}
+	return sub, nil
+}
+
+func qrScanPageHandler(w http.ResponseWriter, r *http.Request) {
+	templ.Handler(templates.QRScanPage()).ServeHTTP(w, r)
+}
+
+// apiQRImportHandler takes every scanned chunk of one submission and stores it
+// through the same path as /api/save-scout.
+func apiQRImportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Chunks []string `json:"chunks"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := decodeQRSubmission(req.Chunks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := submissionMatchID(&sub)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	saved, err := saveScoutSubmission(r.Context(), sub)
+	if err != nil {
+		var invalid invalidSubmissionError
+		if errors.As(err, &invalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			log.Printf("QR import: %v", err)
+			http.Error(w, "DB error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	log.Printf("QR import: %s, scouter %d, %d/%d teams", sub.MatchKey, sub.ScouterID, saved, len(sub.Teams))
+	if saved == 0 {
+		fmt.Fprintf(w, "%s from scouter %d was already imported", id.Label(), sub.ScouterID)
+		return
+	}
+	fmt.Fprintf(w, "Imported %s from scouter %d (%d teams)", id.Label(), sub.ScouterID, saved)
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a-h/templ"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+)
+
+// Official match results come from TBA's full match list, synced when
+// something needs them, and from match_score webhooks as matches end. They
+// live in match_results so the scout page and the analysis prompt have them
+// when TBA can't be reached.
+
+// resultSyncTimeout bounds a sync the scout page starts in the background.
+const resultSyncTimeout = 30 * time.Second
+
+// resultSyncInterval is how often the scout page and analyses may sync an
+// event, so a room of scouts refreshing or a bulk analysis job doesn't hit
+// TBA once per page or per team. Webhooks keep results current in between.
+// It's longer than resultSyncTimeout, so the scout page's background syncs
+// of an event never overlap.
+const resultSyncInterval = time.Minute
+
+var (
+	resultSyncsMu   sync.Mutex
+	lastResultSyncs = map[string]time.Time{} // when each event's last throttled sync started
+)
+
+// hasOfficialResults reports whether TBA scores the event. The test event
+// and custom events aren't on TBA.
+func hasOfficialResults(ctx context.Context, eventKey string) (bool, error) {
+	if eventKey == testEventKey {
+		return false, nil
+	}
+	custom, err := isCustomEvent(ctx, eventKey)
+	return !custom, err
+}
+
+// matchResultFromTBA converts a match from TBA, reporting false if it hasn't
+// been played.
+func matchResultFromTBA(eventKey string, m Match) (store.MatchResult, bool) {
+	red, blue := m.Alliances.Red, m.Alliances.Blue
+	if red.Score < 0 || blue.Score < 0 {
+		return store.MatchResult{}, false
+	}
+	id := m.ID(eventKey)
+	r := store.MatchResult{
+		EventKey:        eventKey,
+		MatchKey:        m.Key,
+		CompLevel:       id.CompLevel,
+		SetNumber:       id.SetNumber,
+		MatchNumber:     id.MatchNumber,
+		RedTeams:        stripFRC(red.TeamKeys),
+		BlueTeams:       stripFRC(blue.TeamKeys),
+		RedScore:        red.Score,
+		BlueScore:       blue.Score,
+		WinningAlliance: m.WinningAlliance,
+	}
+	if m.ActualTime > 0 {
+		r.PlayedAt = time.Unix(m.ActualTime, 0)
+	}
+	if b := m.ScoreBreakdown; b != nil {
+		r.RedBreakdown, r.BlueBreakdown = rawJSONOrNil(b.Red), rawJSONOrNil(b.Blue)
+	}
+	return r, true
+}
+
+func rawJSONOrNil(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return raw
+}
+
+// syncMatchResults stores every played match at eventKey from TBA's full
+// match list, which is cached like the schedule.
+func syncMatchResults(ctx context.Context, eventKey string) error {
+	if official, err := hasOfficialResults(ctx, eventKey); err != nil || !official {
+		return err
+	}
+	matches, err := getMatchResultsCached(ctx, eventKey)
+	if err != nil {
+		return err
+	}
+	var results []store.MatchResult
+	for _, m := range matches {
+		if r, ok := matchResultFromTBA(eventKey, m); ok {
+			results = append(results, r)
+		}
+	}
+	return repo.Results.Upsert(ctx, results)
+}
+
+// claimResultSync reports whether the caller may sync eventKey now, i.e. no
+// sync of it was started less than resultSyncInterval ago, and if so counts
+// one as started.
+func claimResultSync(eventKey string) bool {
+	resultSyncsMu.Lock()
+	defer resultSyncsMu.Unlock()
+	if time.Since(lastResultSyncs[eventKey]) < resultSyncInterval {
+		return false
+	}
+	lastResultSyncs[eventKey] = time.Now()
+	return true
+}
+
+// syncMatchResultsThrottled syncs eventKey unless claimResultSync says not
+// to yet.
+func syncMatchResultsThrottled(ctx context.Context, eventKey string) error {
+	if !claimResultSync(eventKey) {
+		return nil
+	}
+	return syncMatchResults(ctx, eventKey)
+}
+
+// breakdownHighlights picks the totals every season's score breakdown has
+// out of one alliance's: auto, teleop (which counts the endgame) and foul
+// points, and ranking points in quals.
+func breakdownHighlights(raw json.RawMessage) string {
+	var b map[string]any
+	if len(raw) == 0 || json.Unmarshal(raw, &b) != nil {
+		return ""
+	}
+	var parts []string
+	for _, f := range []struct{ Key, Format string }{
+		{"autoPoints", "auto %s"},
+		{"teleopPoints", "teleop %s"},
+		{"foulPoints", "fouls %s"},
+		{"rp", "%s RP"},
+	} {
+		if v, ok := b[f.Key].(float64); ok {
+			parts = append(parts, fmt.Sprintf(f.Format, formatChartValue(v)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func matchResultRow(r store.MatchResult) templates.MatchResultRow {
+	id := matchID{EventKey: r.EventKey, CompLevel: r.CompLevel, SetNumber: r.SetNumber, MatchNumber: r.MatchNumber}
+	return templates.MatchResultRow{
+		Label:      id.Label(),
+		Key:        r.MatchKey,
+		Red:        r.RedTeams,
+		Blue:       r.BlueTeams,
+		RedScore:   r.RedScore,
+		BlueScore:  r.BlueScore,
+		Winner:     r.WinningAlliance,
+		RedDetail:  breakdownHighlights(r.RedBreakdown),
+		BlueDetail: breakdownHighlights(r.BlueBreakdown),
+	}
+}
+
+// previousMatchResult is the result of the match before currentKey in the
+// sorted schedule, or nil if there is none yet. A missing result starts a
+// background sync, throttled per event, so it shows on a later page without
+// holding this one up.
+func previousMatchResult(ctx context.Context, eventKey string, sorted []Match, currentKey string) *templates.MatchResultRow {
+	i := slices.IndexFunc(sorted, func(m Match) bool { return m.Key == currentKey })
+	if i <= 0 {
+		return nil
+	}
+	prev := sorted[i-1].Key
+	r, err := repo.Results.Get(ctx, prev)
+	if err == nil {
+		row := matchResultRow(r)
+		return &row
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		log.Printf("scout page: %v", err)
+	} else if official, err := hasOfficialResults(ctx, eventKey); err != nil {
+		log.Printf("scout page: %v", err)
+	} else if official && claimResultSync(eventKey) {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), resultSyncTimeout)
+			defer cancel()
+			if err := syncMatchResults(ctx, eventKey); err != nil {
+				log.Printf("results: syncing %s: %v", eventKey, err)
+			}
+		}()
+	}
+	return nil
+}
+
+// teamResultsContext describes the team's official results at the event for
+// the analysis prompt: its record, then each match's alliances, score and
+// outcome. It's empty if the team hasn't played. Results are synced at most
+// once per resultSyncInterval, so a bulk analysis job syncs once, not per
+// team.
+func teamResultsContext(ctx context.Context, eventKey, teamNum string) (string, error) {
+	if err := syncMatchResultsThrottled(ctx, eventKey); err != nil {
+		log.Printf("team analysis %s: results: %v", teamNum, err)
+	}
+	results, err := repo.Results.ForTeam(ctx, eventKey, teamNum)
+	if err != nil || len(results) == 0 {
+		return "", err
+	}
+
+	var lines []string
+	var wins, losses, ties int
+	for _, r := range results {
+		side := r.Alliance(teamNum)
+		ours, theirs := r.RedScore, r.BlueScore
+		partners, opponents, breakdown := r.RedTeams, r.BlueTeams, r.RedBreakdown
+		if side == "blue" {
+			ours, theirs = theirs, ours
+			partners, opponents, breakdown = opponents, partners, r.BlueBreakdown
+		}
+		outcome := "tied"
+		switch r.WinningAlliance {
+		case "":
+			ties++
+		case side:
+			outcome = "won"
+			wins++
+		default:
+			outcome = "lost"
+			losses++
+		}
+		partners = slices.DeleteFunc(slices.Clone(partners), func(t string) bool { return t == teamNum })
+
+		id := matchID{EventKey: r.EventKey, CompLevel: r.CompLevel, SetNumber: r.SetNumber, MatchNumber: r.MatchNumber}
+		line := fmt.Sprintf("- %s (%s alliance with %s): %s %d-%d against %s",
+			id.Label(), side, strings.Join(partners, ", "), outcome, ours, theirs, strings.Join(opponents, ", "))
+		if h := breakdownHighlights(breakdown); h != "" {
+			line += "; alliance " + h
+		}
+		lines = append(lines, line)
+	}
+	return fmt.Sprintf("Record: %d-%d-%d (W-L-T)\n", wins, losses, ties) + strings.Join(lines, "\n"), nil
+}
+
+// resultsPageHandler serves /results?event_key=…: every played match's
+// official score.
+func resultsPageHandler(w http.ResponseWriter, r *http.Request) {
+	eventMap, err := currentEventMap(r.Context())
+	if err != nil {
+		http.Error(w, "Could not load events", 500)
+		return
+	}
+	ctx := r.Context()
+	data := templates.ResultsPageData{Events: eventMap, EventKey: r.URL.Query().Get("event_key")}
+	if data.EventKey == "" {
+		templ.Handler(templates.ResultsPage(data)).ServeHTTP(w, r)
+		return
+	}
+
+	if err := syncMatchResults(ctx, data.EventKey); err != nil {
+		log.Printf("results %s: %v", data.EventKey, err)
+		data.Error = "Couldn't reach The Blue Alliance; showing the results saved so far."
+	}
+	results, err := repo.Results.ForEvent(ctx, data.EventKey)
+	if err != nil {
+		log.Printf("results %s: %v", data.EventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	for _, res := range results {
+		data.Results = append(data.Results, matchResultRow(res))
+	}
+	templ.Handler(templates.ResultsPage(data)).ServeHTTP(w, r)
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+
+	"github.com/a-h/templ"
+)
+
+// Scouters sign up per event by name. The assignment generator then hands
+// each one a specific robot per match: scouters work shifts of a few matches
+// followed by a break, skip the qual matches they listed as away, and are
+// matched to robots so every team is seen by as many different people as
+// possible. A scouter's submissions use their roster ID as scouter_id and
+// keep their name in scouter_name. Roster IDs start above the anonymous
+// seats (see migration 5), so the two never share a scouter_id.
+
+// anonymousSeats is how many numbered seats the scouter picker offers for
+// an event without a roster.
+const anonymousSeats = 6
+
+type rosterScouter struct {
+	store.Scouter
+	away map[int]bool // qual match numbers from Breaks
+}
+
+type shiftOptions struct {
+	ShiftLength int // matches on before a break; 0 means no forced breaks
+	BreakLength int // matches off after a shift
+}
+
+var defaultShiftOptions = shiftOptions{ShiftLength: 6, BreakLength: 3}
+
+// parseBreaks reads a list like "12-18, 40" into a set of qual match numbers.
+func parseBreaks(s string) (map[int]bool, error) {
+	away := map[int]bool{}
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to, isRange := strings.Cut(part, "-")
+		lo, err := strconv.Atoi(from)
+		if err != nil || lo < 1 {
+			return nil, fmt.Errorf("bad break %q: use qual match numbers like 12-18, 40", part)
+		}
+		hi := lo
+		if isRange {
+			if hi, err = strconv.Atoi(to); err != nil || hi < lo {
+				return nil, fmt.Errorf("bad break %q: use qual match numbers like 12-18, 40", part)
+			}
+		}
+		for n := lo; n <= hi; n++ {
+			away[n] = true
+		}
+	}
+	return away, nil
+}
+
+func loadScouters(ctx context.Context, eventKey string) ([]rosterScouter, error) {
+	active, err := repo.Roster.Active(ctx, eventKey)
+	if err != nil {
+		return nil, err
+	}
+	scouters := make([]rosterScouter, len(active))
+	for i, sc := range active {
+		// Breaks are validated on the way in; anything unparsable is ignored
+		away, _ := parseBreaks(sc.Breaks)
+		scouters[i] = rosterScouter{Scouter: sc, away: away}
+	}
+	return scouters, nil
+}
+
+// findScouter returns the roster entry for a scouter ID, if the ID belongs
+// to this event's roster rather than an anonymous numbered seat.
+func findScouter(ctx context.Context, eventKey string, id int) (store.Scouter, bool, error) {
+	s, err := repo.Roster.Scouter(ctx, eventKey, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return store.Scouter{}, false, nil
+	}
+	if err != nil {
+		return store.Scouter{}, false, err
+	}
+	return s, true, nil
+}
+
+// ── Generator ─────────────────────────────────────────────────────────────────
+
+// generateAssignments walks the schedule in play order and fills each robot
+// slot. prior holds assignments that are kept as-is (matches already
+// scouted); they only count toward coverage.
+func generateAssignments(matches []Match, scouters []rosterScouter, opts shiftOptions, prior []store.Assignment) []store.Assignment {
+	sorted := append([]Match(nil), matches...)
+	sortMatches(sorted)
+
+	watched := map[int]map[string]int{} // scouter → team → times assigned
+	coverage := map[string]int{}        // team → times assigned to anyone
+	total := map[int]int{}
+	for _, s := range scouters {
+		watched[s.ID] = map[string]int{}
+	}
+	record := func(a store.Assignment) {
+		if watched[a.ScouterID] == nil {
+			watched[a.ScouterID] = map[string]int{}
+		}
+		watched[a.ScouterID][a.TeamNumber]++
+		coverage[a.TeamNumber]++
+		total[a.ScouterID]++
+	}
+	for _, a := range prior {
+		record(a)
+	}
+
+	streak := map[int]int{} // consecutive matches on the current shift
+	rest := map[int]int{}   // matches left on the current break
+
+	// Stagger the first shifts so breaks don't all land on the same matches
+	if opts.ShiftLength > 0 {
+		for i, s := range scouters {
+			streak[s.ID] = i * opts.ShiftLength / len(scouters)
+		}
+	}
+
+	var out []store.Assignment
+	for _, m := range sorted {
+		var teams []string
+		seen := map[string]bool{}
+		for _, t := range stripFRC(append(append([]string(nil), m.Alliances.Red.TeamKeys...), m.Alliances.Blue.TeamKeys...)) {
+			if !seen[t] {
+				seen[t] = true
+				teams = append(teams, t)
+			}
+		}
+		if len(teams) == 0 {
+			continue // playoff slot whose alliances aren't known yet
+		}
+
+		// Scouters mid-shift stay on; the rest are filled by who has worked least
+		var onShift, fresh []rosterScouter
+		for _, s := range scouters {
+			if rest[s.ID] > 0 || (m.CompLevel == "qm" && s.away[m.MatchNumber]) {
+				streak[s.ID] = 0
+				continue
+			}
+			if streak[s.ID] > 0 {
+				onShift = append(onShift, s)
+			} else {
+				fresh = append(fresh, s)
+			}
+		}
+		sort.SliceStable(onShift, func(i, j int) bool { return streak[onShift[i].ID] > streak[onShift[j].ID] })
+		sort.SliceStable(fresh, func(i, j int) bool { return total[fresh[i].ID] < total[fresh[j].ID] })
+		working := append(onShift, fresh...)
+		if len(working) > len(teams) {
+			working = working[:len(teams)]
+		}
+
+		// Pair scouters with robots greedily: fewest prior looks by this scouter
+		// at this robot first, then the robot with the least coverage overall.
+		usedScouter := map[int]bool{}
+		usedTeam := map[string]bool{}
+		for range working {
+			best, bestTeam := -1, ""
+			for i, s := range working {
+				if usedScouter[s.ID] {
+					continue
+				}
+				for _, t := range teams {
+					if usedTeam[t] {
+						continue
+					}
+					if best < 0 || watched[s.ID][t] < watched[working[best].ID][bestTeam] ||
+						(watched[s.ID][t] == watched[working[best].ID][bestTeam] && coverage[t] < coverage[bestTeam]) {
+						best, bestTeam = i, t
+					}
+				}
+			}
+			a := store.Assignment{MatchKey: m.Key, TeamNumber: bestTeam, ScouterID: working[best].ID}
+			usedScouter[a.ScouterID] = true
+			usedTeam[a.TeamNumber] = true
+			record(a)
+			out = append(out, a)
+		}
+
+		// Advance shifts and breaks
+		for _, s := range scouters {
+			switch {
+			case usedScouter[s.ID]:
+				streak[s.ID]++
+				if opts.ShiftLength > 0 && streak[s.ID] >= opts.ShiftLength {
+					streak[s.ID] = 0
+					rest[s.ID] = opts.BreakLength
+				}
+			case rest[s.ID] > 0:
+				rest[s.ID]--
+			default:
+				streak[s.ID] = 0
+			}
+		}
+	}
+	return out
+}
+
+// regenerateAssignments replaces every assignment for matches nobody has
+// scouted yet. Assignments for scouted matches are history and are kept.
+func regenerateAssignments(ctx context.Context, eventKey string, opts shiftOptions) error {
+	scouters, err := loadScouters(ctx, eventKey)
+	if err != nil {
+		return err
+	}
+	matches, err := getMatchesCached(ctx, eventKey)
+	if err != nil {
+		return err
+	}
+	existing, err := repo.Roster.Assignments(ctx, eventKey)
+	if err != nil {
+		return err
+	}
+
+	done, err := repo.Submissions.ScoutedMatches(ctx, eventKey)
+	if err != nil {
+		return err
+	}
+	var prior []store.Assignment
+	for _, a := range existing {
+		if done[a.MatchKey] {
+			prior = append(prior, a)
+		}
+	}
+	var open []Match
+	for _, m := range matches {
+		if !done[m.Key] {
+			open = append(open, m)
+		}
+	}
+	return repo.Roster.ReplaceOpenAssignments(ctx, eventKey, generateAssignments(open, scouters, opts, prior))
+}
+
+// scouterQueue lists a scouter's assignments in play order.
+func scouterQueue(ctx context.Context, eventKey string, scouterID int, matches []Match) ([]store.Assignment, error) {
+	assigned, err := repo.Roster.AssignmentsFor(ctx, eventKey, scouterID)
+	if err != nil {
+		return nil, err
+	}
+	byMatch := map[string]store.Assignment{}
+	for _, a := range assigned {
+		byMatch[a.MatchKey] = a
+	}
+
+	sorted := append([]Match(nil), matches...)
+	sortMatches(sorted)
+	var queue []store.Assignment
+	for _, m := range sorted {
+		if a, ok := byMatch[m.Key]; ok {
+			queue = append(queue, a)
+		}
+	}
+	return queue, nil
+}
+
+func assignedTeam(queue []store.Assignment, matchKey string) (string, bool) {
+	for _, a := range queue {
+		if a.MatchKey == matchKey {
+			return a.TeamNumber, true
+		}
+	}
+	return "", false
+}
+
+// nextAssignment is the scouter's first assigned match they haven't
+// submitted yet.
+func nextAssignment(ctx context.Context, eventKey string, scouterID int, matches []Match) (store.Assignment, bool, error) {
+	submitted, err := repo.Submissions.ScoutedMatchesBy(ctx, eventKey, scouterID)
+	if err != nil {
+		return store.Assignment{}, false, err
+	}
+	queue, err := scouterQueue(ctx, eventKey, scouterID, matches)
+	if err != nil {
+		return store.Assignment{}, false, err
+	}
+	for _, a := range queue {
+		if !submitted[a.MatchKey] {
+			return a, true, nil
+		}
+	}
+	return store.Assignment{}, false, nil
+}
+
+// ── Handlers ──────────────────────────────────────────────────────────────────
+
+func rosterPageHandler(w http.ResponseWriter, r *http.Request) {
+	eventMap, err := currentEventMap(r.Context())
+	if err != nil {
+		http.Error(w, "Could not load events", 500)
+		return
+	}
+
+	data := templates.RosterPageData{
+		Events:        eventMap,
+		SelectedEvent: r.URL.Query().Get("event_key"),
+	}
+	templ.Handler(templates.RosterPage(data)).ServeHTTP(w, r)
+}
+
+func renderRosterBoard(w http.ResponseWriter, r *http.Request, eventKey, message string) {
+	ctx := r.Context()
+	scouters, err := loadScouters(ctx, eventKey)
+	if err != nil {
+		log.Printf("roster %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	assignments, err := repo.Roster.Assignments(ctx, eventKey)
+	if err != nil {
+		log.Printf("roster %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+
+	board := templates.RosterBoard{
+		EventKey:    eventKey,
+		Message:     message,
+		ShiftLength: defaultShiftOptions.ShiftLength,
+		BreakLength: defaultShiftOptions.BreakLength,
+	}
+
+	names, err := repo.Roster.Names(ctx, eventKey)
+	if err != nil {
+		log.Printf("roster %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+
+	bySlot := map[string]int{} // match_key/team → scouter
+	perScouter := map[int]int{}
+	teamScouters := map[string]map[int]bool{}
+	for _, a := range assignments {
+		bySlot[a.MatchKey+"/"+a.TeamNumber] = a.ScouterID
+		perScouter[a.ScouterID]++
+		if teamScouters[a.TeamNumber] == nil {
+			teamScouters[a.TeamNumber] = map[int]bool{}
+		}
+		teamScouters[a.TeamNumber][a.ScouterID] = true
+	}
+
+	for _, s := range scouters {
+		board.Scouters = append(board.Scouters, templates.RosterScouter{
+			ID: s.ID, Name: s.Name, Breaks: s.Breaks, Assigned: perScouter[s.ID],
+		})
+	}
+
+	if len(assignments) > 0 {
+		matches, err := getMatchesCached(ctx, eventKey)
+		if err != nil {
+			board.Message = "Schedule unavailable: " + err.Error()
+		}
+		sortMatches(matches)
+		done, err := repo.Submissions.ScoutedMatches(ctx, eventKey)
+		if err != nil {
+			log.Printf("roster %s: %v", eventKey, err)
+			http.Error(w, "DB error", http.StatusInternalServerError)
+			return
+		}
+		matchCount := map[string]int{}
+		for _, m := range matches {
+			row := templates.AssignmentRow{MatchLabel: m.ID(eventKey).Label(), Scouted: done[m.Key]}
+			add := func(alliance string, keys []string) {
+				for _, t := range stripFRC(keys) {
+					matchCount[t]++
+					row.Slots = append(row.Slots, templates.AssignmentSlot{
+						Team: t, Alliance: alliance, Scouter: names[bySlot[m.Key+"/"+t]],
+					})
+				}
+			}
+			add("Red", m.Alliances.Red.TeamKeys)
+			add("Blue", m.Alliances.Blue.TeamKeys)
+			if len(row.Slots) > 0 {
+				board.Matches = append(board.Matches, row)
+			}
+		}
+		for team, n := range matchCount {
+			board.Coverage = append(board.Coverage, templates.TeamCoverage{
+				Team: team, Scouters: len(teamScouters[team]), Matches: n,
+			})
+		}
+		sort.Slice(board.Coverage, func(i, j int) bool {
+			a, b := board.Coverage[i], board.Coverage[j]
+			if a.Scouters != b.Scouters {
+				return a.Scouters < b.Scouters
+			}
+			ai, _ := strconv.Atoi(a.Team)
+			bi, _ := strconv.Atoi(b.Team)
+			return ai < bi
+		})
+	}
+
+	templates.RosterBoardView(board).Render(r.Context(), w)
+}
+
+func apiRosterBoardHandler(w http.ResponseWriter, r *http.Request) {
+	eventKey := r.FormValue("event_key")
+	if eventKey == "" || eventKey == "none" {
+		http.Error(w, "event_key required", http.StatusBadRequest)
+		return
+	}
+	renderRosterBoard(w, r, eventKey, "")
+}
+
+// apiRosterAddHandler adds a scouter, or updates the breaks of one already
+// on the roster (including one who was removed earlier).
+func apiRosterAddHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	eventKey := r.FormValue("event_key")
+	name := strings.TrimSpace(r.FormValue("name"))
+	breaks := strings.TrimSpace(r.FormValue("breaks"))
+	if eventKey == "" || name == "" {
+		http.Error(w, "event_key and name required", http.StatusBadRequest)
+		return
+	}
+	if _, err := parseBreaks(breaks); err != nil {
+		renderRosterBoard(w, r, eventKey, err.Error())
+		return
+	}
+
+	if err := repo.Roster.Add(r.Context(), eventKey, name, breaks); err != nil {
+		log.Printf("roster %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	renderRosterBoard(w, r, eventKey, "Saved "+name+". Regenerate assignments to include the change.")
+}
+
+// apiRosterRemoveHandler takes a scouter off the roster. Their assignments
+// for unscouted matches become gaps until assignments are regenerated.
+func apiRosterRemoveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	eventKey := r.FormValue("event_key")
+	id, _ := strconv.Atoi(r.FormValue("scouter_id"))
+	if eventKey == "" || id == 0 {
+		http.Error(w, "event_key and scouter_id required", http.StatusBadRequest)
+		return
+	}
+
+	if err := repo.Roster.Remove(r.Context(), eventKey, id); err != nil {
+		log.Printf("roster %s: %v", eventKey, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	renderRosterBoard(w, r, eventKey, "")
+}
+
+func apiRosterGenerateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	eventKey := r.FormValue("event_key")
+	if eventKey == "" {
+		http.Error(w, "event_key required", http.StatusBadRequest)
+		return
+	}
+	opts := defaultShiftOptions
+	if n, err := strconv.Atoi(r.FormValue("shift_length")); err == nil && n >= 0 {
+		opts.ShiftLength = n
+	}
+	if n, err := strconv.Atoi(r.FormValue("break_length")); err == nil && n >= 0 {
+		opts.BreakLength = n
+	}
+
+	if err := regenerateAssignments(r.Context(), eventKey, opts); err != nil {
+		renderRosterBoard(w, r, eventKey, "Failed to generate assignments: "+err.Error())
+		return
+	}
+	renderRosterBoard(w, r, eventKey, "")
+}
+
+// apiScouterOptionsHandler fills the home page's scouter picker: the event
+// roster if there is one, otherwise the anonymous numbered seats.
+func apiScouterOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	var options []templates.ScouterOption
+	scouters, err := loadScouters(r.Context(), r.FormValue("event_key"))
+	if err != nil {
+		log.Printf("scouter options: %v", err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	for _, s := range scouters {
+		options = append(options, templates.ScouterOption{ID: s.ID, Name: s.Name})
+	}
+	if len(options) == 0 {
+		for i := 1; i <= anonymousSeats; i++ {
+			options = append(options, templates.ScouterOption{ID: i, Name: fmt.Sprintf("Scouter %d", i)})
+		}
+	}
+	templates.ScouterOptions(options).Render(r.Context(), w)
+}
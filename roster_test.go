@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"vibe-scout/store"
+)
+
+// testQuals is a qual schedule of n matches over teams, shuffled
+// deterministically so every team plays about as often.
+func testQuals(eventKey string, teams, n int) []Match {
+	rng := rand.New(rand.NewPCG(6238, uint64(teams)))
+	var pool []string
+	var matches []Match
+	for i := 1; i <= n; i++ {
+		if len(pool) < 6 {
+			for t := 1; t <= teams; t++ {
+				pool = append(pool, fmt.Sprintf("frc%d", 100+t))
+			}
+			rng.Shuffle(len(pool), func(a, b int) { pool[a], pool[b] = pool[b], pool[a] })
+		}
+		m := Match{Key: fmt.Sprintf("%s_qm%d", eventKey, i), CompLevel: "qm", SetNumber: 1, MatchNumber: i}
+		m.Alliances.Red.TeamKeys = pool[:3]
+		m.Alliances.Blue.TeamKeys = pool[3:6]
+		pool = pool[6:]
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+func testRoster(breaks ...string) []rosterScouter {
+	scouters := make([]rosterScouter, len(breaks))
+	for i, b := range breaks {
+		away, err := parseBreaks(b)
+		if err != nil {
+			panic(err)
+		}
+		scouters[i] = rosterScouter{Scouter: store.Scouter{ID: 1001 + i, Name: fmt.Sprintf("Scouter %d", i+1), Breaks: b}, away: away}
+	}
+	return scouters
+}
+
+func TestGenerateAssignments(t *testing.T) {
+	tests := []struct {
+		name       string
+		teams      int
+		matches    int
+		scouters   []rosterScouter
+		opts       shiftOptions
+		minWatched int // distinct scouters every team is seen by
+	}{
+		{
+			name: "no forced breaks", teams: 18, matches: 36,
+			scouters:   testRoster("", "", "", "", "", ""),
+			opts:       shiftOptions{},
+			minWatched: 5,
+		},
+		{
+			name: "shifts and breaks", teams: 24, matches: 48,
+			scouters:   testRoster("", "", "", "", "", "", "", "", ""),
+			opts:       defaultShiftOptions,
+			minWatched: 5,
+		},
+		{
+			name: "listed away matches", teams: 30, matches: 60,
+			scouters:   testRoster("1-5", "20-30", "", "41, 43, 45", "", "", "", "12-14", "55-60"),
+			opts:       shiftOptions{ShiftLength: 4, BreakLength: 2},
+			minWatched: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := testQuals("2026test", tt.teams, tt.matches)
+			got := generateAssignments(matches, tt.scouters, tt.opts, nil)
+
+			byMatch := map[string]map[int]string{} // match → scouter → team
+			for _, a := range got {
+				if byMatch[a.MatchKey] == nil {
+					byMatch[a.MatchKey] = map[int]string{}
+				}
+				if _, dup := byMatch[a.MatchKey][a.ScouterID]; dup {
+					t.Errorf("scouter %d has two robots in %s", a.ScouterID, a.MatchKey)
+				}
+				byMatch[a.MatchKey][a.ScouterID] = a.TeamNumber
+			}
+
+			watchers := map[string]map[int]bool{}
+			for _, s := range tt.scouters {
+				run, breakLeft := 0, 0 // matches into the current shift, and left of the current break
+				for _, m := range matches {
+					team, on := byMatch[m.Key][s.ID]
+					if !on {
+						run = 0
+						if breakLeft > 0 {
+							breakLeft--
+						}
+						continue
+					}
+					if s.away[m.MatchNumber] {
+						t.Errorf("%s assigned to %s, which they listed as away", s.Name, m.Key)
+					}
+					if breakLeft > 0 {
+						t.Errorf("%s assigned to %s with %d matches of their break left", s.Name, m.Key, breakLeft)
+					}
+					run++
+					if tt.opts.ShiftLength > 0 && run == tt.opts.ShiftLength {
+						run, breakLeft = 0, tt.opts.BreakLength
+					}
+					if watchers[team] == nil {
+						watchers[team] = map[int]bool{}
+					}
+					watchers[team][s.ID] = true
+				}
+			}
+
+			for i := 1; i <= tt.teams; i++ {
+				team := fmt.Sprint(100 + i)
+				if n := len(watchers[team]); n < tt.minWatched {
+					t.Errorf("team %s seen by %d scouters, want at least %d", team, n, tt.minWatched)
+				}
+			}
+		})
+	}
+}
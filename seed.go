@@ -1,5 +1,11 @@
 package main
 
+import (
+	"context"
+
+	"vibe-scout/store"
+)
+
 const testEventKey = "2026test"
 const testEventName = "Test Event 2026"
 
@@ -49,6 +55,13 @@ var testMatches = []Match{
 	}},
 }
 
+// testResults are official scores for the first four test matches, by
+// match number, so results show before the test event is over.
+var testResults = map[int][2]int{1: {87, 64}, 2: {52, 71}, 3: {95, 95}, 4: {60, 78}}
+
+// testRankings is the fake qualification ranking order for the test event.
+var testRankings = []string{"1001", "1004", "1009", "1002", "1006", "1008", "1005", "1003", "1007"}
+
 type seedObs struct {
 	matchNum int
 	team     string
@@ -109,17 +122,56 @@ var testObservations = []seedObs{
 	{6, "1009", "E-stopped at the 30 second mark — connection issue. Had already scored 3 notes. Unreliable."},
 }
 
-func seedTestData() {
+func seedTestData(ctx context.Context) error {
 	// Clear existing test event data
-	db.Exec("DELETE FROM scout_submissions WHERE event_key = ?", testEventKey)
-	db.Exec("DELETE FROM analysis_cache WHERE event_key = ?", testEventKey)
-	db.Exec("DELETE FROM match_plan_cache WHERE event_key = ?", testEventKey)
+	if err := repo.ClearEvent(ctx, testEventKey); err != nil {
+		return err
+	}
 
 	// Insert fake observations (scouter_id 1 for all)
-	for _, obs := range testObservations {
-		db.Exec(`
-			INSERT INTO scout_submissions (event_key, match_num, scouter_id, team_number, notes)
-			VALUES (?, ?, 1, ?, ?)`,
-			testEventKey, obs.matchNum, obs.team, obs.notes)
+	subs := make([]store.Submission, len(testObservations))
+	for i, obs := range testObservations {
+		id := matchID{EventKey: testEventKey, CompLevel: "qm", SetNumber: 1, MatchNumber: obs.matchNum}
+		subs[i] = store.Submission{
+			EventKey:    testEventKey,
+			MatchKey:    id.Key(),
+			CompLevel:   id.CompLevel,
+			SetNumber:   id.SetNumber,
+			MatchNumber: id.MatchNumber,
+			ScouterID:   1,
+			TeamNumber:  obs.team,
+			Notes:       obs.notes,
+		}
+	}
+	if _, err := repo.Submissions.Save(ctx, subs); err != nil {
+		return err
+	}
+
+	var results []store.MatchResult
+	for _, m := range testMatches {
+		score, ok := testResults[m.MatchNumber]
+		if !ok {
+			continue
+		}
+		id := m.ID(testEventKey)
+		r := store.MatchResult{
+			EventKey:    testEventKey,
+			MatchKey:    m.Key,
+			CompLevel:   id.CompLevel,
+			SetNumber:   id.SetNumber,
+			MatchNumber: id.MatchNumber,
+			RedTeams:    stripFRC(m.Alliances.Red.TeamKeys),
+			BlueTeams:   stripFRC(m.Alliances.Blue.TeamKeys),
+			RedScore:    score[0],
+			BlueScore:   score[1],
+		}
+		switch {
+		case score[0] > score[1]:
+			r.WinningAlliance = "red"
+		case score[1] > score[0]:
+			r.WinningAlliance = "blue"
+		}
+		results = append(results, r)
 	}
+	return repo.Results.Upsert(ctx, results)
 }
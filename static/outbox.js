@@ -0,0 +1,113 @@
+// Offline outbox for scout submissions. Loaded by the scout page and imported
+// by the service worker so both can queue and replay submissions.
+//
+// Every submission carries a client-generated submission_id; the server
+// ignores repeats, so replaying the same entry twice is always safe.
+(function (global) {
+	const DB_NAME = 'vibe-scout';
+	const STORE = 'outbox';
+
+	function openDB() {
+		return new Promise((resolve, reject) => {
+			const req = indexedDB.open(DB_NAME, 1);
+			req.onupgradeneeded = () => {
+				req.result.createObjectStore(STORE, { keyPath: 'submission_id' });
+			};
+			req.onsuccess = () => resolve(req.result);
+			req.onerror = () => reject(req.error);
+		});
+	}
+
+	async function withStore(mode, fn) {
+		const db = await openDB();
+		return new Promise((resolve, reject) => {
+			const tx = db.transaction(STORE, mode);
+			const req = fn(tx.objectStore(STORE));
+			tx.oncomplete = () => resolve(req.result);
+			tx.onerror = () => reject(tx.error);
+		});
+	}
+
+	function enqueue(submission) {
+		return withStore('readwrite', store => store.put(submission));
+	}
+
+	function remove(id) {
+		return withStore('readwrite', store => store.delete(id));
+	}
+
+	function pending() {
+		return withStore('readonly', store => store.getAll());
+	}
+
+	// send posts one submission. It resolves to 'ok', 'rejected' (the server
+	// refused it and retrying will not help) or 'retry' (network or server
+	// trouble).
+	async function send(submission) {
+		try {
+			const resp = await fetch('/api/save-scout', {
+				method: 'POST',
+				headers: { 'Content-Type': 'application/json' },
+				body: JSON.stringify(submission)
+			});
+			if (resp.ok) return 'ok';
+			// Logged out: keep it queued until the scouter signs back in
+			if (resp.status === 401) return 'retry';
+			if (resp.status >= 400 && resp.status < 500) return 'rejected';
+			return 'retry';
+		} catch (err) {
+			return 'retry';
+		}
+	}
+
+	// flush replays queued submissions oldest first and stops at the first one
+	// that still cannot be delivered. Rejected submissions stay in the outbox,
+	// flagged, so the notes are never silently thrown away.
+	async function flush() {
+		const items = (await pending())
+			.filter(item => !item.rejected)
+			.sort((a, b) => (a.queued_at || 0) - (b.queued_at || 0));
+		let sent = 0;
+		for (const item of items) {
+			const result = await send(item);
+			if (result === 'retry') break;
+			if (result === 'ok') {
+				await remove(item.submission_id);
+				sent++;
+			} else {
+				item.rejected = true;
+				await enqueue(item);
+			}
+		}
+		return sent;
+	}
+
+	// toQRChunks encodes a submission in the versioned QR transfer format
+	// understood by /api/admin/qr-import (see qrtransfer.go):
+	//   VS1|<submission_id>|<part>|<total>|<base64url chunk>
+	function toQRChunks(submission, chunkSize) {
+		chunkSize = chunkSize || 500;
+		const compact = {
+			v: 1,
+			id: submission.submission_id,
+			e: submission.event_key,
+			k: submission.match_key,
+			m: submission.match_num,
+			s: submission.scouter_id,
+			t: submission.teams.map(t => ({ n: t.team_number, x: t.notes, f: t.fields }))
+		};
+		const bytes = new TextEncoder().encode(JSON.stringify(compact));
+		let bin = '';
+		bytes.forEach(b => { bin += String.fromCharCode(b); });
+		const data = btoa(bin).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+
+		const total = Math.max(1, Math.ceil(data.length / chunkSize));
+		const chunks = [];
+		for (let i = 0; i < total; i++) {
+			chunks.push(['VS1', compact.id, i + 1, total, data.slice(i * chunkSize, (i + 1) * chunkSize)].join('|'));
+		}
+		return chunks;
+	}
+
+	global.scoutOutbox = { enqueue, remove, pending, send, flush, toQRChunks };
+})(self);
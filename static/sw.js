@@ -0,0 +1,93 @@
+// Service worker for offline scouting. Scout pages and the event schedule are
+// served network-first and fall back to the cache; CDN assets are cache-first.
+importScripts('/static/outbox.js');
+
+const CACHE = 'vibe-scout-v1';
+
+self.addEventListener('install', event => {
+	event.waitUntil(caches.open(CACHE).then(cache => cache.addAll(['/static/outbox.js'])));
+	self.skipWaiting();
+});
+
+self.addEventListener('activate', event => {
+	event.waitUntil((async () => {
+		for (const key of await caches.keys()) {
+			if (key !== CACHE) await caches.delete(key);
+		}
+		await self.clients.claim();
+	})());
+});
+
+self.addEventListener('fetch', event => {
+	const req = event.request;
+	if (req.method !== 'GET') return;
+	const url = new URL(req.url);
+
+	if (url.origin !== self.location.origin) {
+		event.respondWith(cacheFirst(req));
+	} else if (url.pathname === '/scout' || url.pathname === '/api/schedule' || url.pathname.startsWith('/static/')) {
+		event.respondWith(networkFirst(req));
+	}
+});
+
+// The scout page asks us to warm the cache with upcoming matches so the
+// scouter can keep advancing after the Wi-Fi drops.
+self.addEventListener('message', event => {
+	if (event.data && event.data.type === 'precache') {
+		event.waitUntil(precache(event.data.urls || []));
+	}
+});
+
+self.addEventListener('sync', event => {
+	if (event.tag === 'scout-outbox') {
+		event.waitUntil(self.scoutOutbox.flush());
+	}
+});
+
+async function cacheFirst(req) {
+	const cached = await caches.match(req);
+	if (cached) return cached;
+	const resp = await fetch(req);
+	const cache = await caches.open(CACHE);
+	cache.put(req, resp.clone());
+	return resp;
+}
+
+async function networkFirst(req) {
+	const cache = await caches.open(CACHE);
+	try {
+		const resp = await fetch(req);
+		// A redirect means the session expired and this is the login page
+		if (resp.ok && !resp.redirected) cache.put(req, resp.clone());
+		return resp;
+	} catch (err) {
+		const cached = await cache.match(req);
+		if (cached) return cached;
+		return offlineFallback(req);
+	}
+}
+
+async function precache(urls) {
+	const cache = await caches.open(CACHE);
+	for (const u of urls) {
+		if (await cache.match(u)) continue;
+		try {
+			const resp = await fetch(u);
+			if (resp.ok && !resp.redirected) await cache.put(u, resp);
+		} catch (err) {
+			return; // offline again, try next time
+		}
+	}
+}
+
+function offlineFallback(req) {
+	if (new URL(req.url).pathname === '/api/schedule') {
+		return new Response('[]', { status: 503, headers: { 'Content-Type': 'application/json' } });
+	}
+	const html = '<!DOCTYPE html><meta name="viewport" content="width=device-width, initial-scale=1.0">' +
+		'<body style="font-family:sans-serif;background:#F7F0E6;color:#5D4037;padding:2rem;text-align:center">' +
+		'<h1>Offline</h1><p>This match was not cached before the connection dropped.</p>' +
+		'<p>Your saved notes are still queued and will upload when you are back online.</p>' +
+		'<p><a href="javascript:history.back()">← Back</a></p></body>';
+	return new Response(html, { status: 503, headers: { 'Content-Type': 'text/html; charset=utf-8' } });
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// APICache is api_cache: the last good response from TBA or Statbotics for
+// each URL, with the validators to revalidate it.
+type APICache struct {
+	db *sql.DB
+}
+
+// CachedResponse is one stored response. Expired means the next read should
+// revalidate whatever its age.
+type CachedResponse struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+	FetchedAt    time.Time
+	Expired      bool
+}
+
+// Get returns ErrNotFound if nothing is stored for url.
+func (a *APICache) Get(ctx context.Context, url string) (CachedResponse, error) {
+	var c CachedResponse
+	var etag, lastModified sql.NullString
+	err := a.db.QueryRowContext(ctx, `
+		SELECT body, etag, last_modified, fetched_at, expired FROM api_cache WHERE url = ?`, url).
+		Scan(&c.Body, &etag, &lastModified, &c.FetchedAt, &c.Expired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CachedResponse{}, ErrNotFound
+	}
+	if err != nil {
+		return CachedResponse{}, fmt.Errorf("api cache: %s: %w", url, err)
+	}
+	c.ETag, c.LastModified = etag.String, lastModified.String
+	return c, nil
+}
+
+// Put stores c as url's current response. c.Expired is ignored.
+func (a *APICache) Put(ctx context.Context, url string, c CachedResponse) error {
+	_, err := a.db.ExecContext(ctx, `
+		INSERT INTO api_cache (url, body, etag, last_modified, fetched_at, expired)
+		VALUES (?, ?, ?, ?, ?, 0)
+		ON CONFLICT(url) DO UPDATE SET
+			body = excluded.body,
+			etag = excluded.etag,
+			last_modified = excluded.last_modified,
+			fetched_at = excluded.fetched_at,
+			expired = 0`,
+		url, c.Body, NullString(c.ETag), NullString(c.LastModified), c.FetchedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("api cache: storing %s: %w", url, err)
+	}
+	return nil
+}
+
+// Touch marks url's stored response current as of at, after upstream said
+// it hasn't changed.
+func (a *APICache) Touch(ctx context.Context, url string, at time.Time) error {
+	if _, err := a.db.ExecContext(ctx, `UPDATE api_cache SET fetched_at = ?, expired = 0 WHERE url = ?`, at.UTC(), url); err != nil {
+		return fmt.Errorf("api cache: %s: %w", url, err)
+	}
+	return nil
+}
+
+// Expire makes the next read of url revalidate. The stored copy stays as
+// the fallback.
+func (a *APICache) Expire(ctx context.Context, url string) error {
+	if _, err := a.db.ExecContext(ctx, `UPDATE api_cache SET expired = 1 WHERE url = ?`, url); err != nil {
+		return fmt.Errorf("api cache: expiring %s: %w", url, err)
+	}
+	return nil
+}
+
+// ReplaceBody swaps in an edited body for url, dropping the validators
+// since it no longer matches upstream's.
+func (a *APICache) ReplaceBody(ctx context.Context, url string, body []byte) error {
+	if _, err := a.db.ExecContext(ctx, `UPDATE api_cache SET body = ?, etag = NULL, last_modified = NULL WHERE url = ?`, body, url); err != nil {
+		return fmt.Errorf("api cache: updating %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CachedText is a generated result and the hash of the notes it was
+// generated from; a different hash means the notes changed since.
+type CachedText struct {
+	Text      string
+	NotesHash string
+}
+
+// AnalysisCache is analysis_cache: one LLM team analysis (as JSON) per team
+// per event.
+type AnalysisCache struct {
+	db *sql.DB
+}
+
+// Get returns ErrNotFound if the team has no cached analysis.
+func (c *AnalysisCache) Get(ctx context.Context, eventKey, teamNumber string) (CachedText, error) {
+	var e CachedText
+	err := c.db.QueryRowContext(ctx, `
+		SELECT analysis, notes_hash FROM analysis_cache
+		WHERE event_key = ? AND team_number = ?`,
+		eventKey, teamNumber).Scan(&e.Text, &e.NotesHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CachedText{}, ErrNotFound
+	}
+	if err != nil {
+		return CachedText{}, fmt.Errorf("analysis for %s team %s: %w", eventKey, teamNumber, err)
+	}
+	return e, nil
+}
+
+func (c *AnalysisCache) Put(ctx context.Context, eventKey, teamNumber string, e CachedText) error {
+	_, err := c.db.ExecContext(ctx, `
+		INSERT INTO analysis_cache (event_key, team_number, analysis, notes_hash)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(event_key, team_number) DO UPDATE SET
+			analysis = excluded.analysis,
+			notes_hash = excluded.notes_hash,
+			created_at = CURRENT_TIMESTAMP`,
+		eventKey, teamNumber, e.Text, e.NotesHash)
+	if err != nil {
+		return fmt.Errorf("store analysis for %s team %s: %w", eventKey, teamNumber, err)
+	}
+	return nil
+}
+
+func deleteAnalysis(ctx context.Context, tx *sql.Tx, eventKey, teamNumber string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM analysis_cache WHERE event_key = ? AND team_number = ?`, eventKey, teamNumber)
+	if err != nil {
+		return fmt.Errorf("drop analysis for %s team %s: %w", eventKey, teamNumber, err)
+	}
+	return nil
+}
+
+// MatchPlanCache is match_plan_cache: one LLM strategy per team per match.
+type MatchPlanCache struct {
+	db *sql.DB
+}
+
+// Get returns ErrNotFound if there's no cached plan.
+func (c *MatchPlanCache) Get(ctx context.Context, eventKey, teamNumber, matchKey string) (CachedText, error) {
+	var e CachedText
+	err := c.db.QueryRowContext(ctx, `
+		SELECT strategy, notes_hash FROM match_plan_cache
+		WHERE event_key = ? AND team_number = ? AND match_key = ?`,
+		eventKey, teamNumber, matchKey).Scan(&e.Text, &e.NotesHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CachedText{}, ErrNotFound
+	}
+	if err != nil {
+		return CachedText{}, fmt.Errorf("match plan for %s team %s: %w", matchKey, teamNumber, err)
+	}
+	return e, nil
+}
+
+func (c *MatchPlanCache) Put(ctx context.Context, eventKey, teamNumber, matchKey string, e CachedText) error {
+	_, err := c.db.ExecContext(ctx, `
+		INSERT INTO match_plan_cache (event_key, team_number, match_key, strategy, notes_hash)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(event_key, team_number, match_key) DO UPDATE SET
+			strategy = excluded.strategy,
+			notes_hash = excluded.notes_hash,
+			created_at = CURRENT_TIMESTAMP`,
+		eventKey, teamNumber, matchKey, e.Text, e.NotesHash)
+	if err != nil {
+		return fmt.Errorf("store match plan for %s team %s: %w", matchKey, teamNumber, err)
+	}
+	return nil
+}
+
+// DeleteMatch drops every team's plan for a match, e.g. when its alliances
+// change.
+func (c *MatchPlanCache) DeleteMatch(ctx context.Context, eventKey, matchKey string) error {
+	_, err := c.db.ExecContext(ctx, `DELETE FROM match_plan_cache WHERE event_key = ? AND match_key = ?`, eventKey, matchKey)
+	if err != nil {
+		return fmt.Errorf("drop match plans for %s: %w", matchKey, err)
+	}
+	return nil
+}
+
+// Analysis is a team's cached analysis as listed by ForEvent and ForTeam.
+type Analysis struct {
+	EventKey   string
+	TeamNumber string
+	CachedText
+	CreatedAt time.Time
+}
+
+// ForEvent lists the cached analyses at an event by team.
+func (c *AnalysisCache) ForEvent(ctx context.Context, eventKey string) ([]Analysis, error) {
+	out, err := c.query(ctx, `event_key = ? ORDER BY CAST(team_number AS INTEGER), team_number`, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("analyses for %s: %w", eventKey, err)
+	}
+	return out, nil
+}
+
+// ForTeam lists a team's cached analyses at every event, oldest first.
+func (c *AnalysisCache) ForTeam(ctx context.Context, teamNumber string) ([]Analysis, error) {
+	out, err := c.query(ctx, `team_number = ? ORDER BY created_at, event_key`, teamNumber)
+	if err != nil {
+		return nil, fmt.Errorf("analyses for team %s: %w", teamNumber, err)
+	}
+	return out, nil
+}
+
+func (c *AnalysisCache) query(ctx context.Context, where string, args ...any) ([]Analysis, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT event_key, team_number, analysis, notes_hash, created_at FROM analysis_cache
+		WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Analysis
+	for rows.Next() {
+		var a Analysis
+		var createdAt sql.NullTime
+		if err := rows.Scan(&a.EventKey, &a.TeamNumber, &a.Text, &a.NotesHash, &createdAt); err != nil {
+			return nil, err
+		}
+		a.CreatedAt = createdAt.Time
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// MatchPlan is a cached plan as listed by ForEvent.
+type MatchPlan struct {
+	MatchKey   string
+	TeamNumber string
+	CachedText
+	CreatedAt time.Time
+}
+
+// ForEvent lists the cached match plans at an event by match, then team.
+func (c *MatchPlanCache) ForEvent(ctx context.Context, eventKey string) ([]MatchPlan, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT match_key, team_number, strategy, notes_hash, created_at FROM match_plan_cache
+		WHERE event_key = ?
+		ORDER BY match_key, CAST(team_number AS INTEGER)`, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("match plans for %s: %w", eventKey, err)
+	}
+	defer rows.Close()
+
+	var out []MatchPlan
+	for rows.Next() {
+		var p MatchPlan
+		var createdAt sql.NullTime
+		if err := rows.Scan(&p.MatchKey, &p.TeamNumber, &p.Text, &p.NotesHash, &createdAt); err != nil {
+			return nil, fmt.Errorf("match plans for %s: %w", eventKey, err)
+		}
+		p.CreatedAt = createdAt.Time
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("match plans for %s: %w", eventKey, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// CustomEvents is custom_events and custom_matches: events TBA doesn't
+// cover and the qualification schedules uploaded for them.
+type CustomEvents struct {
+	db *sql.DB
+}
+
+// CustomEvent is one custom event. Matches is how many matches its
+// schedule has; Save ignores it.
+type CustomEvent struct {
+	Key       string
+	Name      string
+	StartDate string // YYYY-MM-DD
+	Matches   int
+}
+
+// CustomMatch is one scheduled match. Teams are TBA team keys.
+type CustomMatch struct {
+	MatchKey    string
+	CompLevel   string
+	SetNumber   int
+	MatchNumber int
+	RedTeams    []string
+	BlueTeams   []string
+}
+
+// Exists reports whether eventKey is a custom event.
+func (c *CustomEvents) Exists(ctx context.Context, eventKey string) (bool, error) {
+	var n int
+	if err := c.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM custom_events WHERE event_key = ?`, eventKey).Scan(&n); err != nil {
+		return false, fmt.Errorf("custom event %s: %w", eventKey, err)
+	}
+	return n > 0, nil
+}
+
+// Matches lists the event's uploaded schedule in no particular order.
+func (c *CustomEvents) Matches(ctx context.Context, eventKey string) ([]CustomMatch, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT match_key, comp_level, set_number, match_number, red_teams, blue_teams
+		FROM custom_matches WHERE event_key = ?`, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("schedule for %s: %w", eventKey, err)
+	}
+	defer rows.Close()
+
+	var out []CustomMatch
+	for rows.Next() {
+		var m CustomMatch
+		var red, blue string
+		if err := rows.Scan(&m.MatchKey, &m.CompLevel, &m.SetNumber, &m.MatchNumber, &red, &blue); err != nil {
+			return nil, fmt.Errorf("schedule for %s: %w", eventKey, err)
+		}
+		m.RedTeams, m.BlueTeams = strings.Split(red, ","), strings.Split(blue, ",")
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("schedule for %s: %w", eventKey, err)
+	}
+	return out, nil
+}
+
+// List returns every custom event, newest first, with its match count.
+func (c *CustomEvents) List(ctx context.Context) ([]CustomEvent, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT e.event_key, e.name, e.start_date, COUNT(m.match_key)
+		FROM custom_events e LEFT JOIN custom_matches m ON m.event_key = e.event_key
+		GROUP BY e.event_key ORDER BY e.start_date DESC, e.event_key`)
+	if err != nil {
+		return nil, fmt.Errorf("custom events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []CustomEvent
+	for rows.Next() {
+		var e CustomEvent
+		if err := rows.Scan(&e.Key, &e.Name, &e.StartDate, &e.Matches); err != nil {
+			return nil, fmt.Errorf("custom events: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("custom events: %w", err)
+	}
+	return out, nil
+}
+
+// Save creates or renames the event and, if matches isn't nil, replaces its
+// schedule.
+func (c *CustomEvents) Save(ctx context.Context, e CustomEvent, matches []CustomMatch) error {
+	return inTx(ctx, c.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO custom_events (event_key, name, start_date) VALUES (?, ?, ?)
+			ON CONFLICT(event_key) DO UPDATE SET name = excluded.name, start_date = excluded.start_date`,
+			e.Key, e.Name, e.StartDate); err != nil {
+			return fmt.Errorf("save custom event %s: %w", e.Key, err)
+		}
+		if matches == nil {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM custom_matches WHERE event_key = ?`, e.Key); err != nil {
+			return fmt.Errorf("clear schedule for %s: %w", e.Key, err)
+		}
+		for _, m := range matches {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO custom_matches (event_key, match_key, comp_level, set_number, match_number, red_teams, blue_teams)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				e.Key, m.MatchKey, m.CompLevel, m.SetNumber, m.MatchNumber,
+				strings.Join(m.RedTeams, ","), strings.Join(m.BlueTeams, ",")); err != nil {
+				return fmt.Errorf("save %s: %w", m.MatchKey, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes a custom event and its schedule. Scouting data for the
+// event is kept.
+func (c *CustomEvents) Delete(ctx context.Context, eventKey string) error {
+	return inTx(ctx, c.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM custom_matches WHERE event_key = ?`, eventKey); err != nil {
+			return fmt.Errorf("delete schedule for %s: %w", eventKey, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM custom_events WHERE event_key = ?`, eventKey); err != nil {
+			return fmt.Errorf("delete custom event %s: %w", eventKey, err)
+		}
+		return nil
+	})
+}
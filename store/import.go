@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// EventImport is one event's data from another Vibe Scout instance.
+type EventImport struct {
+	EventKey    string
+	Source      string // the partner team, for rows that don't name one
+	Submissions []Submission
+	Analyses    []Analysis
+	MatchPlans  []MatchPlan
+	PickLists   []PickList
+}
+
+// ImportCounts is how many rows ImportEvent added. Rows already present are
+// not counted.
+type ImportCounts struct {
+	Submissions int
+	Analyses    int
+	MatchPlans  int
+	PickLists   int
+
+	// PickListsSkipped is set when the bundle's pick lists were left out
+	// because the event already has its own.
+	PickListsSkipped bool
+}
+
+// ImportEvent adds imp in one transaction. Submissions already imported are
+// skipped by UUID, so importing a bundle twice is harmless. Cached analyses
+// and match plans only fill gaps, and pick lists are only imported for an
+// event with none: local work always wins.
+func (s *Store) ImportEvent(ctx context.Context, imp EventImport) (ImportCounts, error) {
+	var n ImportCounts
+	err := inTx(ctx, s.db, func(tx *sql.Tx) error {
+		n = ImportCounts{}
+		for _, sub := range imp.Submissions {
+			sub.EventKey = imp.EventKey
+			sub.ScouterID = 0
+			if sub.Source == "" {
+				sub.Source = imp.Source
+			}
+			ok, err := saveSubmission(ctx, tx, sub)
+			if err != nil {
+				return fmt.Errorf("import %s team %s: %w", sub.MatchKey, sub.TeamNumber, err)
+			}
+			if ok {
+				n.Submissions++
+			}
+		}
+
+		for _, a := range imp.Analyses {
+			added, err := insertIgnore(ctx, tx, `
+				INSERT INTO analysis_cache (event_key, team_number, analysis, notes_hash, created_at)
+				VALUES (?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP))
+				ON CONFLICT(event_key, team_number) DO NOTHING`,
+				imp.EventKey, a.TeamNumber, a.Text, a.NotesHash, sqlTime(a.CreatedAt))
+			if err != nil {
+				return fmt.Errorf("import analysis for team %s: %w", a.TeamNumber, err)
+			}
+			n.Analyses += added
+		}
+
+		for _, p := range imp.MatchPlans {
+			added, err := insertIgnore(ctx, tx, `
+				INSERT INTO match_plan_cache (event_key, team_number, match_key, strategy, notes_hash, created_at)
+				VALUES (?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP))
+				ON CONFLICT(event_key, team_number, match_key) DO NOTHING`,
+				imp.EventKey, p.TeamNumber, p.MatchKey, p.Text, p.NotesHash, sqlTime(p.CreatedAt))
+			if err != nil {
+				return fmt.Errorf("import match plan for %s team %s: %w", p.MatchKey, p.TeamNumber, err)
+			}
+			n.MatchPlans += added
+		}
+
+		if len(imp.PickLists) == 0 {
+			return nil
+		}
+		var existing int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM pick_lists WHERE event_key = ?`, imp.EventKey).Scan(&existing); err != nil {
+			return fmt.Errorf("count pick lists: %w", err)
+		}
+		if existing > 0 {
+			n.PickListsSkipped = true
+			return nil
+		}
+		for _, pl := range imp.PickLists {
+			source := pl.Source
+			if source == "" {
+				source = imp.Source
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO pick_lists (event_key, revision, entries, author, source, created_at)
+				VALUES (?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP))`,
+				imp.EventKey, pl.Revision, pl.Entries, pl.Author, NullString(source), sqlTime(pl.CreatedAt)); err != nil {
+				return fmt.Errorf("import pick list revision %d: %w", pl.Revision, err)
+			}
+			n.PickLists++
+		}
+		return nil
+	})
+	if err != nil {
+		return ImportCounts{}, err
+	}
+	return n, nil
+}
+
+// insertIgnore runs an INSERT … DO NOTHING and reports whether it added a row.
+func insertIgnore(ctx context.Context, tx *sql.Tx, query string, args ...any) (int, error) {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
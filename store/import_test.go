@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestImportRoundTrip saves submissions on one instance, imports what
+// ForEvent lists into another, and expects the same rows back with the
+// partner team as their source.
+func TestImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	local, localDB := newTestStore(t)
+	partner, _ := newTestStore(t)
+
+	if _, err := localDB.Exec(`INSERT INTO scouters (id, event_key, name) VALUES (1, '2026aaa', 'Ada')`); err != nil {
+		t.Fatal(err)
+	}
+	three, climb := 3, "deep"
+	created := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	subs := []Submission{
+		{
+			EventKey: "2026aaa", MatchKey: "2026aaa_qm1", CompLevel: "qm", SetNumber: 1, MatchNumber: 1,
+			ScouterID: 1, ScouterName: "Ada", TeamNumber: "254", Notes: "fast cycles", UUID: "u1",
+			SchemaSeason: 2026, SchemaVersion: 2, CreatedAt: created,
+			Metrics: []Metric{{Key: "auto_pieces", IntValue: &three}, {Key: "climb", TextValue: &climb}},
+		},
+		{
+			EventKey: "2026aaa", MatchKey: "2026aaa_sf2m1", CompLevel: "sf", SetNumber: 2, MatchNumber: 1,
+			ScouterID: 4, TeamNumber: "1678", Notes: "defended", AIGenerated: true, UUID: "u2", CreatedAt: created,
+		},
+	}
+	if n, err := local.Submissions.Save(ctx, subs); err != nil || n != 2 {
+		t.Fatalf("Save = %d, %v; want 2 new", n, err)
+	}
+	exported, err := local.Submissions.ForEvent(ctx, "2026aaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imp := EventImport{
+		EventKey:  "2026aaa",
+		Source:    "6238",
+		Analyses:  []Analysis{{TeamNumber: "254", CachedText: CachedText{Text: "{}", NotesHash: "h"}}},
+		PickLists: []PickList{{Revision: 3, Entries: `[{"team":"254"}]`, Author: "Ada"}},
+	}
+	for _, r := range exported {
+		imp.Submissions = append(imp.Submissions, Submission{
+			MatchKey: r.MatchKey, CompLevel: r.CompLevel, SetNumber: r.SetNumber, MatchNumber: r.MatchNumber,
+			ScouterID: r.ScouterID, ScouterName: r.ScouterName, TeamNumber: r.TeamNumber, Notes: r.Notes,
+			AIGenerated: r.AIGenerated, UUID: r.UUID, SchemaSeason: r.SchemaSeason, SchemaVersion: r.SchemaVersion,
+			Metrics: r.Metrics, CreatedAt: r.CreatedAt,
+		})
+	}
+
+	n, err := partner.ImportEvent(ctx, imp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ImportCounts{Submissions: 2, Analyses: 1, PickLists: 1}); n != want {
+		t.Fatalf("first import counts = %+v, want %+v", n, want)
+	}
+
+	imported, err := partner.Submissions.ForEvent(ctx, "2026aaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != len(exported) {
+		t.Fatalf("imported %d rows, want %d", len(imported), len(exported))
+	}
+	for i, got := range imported {
+		want := exported[i]
+		if got.UUID != want.UUID || got.MatchKey != want.MatchKey || got.CompLevel != want.CompLevel ||
+			got.SetNumber != want.SetNumber || got.MatchNumber != want.MatchNumber || got.TeamNumber != want.TeamNumber ||
+			got.Notes != want.Notes || got.AIGenerated != want.AIGenerated || got.ScouterName != want.ScouterName ||
+			got.SchemaSeason != want.SchemaSeason || got.SchemaVersion != want.SchemaVersion || !got.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("row %d:\n got %+v\nwant %+v", i, got, want)
+		}
+		if got.Source != "6238" || got.ScouterID != 0 {
+			t.Errorf("row %d: source %q, scouter %d; want 6238 and no local scouter", i, got.Source, got.ScouterID)
+		}
+		if len(got.Metrics) != len(want.Metrics) {
+			t.Errorf("row %d: %d metrics, want %d", i, len(got.Metrics), len(want.Metrics))
+		}
+	}
+	if m := imported[0].Metrics; len(m) == 2 {
+		byKey := map[string]Metric{m[0].Key: m[0], m[1].Key: m[1]}
+		if v := byKey["auto_pieces"].IntValue; v == nil || *v != 3 {
+			t.Errorf("auto_pieces = %v, want 3", v)
+		}
+		if v := byKey["climb"].TextValue; v == nil || *v != "deep" {
+			t.Errorf("climb = %v, want deep", v)
+		}
+	}
+	if exported[0].ScouterName != "Ada" || exported[1].ScouterName != "" {
+		t.Errorf("exported scouters %q, %q; want Ada and an anonymous seat", exported[0].ScouterName, exported[1].ScouterName)
+	}
+
+	lists, err := partner.PickLists.ForEvent(ctx, "2026aaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 1 || lists[0].Source != "6238" || lists[0].Revision != 3 {
+		t.Errorf("pick lists = %+v, want revision 3 from 6238", lists)
+	}
+
+	n, err = partner.ImportEvent(ctx, imp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ImportCounts{PickListsSkipped: true}); n != want {
+		t.Errorf("second import counts = %+v, want %+v", n, want)
+	}
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Job statuses. A job is queued until a worker claims it, then done or
+// failed, or queued again to retry.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Jobs is jobs: background work queued for the workers in the main package.
+type Jobs struct {
+	db *sql.DB
+}
+
+// Job is one queued, running or finished job. Params and Result are JSON;
+// Result is empty until the job is done.
+type Job struct {
+	ID          int64
+	Kind        string
+	Params      string
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	Result      string
+	Error       string
+}
+
+// Enqueue queues a job and returns its id, or the id of the job of the same
+// kind and dedupeKey already queued or running.
+func (j *Jobs) Enqueue(ctx context.Context, kind, dedupeKey, params string, maxAttempts int) (int64, error) {
+	_, err := j.db.ExecContext(ctx, `
+		INSERT INTO jobs (kind, dedupe_key, params, status, max_attempts, run_after)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING`,
+		kind, dedupeKey, params, JobQueued, maxAttempts, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("enqueue %s: %w", kind, err)
+	}
+
+	var id int64
+	err = j.db.QueryRowContext(ctx, `
+		SELECT id FROM jobs
+		WHERE kind = ? AND dedupe_key = ? AND status IN (?, ?)
+		ORDER BY id DESC LIMIT 1`,
+		kind, dedupeKey, JobQueued, JobRunning).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue %s: %w", kind, err)
+	}
+	return id, nil
+}
+
+// Get returns ErrNotFound if there's no job id.
+func (j *Jobs) Get(ctx context.Context, id int64) (Job, error) {
+	var job Job
+	err := j.db.QueryRowContext(ctx, `
+		SELECT id, kind, params, status, attempts, max_attempts, COALESCE(result, ''), COALESCE(error, '')
+		FROM jobs WHERE id = ?`, id).
+		Scan(&job.ID, &job.Kind, &job.Params, &job.Status, &job.Attempts, &job.MaxAttempts, &job.Result, &job.Error)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Job{}, ErrNotFound
+	}
+	if err != nil {
+		return Job{}, fmt.Errorf("job %d: %w", id, err)
+	}
+	return job, nil
+}
+
+// QueuedAhead counts the queued jobs in front of job id.
+func (j *Jobs) QueuedAhead(ctx context.Context, id int64) (int, error) {
+	var n int
+	if err := j.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM jobs WHERE status = ? AND id < ?`, JobQueued, id).Scan(&n); err != nil {
+		return 0, fmt.Errorf("jobs ahead of %d: %w", id, err)
+	}
+	return n, nil
+}
+
+// Claim marks the oldest job due to run as running and returns it, or
+// ErrNotFound if none is due.
+func (j *Jobs) Claim(ctx context.Context) (Job, error) {
+	job := Job{Status: JobRunning}
+	err := j.db.QueryRowContext(ctx, `
+		UPDATE jobs SET status = ?, attempts = attempts + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM jobs WHERE status = ? AND run_after <= ?
+			ORDER BY id LIMIT 1)
+		RETURNING id, kind, params, attempts, max_attempts`,
+		JobRunning, JobQueued, time.Now().UTC()).
+		Scan(&job.ID, &job.Kind, &job.Params, &job.Attempts, &job.MaxAttempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Job{}, ErrNotFound
+	}
+	if err != nil {
+		return Job{}, fmt.Errorf("claim job: %w", err)
+	}
+	return job, nil
+}
+
+// Finish stores a job's result and marks it done.
+func (j *Jobs) Finish(ctx context.Context, id int64, result string) error {
+	if _, err := j.db.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, result = ?, error = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		JobDone, result, id); err != nil {
+		return fmt.Errorf("finish job %d: %w", id, err)
+	}
+	return nil
+}
+
+// Retry queues a failed attempt to run again after runAfter.
+func (j *Jobs) Retry(ctx context.Context, id int64, runErr string, runAfter time.Time) error {
+	if _, err := j.db.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, error = ?, run_after = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		JobQueued, runErr, runAfter.UTC(), id); err != nil {
+		return fmt.Errorf("requeue job %d: %w", id, err)
+	}
+	return nil
+}
+
+// Fail marks a job failed for good.
+func (j *Jobs) Fail(ctx context.Context, id int64, runErr string) error {
+	if _, err := j.db.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, error = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		JobFailed, runErr, id); err != nil {
+		return fmt.Errorf("fail job %d: %w", id, err)
+	}
+	return nil
+}
+
+// RequeueRunning puts jobs a restart interrupted back in the queue.
+func (j *Jobs) RequeueRunning(ctx context.Context) error {
+	if _, err := j.db.ExecContext(ctx, `UPDATE jobs SET status = ? WHERE status = ?`, JobQueued, JobRunning); err != nil {
+		return fmt.Errorf("requeue interrupted jobs: %w", err)
+	}
+	return nil
+}
+
+// PruneFinished deletes jobs that finished more than a week ago.
+func (j *Jobs) PruneFinished(ctx context.Context) error {
+	if _, err := j.db.ExecContext(ctx, `
+		DELETE FROM jobs WHERE status IN (?, ?) AND updated_at < datetime('now', '-7 days')`,
+		JobDone, JobFailed); err != nil {
+		return fmt.Errorf("prune finished jobs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LLMFailures is llm_failures: generations that never passed validation,
+// kept for the admin panel.
+type LLMFailures struct {
+	db *sql.DB
+}
+
+// LLMFailure is one failed generation. Response is the model's last
+// output, if there was one.
+type LLMFailure struct {
+	Task       string
+	EventKey   string
+	TeamNumber string
+	Attempts   int
+	Error      string
+	Response   string
+	CreatedAt  time.Time
+}
+
+// Record stores f. CreatedAt is ignored; it's now.
+func (l *LLMFailures) Record(ctx context.Context, f LLMFailure) error {
+	if _, err := l.db.ExecContext(ctx, `
+		INSERT INTO llm_failures (task, event_key, team_number, attempts, error, response)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		f.Task, NullString(f.EventKey), NullString(f.TeamNumber), f.Attempts, f.Error, NullString(f.Response)); err != nil {
+		return fmt.Errorf("record %s failure: %w", f.Task, err)
+	}
+	return nil
+}
+
+// Recent lists up to limit failures, newest first.
+func (l *LLMFailures) Recent(ctx context.Context, limit int) ([]LLMFailure, error) {
+	rows, err := l.db.QueryContext(ctx, `
+		SELECT task, COALESCE(event_key, ''), COALESCE(team_number, ''), attempts, error,
+			COALESCE(response, ''), created_at
+		FROM llm_failures ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("llm failures: %w", err)
+	}
+	defer rows.Close()
+
+	var out []LLMFailure
+	for rows.Next() {
+		var f LLMFailure
+		var createdAt sql.NullTime
+		if err := rows.Scan(&f.Task, &f.EventKey, &f.TeamNumber, &f.Attempts, &f.Error, &f.Response, &createdAt); err != nil {
+			return nil, fmt.Errorf("llm failures: %w", err)
+		}
+		f.CreatedAt = createdAt.Time
+		out = append(out, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("llm failures: %w", err)
+	}
+	return out, nil
+}
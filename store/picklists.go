@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PickLists is pick_lists: every saved revision of each event's pick list.
+type PickLists struct {
+	db *sql.DB
+}
+
+// PickList is one revision. Entries is the stored JSON; Source is the
+// partner team it was imported from, if any.
+type PickList struct {
+	Revision  int
+	Entries   string
+	Author    string
+	Source    string
+	CreatedAt time.Time
+}
+
+// ForEvent lists an event's pick list revisions, oldest first.
+func (p *PickLists) ForEvent(ctx context.Context, eventKey string) ([]PickList, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT revision, entries, author, COALESCE(source, ''), created_at FROM pick_lists
+		WHERE event_key = ?
+		ORDER BY revision`, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("pick lists for %s: %w", eventKey, err)
+	}
+	defer rows.Close()
+
+	var out []PickList
+	for rows.Next() {
+		var pl PickList
+		var createdAt sql.NullTime
+		if err := rows.Scan(&pl.Revision, &pl.Entries, &pl.Author, &pl.Source, &createdAt); err != nil {
+			return nil, fmt.Errorf("pick lists for %s: %w", eventKey, err)
+		}
+		pl.CreatedAt = createdAt.Time
+		out = append(out, pl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pick lists for %s: %w", eventKey, err)
+	}
+	return out, nil
+}
+
+// ErrConflict is returned by writes based on a version someone else has
+// since replaced.
+var ErrConflict = errors.New("changed by someone else")
+
+// Latest returns the event's newest revision, or ErrNotFound if it has no
+// pick list yet.
+func (p *PickLists) Latest(ctx context.Context, eventKey string) (PickList, error) {
+	return p.get(ctx, eventKey, `
+		SELECT revision, entries, author, COALESCE(source, ''), created_at FROM pick_lists
+		WHERE event_key = ? ORDER BY revision DESC LIMIT 1`, eventKey)
+}
+
+// Get returns one revision, or ErrNotFound.
+func (p *PickLists) Get(ctx context.Context, eventKey string, revision int) (PickList, error) {
+	return p.get(ctx, eventKey, `
+		SELECT revision, entries, author, COALESCE(source, ''), created_at FROM pick_lists
+		WHERE event_key = ? AND revision = ?`, eventKey, revision)
+}
+
+func (p *PickLists) get(ctx context.Context, eventKey, query string, args ...any) (PickList, error) {
+	var pl PickList
+	var createdAt sql.NullTime
+	err := p.db.QueryRowContext(ctx, query, args...).Scan(&pl.Revision, &pl.Entries, &pl.Author, &pl.Source, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PickList{}, ErrNotFound
+	}
+	if err != nil {
+		return PickList{}, fmt.Errorf("pick list for %s: %w", eventKey, err)
+	}
+	pl.CreatedAt = createdAt.Time
+	return pl, nil
+}
+
+// LatestRevision is 0 if the event has no pick list yet.
+func (p *PickLists) LatestRevision(ctx context.Context, eventKey string) (int, error) {
+	var revision int
+	err := p.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(revision), 0) FROM pick_lists WHERE event_key = ?`, eventKey).Scan(&revision)
+	if err != nil {
+		return 0, fmt.Errorf("pick list revision for %s: %w", eventKey, err)
+	}
+	return revision, nil
+}
+
+// Save stores entries as the next revision and returns its number.
+// baseRevision must be the latest revision the caller saw; a stale base
+// returns ErrConflict.
+func (p *PickLists) Save(ctx context.Context, eventKey string, baseRevision int, entries, author string) (int, error) {
+	next := 0
+	err := inTx(ctx, p.db, func(tx *sql.Tx) error {
+		var latest int
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(revision), 0) FROM pick_lists WHERE event_key = ?`, eventKey).Scan(&latest); err != nil {
+			return fmt.Errorf("pick list revision for %s: %w", eventKey, err)
+		}
+		if latest != baseRevision {
+			return ErrConflict
+		}
+		next = latest + 1
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO pick_lists (event_key, revision, entries, author)
+			VALUES (?, ?, ?, ?)`,
+			eventKey, next, entries, author); err != nil {
+			return fmt.Errorf("save pick list for %s: %w", eventKey, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+// History lists up to limit of the event's revisions, newest first, without
+// their entries.
+func (p *PickLists) History(ctx context.Context, eventKey string, limit int) ([]PickList, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT revision, author, COALESCE(source, ''), created_at FROM pick_lists
+		WHERE event_key = ? ORDER BY revision DESC LIMIT ?`, eventKey, limit)
+	if err != nil {
+		return nil, fmt.Errorf("pick list history for %s: %w", eventKey, err)
+	}
+	defer rows.Close()
+
+	var out []PickList
+	for rows.Next() {
+		var pl PickList
+		var createdAt sql.NullTime
+		if err := rows.Scan(&pl.Revision, &pl.Author, &pl.Source, &createdAt); err != nil {
+			return nil, fmt.Errorf("pick list history for %s: %w", eventKey, err)
+		}
+		pl.CreatedAt = createdAt.Time
+		out = append(out, pl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pick list history for %s: %w", eventKey, err)
+	}
+	return out, nil
+}
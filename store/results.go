@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MatchResults is match_results: the official score of each played match,
+// as TBA reported it.
+type MatchResults struct {
+	db *sql.DB
+}
+
+// MatchResult is one played match. Teams are numbers without the frc
+// prefix; the breakdowns are TBA's per-game score_breakdown for each
+// alliance, nil if TBA didn't send one.
+type MatchResult struct {
+	EventKey        string
+	MatchKey        string
+	CompLevel       string
+	SetNumber       int
+	MatchNumber     int
+	RedTeams        []string
+	BlueTeams       []string
+	RedScore        int
+	BlueScore       int
+	WinningAlliance string // "red", "blue", or "" for a tie
+	RedBreakdown    json.RawMessage
+	BlueBreakdown   json.RawMessage
+	PlayedAt        time.Time
+	UpdatedAt       time.Time
+}
+
+// Alliance returns "red" or "blue" for the team's side, or "" if it didn't
+// play in the match.
+func (r MatchResult) Alliance(teamNumber string) string {
+	for _, t := range r.RedTeams {
+		if t == teamNumber {
+			return "red"
+		}
+	}
+	for _, t := range r.BlueTeams {
+		if t == teamNumber {
+			return "blue"
+		}
+	}
+	return ""
+}
+
+// Upsert stores results, replacing earlier scores for the same matches.
+// Rows that haven't changed are left alone, so resyncing a whole event is
+// cheap.
+func (s *MatchResults) Upsert(ctx context.Context, results []MatchResult) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO match_results (event_key, match_key, comp_level, set_number, match_num,
+				red_teams, blue_teams, red_score, blue_score, winning_alliance, red_breakdown, blue_breakdown, played_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(match_key) DO UPDATE SET
+				red_teams = excluded.red_teams,
+				blue_teams = excluded.blue_teams,
+				red_score = excluded.red_score,
+				blue_score = excluded.blue_score,
+				winning_alliance = excluded.winning_alliance,
+				red_breakdown = excluded.red_breakdown,
+				blue_breakdown = excluded.blue_breakdown,
+				played_at = excluded.played_at,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE red_teams IS NOT excluded.red_teams OR blue_teams IS NOT excluded.blue_teams
+				OR red_score IS NOT excluded.red_score OR blue_score IS NOT excluded.blue_score
+				OR winning_alliance IS NOT excluded.winning_alliance
+				OR red_breakdown IS NOT excluded.red_breakdown OR blue_breakdown IS NOT excluded.blue_breakdown
+				OR played_at IS NOT excluded.played_at`)
+		if err != nil {
+			return fmt.Errorf("store match results: %w", err)
+		}
+		defer stmt.Close()
+		for _, r := range results {
+			if _, err := stmt.ExecContext(ctx, r.EventKey, r.MatchKey, r.CompLevel, r.SetNumber, r.MatchNumber,
+				strings.Join(r.RedTeams, ","), strings.Join(r.BlueTeams, ","), r.RedScore, r.BlueScore,
+				r.WinningAlliance, NullString(string(r.RedBreakdown)), NullString(string(r.BlueBreakdown)),
+				sqlTime(r.PlayedAt)); err != nil {
+				return fmt.Errorf("store result for %s: %w", r.MatchKey, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Get returns ErrNotFound if the match has no result yet.
+func (s *MatchResults) Get(ctx context.Context, matchKey string) (MatchResult, error) {
+	out, err := s.query(ctx, `match_key = ?`, matchKey)
+	if err != nil {
+		return MatchResult{}, fmt.Errorf("result for %s: %w", matchKey, err)
+	}
+	if len(out) == 0 {
+		return MatchResult{}, ErrNotFound
+	}
+	return out[0], nil
+}
+
+// ForEvent lists an event's results in play order.
+func (s *MatchResults) ForEvent(ctx context.Context, eventKey string) ([]MatchResult, error) {
+	out, err := s.query(ctx, `event_key = ? ORDER BY `+matchOrder, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("results for %s: %w", eventKey, err)
+	}
+	return out, nil
+}
+
+// ForTeam lists the results of a team's matches at an event in play order.
+func (s *MatchResults) ForTeam(ctx context.Context, eventKey, teamNumber string) ([]MatchResult, error) {
+	all, err := s.ForEvent(ctx, eventKey)
+	if err != nil {
+		return nil, err
+	}
+	var out []MatchResult
+	for _, r := range all {
+		if r.Alliance(teamNumber) != "" {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (s *MatchResults) query(ctx context.Context, where string, args ...any) ([]MatchResult, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT event_key, match_key, comp_level, set_number, match_num, red_teams, blue_teams,
+			red_score, blue_score, winning_alliance, red_breakdown, blue_breakdown, played_at, updated_at
+		FROM match_results
+		WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MatchResult
+	for rows.Next() {
+		var r MatchResult
+		var red, blue string
+		var redBreakdown, blueBreakdown sql.NullString
+		var playedAt, updatedAt sql.NullTime
+		if err := rows.Scan(&r.EventKey, &r.MatchKey, &r.CompLevel, &r.SetNumber, &r.MatchNumber, &red, &blue,
+			&r.RedScore, &r.BlueScore, &r.WinningAlliance, &redBreakdown, &blueBreakdown, &playedAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		r.RedTeams, r.BlueTeams = splitTeams(red), splitTeams(blue)
+		if redBreakdown.Valid {
+			r.RedBreakdown = json.RawMessage(redBreakdown.String)
+		}
+		if blueBreakdown.Valid {
+			r.BlueBreakdown = json.RawMessage(blueBreakdown.String)
+		}
+		r.PlayedAt, r.UpdatedAt = playedAt.Time, updatedAt.Time
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func splitTeams(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
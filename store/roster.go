@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Roster is scouters and scout_assignments: who scouts an event and which
+// robot each of them watches in which match.
+type Roster struct {
+	db *sql.DB
+}
+
+// Scouter is a name on an event's roster. Breaks lists the qual matches
+// they're away for, e.g. "12-18, 40".
+type Scouter struct {
+	ID     int
+	Name   string
+	Breaks string
+}
+
+// Assignment is one scouter's robot in one match.
+type Assignment struct {
+	MatchKey   string
+	TeamNumber string
+	ScouterID  int
+}
+
+// Active lists the event's scouters who haven't been removed, by name.
+func (r *Roster) Active(ctx context.Context, eventKey string) ([]Scouter, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, breaks FROM scouters
+		WHERE event_key = ? AND active = 1
+		ORDER BY name COLLATE NOCASE`, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("roster for %s: %w", eventKey, err)
+	}
+	defer rows.Close()
+
+	var out []Scouter
+	for rows.Next() {
+		var s Scouter
+		if err := rows.Scan(&s.ID, &s.Name, &s.Breaks); err != nil {
+			return nil, fmt.Errorf("roster for %s: %w", eventKey, err)
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("roster for %s: %w", eventKey, err)
+	}
+	return out, nil
+}
+
+// Scouter returns ErrNotFound if id isn't on the event's roster, removed
+// scouters included.
+func (r *Roster) Scouter(ctx context.Context, eventKey string, id int) (Scouter, error) {
+	var s Scouter
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, breaks FROM scouters WHERE event_key = ? AND id = ?`,
+		eventKey, id).Scan(&s.ID, &s.Name, &s.Breaks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Scouter{}, ErrNotFound
+	}
+	if err != nil {
+		return Scouter{}, fmt.Errorf("scouter %d at %s: %w", id, eventKey, err)
+	}
+	return s, nil
+}
+
+// Names maps every scouter ever on the event's roster, removed ones
+// included, to their name.
+func (r *Roster) Names(ctx context.Context, eventKey string) (map[int]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name FROM scouters WHERE event_key = ?`, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("scouter names for %s: %w", eventKey, err)
+	}
+	defer rows.Close()
+
+	names := map[int]string{}
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("scouter names for %s: %w", eventKey, err)
+		}
+		names[id] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scouter names for %s: %w", eventKey, err)
+	}
+	return names, nil
+}
+
+// Add puts a scouter on the roster, or updates the breaks of one already on
+// it and brings them back if they were removed.
+func (r *Roster) Add(ctx context.Context, eventKey, name, breaks string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO scouters (event_key, name, breaks) VALUES (?, ?, ?)
+		ON CONFLICT(event_key, name) DO UPDATE SET breaks = excluded.breaks, active = 1`,
+		eventKey, name, breaks)
+	if err != nil {
+		return fmt.Errorf("add scouter %s at %s: %w", name, eventKey, err)
+	}
+	return nil
+}
+
+// Remove deactivates a scouter and drops their assignments for matches
+// nobody has scouted yet. The scouter row stays so old submissions and
+// assignments still have a name.
+func (r *Roster) Remove(ctx context.Context, eventKey string, id int) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `UPDATE scouters SET active = 0 WHERE event_key = ? AND id = ?`, eventKey, id); err != nil {
+			return fmt.Errorf("remove scouter %d at %s: %w", id, eventKey, err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM scout_assignments
+			WHERE event_key = ? AND scouter_id = ?
+			AND match_key NOT IN (SELECT match_key FROM scout_submissions WHERE event_key = ? AND match_key IS NOT NULL)`,
+			eventKey, id, eventKey); err != nil {
+			return fmt.Errorf("drop assignments of scouter %d at %s: %w", id, eventKey, err)
+		}
+		return nil
+	})
+}
+
+// Assignments lists every assignment at the event.
+func (r *Roster) Assignments(ctx context.Context, eventKey string) ([]Assignment, error) {
+	return r.assignments(ctx, eventKey, `
+		SELECT match_key, team_number, scouter_id FROM scout_assignments WHERE event_key = ?`, eventKey)
+}
+
+// AssignmentsFor lists one scouter's assignments at the event.
+func (r *Roster) AssignmentsFor(ctx context.Context, eventKey string, scouterID int) ([]Assignment, error) {
+	return r.assignments(ctx, eventKey, `
+		SELECT match_key, team_number, scouter_id FROM scout_assignments
+		WHERE event_key = ? AND scouter_id = ?`, eventKey, scouterID)
+}
+
+func (r *Roster) assignments(ctx context.Context, eventKey, query string, args ...any) ([]Assignment, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("assignments for %s: %w", eventKey, err)
+	}
+	defer rows.Close()
+
+	var out []Assignment
+	for rows.Next() {
+		var a Assignment
+		if err := rows.Scan(&a.MatchKey, &a.TeamNumber, &a.ScouterID); err != nil {
+			return nil, fmt.Errorf("assignments for %s: %w", eventKey, err)
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("assignments for %s: %w", eventKey, err)
+	}
+	return out, nil
+}
+
+// ReplaceOpenAssignments swaps every assignment for a match nobody has
+// scouted yet for fresh. Assignments for scouted matches are history and
+// are kept.
+func (r *Roster) ReplaceOpenAssignments(ctx context.Context, eventKey string, fresh []Assignment) error {
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM scout_assignments
+			WHERE event_key = ?
+			AND match_key NOT IN (SELECT match_key FROM scout_submissions WHERE event_key = ? AND match_key IS NOT NULL)`,
+			eventKey, eventKey); err != nil {
+			return fmt.Errorf("clear open assignments for %s: %w", eventKey, err)
+		}
+		for _, a := range fresh {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO scout_assignments (event_key, match_key, team_number, scouter_id)
+				VALUES (?, ?, ?, ?)`,
+				eventKey, a.MatchKey, a.TeamNumber, a.ScouterID); err != nil {
+				return fmt.Errorf("assign %s team %s: %w", a.MatchKey, a.TeamNumber, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,429 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// Migration is one numbered schema change; Migrations lists them.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+}
+
+func ensureMigrationsTable(conn *sql.DB) error {
+	_, err := conn.Exec(`
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+      version INTEGER PRIMARY KEY,
+      name TEXT NOT NULL,
+      applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    )`)
+	return err
+}
+
+// AppliedMigrations maps version to applied_at.
+func AppliedMigrations(conn *sql.DB) (map[int]string, error) {
+	if err := ensureMigrationsTable(conn); err != nil {
+		return nil, err
+	}
+	rows, err := conn.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]string{}
+	for rows.Next() {
+		var v int
+		var at string
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		applied[v] = at
+	}
+	return applied, rows.Err()
+}
+
+// Migrate applies each pending migration to conn in its own transaction.
+// It's the live database except when checking an uploaded backup.
+func Migrate(conn *sql.DB) error {
+	applied, err := AppliedMigrations(conn)
+	if err != nil {
+		return err
+	}
+	latest := Migrations[len(Migrations)-1].Version
+	for v := range applied {
+		if v > latest {
+			return fmt.Errorf("database is at schema version %d but this build only knows up to %d", v, latest)
+		}
+	}
+
+	for _, m := range Migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := applyMigration(conn, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		log.Printf("Applied migration %d: %s", m.Version, m.Name)
+	}
+	return nil
+}
+
+func applyMigration(conn *sql.DB, m Migration) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.Up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func execAll(tx *sql.Tx, stmts ...string) error {
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasColumn(tx *sql.Tx, table, column string) (bool, error) {
+	var n int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n)
+	return n > 0, err
+}
+
+// addColumn adds a column unless the table already has it.
+func addColumn(tx *sql.Tx, table, column, decl string) error {
+	has, err := hasColumn(tx, table, column)
+	if err != nil || has {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, decl))
+	return err
+}
+
+// Migrations builds the schema, in order. Each runs in a transaction with its
+// row in schema_migrations. Never edit or reorder one that has shipped;
+// append a new one.
+var Migrations = []Migration{
+	{Version: 1, Name: "baseline", Up: migrateBaseline},
+	{Version: 2, Name: "query indices", Up: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`CREATE INDEX IF NOT EXISTS idx_scout_submissions_event_team ON scout_submissions(event_key, team_number)`,
+			`CREATE INDEX IF NOT EXISTS idx_scout_submissions_event_match ON scout_submissions(event_key, match_key)`,
+			`CREATE INDEX IF NOT EXISTS idx_scout_assignments_scouter ON scout_assignments(scouter_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_sessions_expires ON sessions(expires_at)`,
+			`CREATE INDEX IF NOT EXISTS idx_match_plan_cache_match ON match_plan_cache(event_key, match_key)`,
+		)
+	}},
+	// Rows imported from a partner team's event bundle: source names the team
+	// they came from and scouter_name keeps who scouted it, since scouter_id
+	// means nothing outside the instance that wrote it. NULL source is local.
+	{Version: 3, Name: "import attribution", Up: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`ALTER TABLE scout_submissions ADD COLUMN source TEXT`,
+			`ALTER TABLE scout_submissions ADD COLUMN scouter_name TEXT`,
+			`ALTER TABLE pick_lists ADD COLUMN source TEXT`,
+		)
+	}},
+	// Official scores from TBA, kept apart from the API cache so they survive
+	// its expiry and are there offline. Teams are comma-separated numbers;
+	// the breakdowns are TBA's score_breakdown JSON for each alliance.
+	{Version: 4, Name: "match results", Up: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`CREATE TABLE match_results (
+				match_key TEXT PRIMARY KEY,
+				event_key TEXT NOT NULL,
+				comp_level TEXT NOT NULL,
+				set_number INTEGER NOT NULL,
+				match_num INTEGER NOT NULL,
+				red_teams TEXT NOT NULL,
+				blue_teams TEXT NOT NULL,
+				red_score INTEGER NOT NULL,
+				blue_score INTEGER NOT NULL,
+				winning_alliance TEXT NOT NULL DEFAULT '',
+				red_breakdown TEXT,
+				blue_breakdown TEXT,
+				played_at DATETIME,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+			`CREATE INDEX idx_match_results_event ON match_results(event_key)`,
+		)
+	}},
+	// Roster IDs and the anonymous seats 1-6 shared scouter_id, so a seat's
+	// submissions showed up under whichever rostered scouter had that ID.
+	// Names are now stored with each submission. Existing local rows get
+	// their scouter's name where the ID can't be a seat or an assignment
+	// shows the rostered scouter took that robot; the rest stay anonymous.
+	// Rostered scouters inside the seat range are then renumbered above
+	// 1000, with the rows credited to them, and new ones start there.
+	{Version: 5, Name: "roster scouter ids", Up: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`UPDATE scout_submissions SET scouter_name = (
+				SELECT name FROM scouters sc WHERE sc.id = scout_submissions.scouter_id AND sc.event_key = scout_submissions.event_key)
+			WHERE source IS NULL AND scouter_name IS NULL AND (scouter_id > 6 OR EXISTS (
+				SELECT 1 FROM scout_assignments a
+				WHERE a.event_key = scout_submissions.event_key AND a.match_key = scout_submissions.match_key
+				AND a.team_number = scout_submissions.team_number AND a.scouter_id = scout_submissions.scouter_id))`,
+			`PRAGMA defer_foreign_keys = ON`,
+			`UPDATE scout_submissions SET scouter_id = scouter_id + 1000
+			WHERE source IS NULL AND scouter_id <= 6 AND scouter_name IS NOT NULL`,
+			`UPDATE scout_assignments SET scouter_id = scouter_id + 1000 WHERE scouter_id <= 6`,
+			`UPDATE scouters SET id = id + 1000 WHERE id <= 6`,
+			`INSERT INTO sqlite_sequence (name, seq)
+			SELECT 'scouters', 1000 WHERE NOT EXISTS (SELECT 1 FROM sqlite_sequence WHERE name = 'scouters')`,
+			`UPDATE sqlite_sequence SET seq = MAX(seq, 1000) WHERE name = 'scouters'`,
+		)
+	}},
+}
+
+// migrateBaseline is the schema as it stood before versioned migrations.
+// Databases from then already have some or all of it, so every step checks
+// before changing anything.
+func migrateBaseline(tx *sql.Tx) error {
+	err := execAll(tx,
+		`
+    CREATE TABLE IF NOT EXISTS scout_submissions (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      event_key TEXT,
+      match_num INTEGER,
+      scouter_id INTEGER,
+      team_number TEXT,
+      notes TEXT,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    )`,
+
+		`
+    CREATE TABLE IF NOT EXISTS analysis_cache (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      event_key TEXT NOT NULL,
+      team_number TEXT NOT NULL,
+      analysis TEXT NOT NULL,
+      notes_hash TEXT NOT NULL,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      UNIQUE(event_key, team_number)
+    )`,
+
+		`
+    CREATE TABLE IF NOT EXISTS match_plan_cache (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      event_key TEXT NOT NULL,
+      team_number TEXT NOT NULL,
+      match_key TEXT NOT NULL,
+      strategy TEXT NOT NULL,
+      notes_hash TEXT NOT NULL,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      UNIQUE(event_key, team_number, match_key)
+    )`,
+
+		// Structured form values, one row per schema field per submission
+		`
+    CREATE TABLE IF NOT EXISTS scout_metrics (
+      submission_id INTEGER NOT NULL REFERENCES scout_submissions(id) ON DELETE CASCADE,
+      schema_season INTEGER NOT NULL,
+      schema_version INTEGER NOT NULL,
+      field_key TEXT NOT NULL,
+      int_value INTEGER,
+      text_value TEXT,
+      PRIMARY KEY(submission_id, field_key)
+    )`,
+
+		// Each save of an event's pick list is a new revision; the highest wins
+		`
+    CREATE TABLE IF NOT EXISTS pick_lists (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      event_key TEXT NOT NULL,
+      revision INTEGER NOT NULL,
+      entries TEXT NOT NULL,
+      author TEXT NOT NULL DEFAULT '',
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      UNIQUE(event_key, revision)
+    )`,
+
+		// Picks and declines recorded live during alliance selection, replayed in seq order
+		`
+    CREATE TABLE IF NOT EXISTS alliance_selection_actions (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      event_key TEXT NOT NULL,
+      seq INTEGER NOT NULL,
+      action TEXT NOT NULL,
+      team_number TEXT NOT NULL,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      UNIQUE(event_key, seq)
+    )`,
+
+		// Named scouters per event. breaks lists qual matches they're away for,
+		// e.g. "12-18, 40". Removed scouters are kept so old submissions resolve.
+		`
+    CREATE TABLE IF NOT EXISTS scouters (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      event_key TEXT NOT NULL,
+      name TEXT NOT NULL,
+      breaks TEXT NOT NULL DEFAULT '',
+      active INTEGER NOT NULL DEFAULT 1,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      UNIQUE(event_key, name)
+    )`,
+
+		// One scouter per robot per match, written by the assignment generator
+		`
+    CREATE TABLE IF NOT EXISTS scout_assignments (
+      event_key TEXT NOT NULL,
+      match_key TEXT NOT NULL,
+      team_number TEXT NOT NULL,
+      scouter_id INTEGER NOT NULL REFERENCES scouters(id) ON DELETE CASCADE,
+      PRIMARY KEY(event_key, match_key, team_number)
+    )`,
+
+		// Accounts; role is scouter, strategist or admin
+		`
+    CREATE TABLE IF NOT EXISTS users (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      username TEXT NOT NULL UNIQUE COLLATE NOCASE,
+      role TEXT NOT NULL,
+      password_hash TEXT NOT NULL,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    )`,
+
+		// Login sessions, keyed by the SHA-256 of the cookie token
+		`
+    CREATE TABLE IF NOT EXISTS sessions (
+      token_hash TEXT PRIMARY KEY,
+      user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      expires_at DATETIME NOT NULL,
+      reauth_at DATETIME NOT NULL
+    )`,
+
+		// LLM responses that never passed validation, kept for the admin panel
+		`
+    CREATE TABLE IF NOT EXISTS llm_failures (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      task TEXT NOT NULL,
+      event_key TEXT,
+      team_number TEXT,
+      attempts INTEGER NOT NULL,
+      error TEXT NOT NULL,
+      response TEXT,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    )`,
+
+		// Background jobs (see jobs.go). Only one queued or running job per
+		// kind and dedupe key, so repeat clicks attach to the job in flight.
+		`
+    CREATE TABLE IF NOT EXISTS jobs (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      kind TEXT NOT NULL,
+      dedupe_key TEXT NOT NULL,
+      params TEXT NOT NULL,
+      status TEXT NOT NULL,
+      attempts INTEGER NOT NULL DEFAULT 0,
+      max_attempts INTEGER NOT NULL,
+      result TEXT,
+      error TEXT,
+      run_after DATETIME NOT NULL,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    )`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_active ON jobs(kind, dedupe_key) WHERE status IN ('queued', 'running')`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status, run_after)`,
+
+		// Events without TBA coverage and their uploaded schedules (see
+		// customevents.go). Teams are comma-separated TBA team keys.
+		`
+    CREATE TABLE IF NOT EXISTS custom_events (
+      event_key TEXT PRIMARY KEY,
+      name TEXT NOT NULL,
+      start_date TEXT NOT NULL,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    )`,
+		`
+    CREATE TABLE IF NOT EXISTS custom_matches (
+      event_key TEXT NOT NULL,
+      match_key TEXT NOT NULL,
+      comp_level TEXT NOT NULL,
+      set_number INTEGER NOT NULL,
+      match_number INTEGER NOT NULL,
+      red_teams TEXT NOT NULL,
+      blue_teams TEXT NOT NULL,
+      PRIMARY KEY (event_key, match_key)
+    )`,
+
+		// TBA and Statbotics responses (see apicache.go), keyed by request URL.
+		`
+    CREATE TABLE IF NOT EXISTS api_cache (
+      url TEXT PRIMARY KEY,
+      body BLOB NOT NULL,
+      etag TEXT,
+      last_modified TEXT,
+      fetched_at DATETIME NOT NULL,
+      expired INTEGER NOT NULL DEFAULT 0
+    )`,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Columns added to scout_submissions over time: the AI-generated flag;
+	// TBA match keys, so playoff and practice matches don't collide with
+	// quals of the same number (older rows were all quals); and client
+	// submission IDs so offline replays are deduplicated.
+	if err := migrateMatchPlanCacheToMatchKey(tx); err != nil {
+		return err
+	}
+
+	for _, c := range []struct{ name, decl string }{
+		{"ai_generated", "INTEGER DEFAULT 0"},
+		{"match_key", "TEXT"},
+		{"comp_level", "TEXT NOT NULL DEFAULT 'qm'"},
+		{"set_number", "INTEGER NOT NULL DEFAULT 1"},
+		{"submission_uuid", "TEXT"},
+	} {
+		if err := addColumn(tx, "scout_submissions", c.name, c.decl); err != nil {
+			return err
+		}
+	}
+
+	return execAll(tx,
+		`UPDATE scout_submissions SET match_key = event_key || '_qm' || match_num WHERE match_key IS NULL`,
+		// NULLs are distinct in SQLite unique indexes, so legacy rows are unaffected.
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_scout_submissions_uuid ON scout_submissions(submission_uuid, team_number)`,
+	)
+}
+
+// migrateMatchPlanCacheToMatchKey rebuilds match_plan_cache keyed by match_key
+// instead of match_num. SQLite can't change a UNIQUE constraint in place, so
+// the table is copied once; existing plans were all for quals.
+func migrateMatchPlanCacheToMatchKey(tx *sql.Tx) error {
+	if has, err := hasColumn(tx, "match_plan_cache", "match_key"); err != nil || has {
+		return err
+	}
+	return execAll(tx,
+		`CREATE TABLE match_plan_cache_new (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      event_key TEXT NOT NULL,
+      team_number TEXT NOT NULL,
+      match_key TEXT NOT NULL,
+      strategy TEXT NOT NULL,
+      notes_hash TEXT NOT NULL,
+      created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+      UNIQUE(event_key, team_number, match_key)
+    )`,
+		`INSERT INTO match_plan_cache_new (event_key, team_number, match_key, strategy, notes_hash, created_at)
+      SELECT event_key, team_number, event_key || '_qm' || match_num, strategy, notes_hash, created_at FROM match_plan_cache`,
+		`DROP TABLE match_plan_cache`,
+		`ALTER TABLE match_plan_cache_new RENAME TO match_plan_cache`,
+	)
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// AllianceSelection is alliance_selection_actions: the picks and declines
+// recorded live during an event's alliance selection, replayed in seq order.
+type AllianceSelection struct {
+	db *sql.DB
+}
+
+// SelectionAction is one recorded pick or decline.
+type SelectionAction struct {
+	Seq        int
+	Action     string
+	TeamNumber string
+}
+
+// Actions lists the event's recorded actions in the order they were made.
+func (a *AllianceSelection) Actions(ctx context.Context, eventKey string) ([]SelectionAction, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT seq, action, team_number FROM alliance_selection_actions
+		WHERE event_key = ? ORDER BY seq ASC`, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("alliance selection for %s: %w", eventKey, err)
+	}
+	defer rows.Close()
+
+	var out []SelectionAction
+	for rows.Next() {
+		var sa SelectionAction
+		if err := rows.Scan(&sa.Seq, &sa.Action, &sa.TeamNumber); err != nil {
+			return nil, fmt.Errorf("alliance selection for %s: %w", eventKey, err)
+		}
+		out = append(out, sa)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("alliance selection for %s: %w", eventKey, err)
+	}
+	return out, nil
+}
+
+// Append records an action after the one numbered seq, returning
+// ErrConflict if that's no longer the event's last action. seq is 0 for
+// the first action.
+func (a *AllianceSelection) Append(ctx context.Context, eventKey string, seq int, action, teamNumber string) error {
+	// One statement, so no other writer can get between the check and the
+	// insert.
+	res, err := a.db.ExecContext(ctx, `
+		INSERT INTO alliance_selection_actions (event_key, seq, action, team_number)
+		SELECT ?, ? + 1, ?, ?
+		WHERE (SELECT COALESCE(MAX(seq), 0) FROM alliance_selection_actions WHERE event_key = ?) = ?`,
+		eventKey, seq, action, teamNumber, eventKey, seq)
+	if err != nil {
+		return fmt.Errorf("record %s of %s at %s: %w", action, teamNumber, eventKey, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("record %s of %s at %s: %w", action, teamNumber, eventKey, err)
+	}
+	if n == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// Undo drops the event's last action if it's the one numbered seq, and
+// returns ErrConflict if it isn't. With seq 0 there's nothing to undo.
+func (a *AllianceSelection) Undo(ctx context.Context, eventKey string, seq int) error {
+	res, err := a.db.ExecContext(ctx, `
+		DELETE FROM alliance_selection_actions
+		WHERE event_key = ? AND seq = ?
+			AND seq = (SELECT MAX(seq) FROM alliance_selection_actions WHERE event_key = ?)`,
+		eventKey, seq, eventKey)
+	if err != nil {
+		return fmt.Errorf("undo alliance selection at %s: %w", eventKey, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("undo alliance selection at %s: %w", eventKey, err)
+	}
+	if n == 0 && seq > 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// Reset drops every action recorded at the event if the last one is still
+// numbered seq, and returns ErrConflict if it isn't. With seq 0 there's
+// nothing to reset.
+func (a *AllianceSelection) Reset(ctx context.Context, eventKey string, seq int) error {
+	res, err := a.db.ExecContext(ctx, `
+		DELETE FROM alliance_selection_actions
+		WHERE event_key = ?
+			AND (SELECT MAX(seq) FROM alliance_selection_actions WHERE event_key = ?) = ?`,
+		eventKey, eventKey, seq)
+	if err != nil {
+		return fmt.Errorf("reset alliance selection at %s: %w", eventKey, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("reset alliance selection at %s: %w", eventKey, err)
+	}
+	if n == 0 && seq > 0 {
+		return ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Package store is typed access to the database, and the migrations that
+// build it. Every call takes the caller's context, so a dropped request
+// cancels its queries, and every error comes back wrapped with what was
+// being done.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned by lookups that match no row.
+var ErrNotFound = errors.New("not found")
+
+// Store groups the per-table stores over one database.
+type Store struct {
+	db *sql.DB
+
+	Submissions  *Submissions
+	Analysis     *AnalysisCache
+	MatchPlans   *MatchPlanCache
+	PickLists    *PickLists
+	Results      *MatchResults
+	Roster       *Roster
+	Selection    *AllianceSelection
+	CustomEvents *CustomEvents
+	Users        *Users
+	Sessions     *Sessions
+	Jobs         *Jobs
+	LLMFailures  *LLMFailures
+	APICache     *APICache
+}
+
+func New(db *sql.DB) *Store {
+	return &Store{
+		db:           db,
+		Submissions:  &Submissions{db: db},
+		Analysis:     &AnalysisCache{db: db},
+		MatchPlans:   &MatchPlanCache{db: db},
+		PickLists:    &PickLists{db: db},
+		Results:      &MatchResults{db: db},
+		Roster:       &Roster{db: db},
+		Selection:    &AllianceSelection{db: db},
+		CustomEvents: &CustomEvents{db: db},
+		Users:        &Users{db: db},
+		Sessions:     &Sessions{db: db},
+		Jobs:         &Jobs{db: db},
+		LLMFailures:  &LLMFailures{db: db},
+		APICache:     &APICache{db: db},
+	}
+}
+
+// matchOrder sorts rows with comp_level, set_number and match_num columns
+// into play order: practice, quals, then each playoff round.
+const matchOrder = `CASE comp_level WHEN 'pm' THEN 0 WHEN 'qm' THEN 1 WHEN 'ef' THEN 2 WHEN 'qf' THEN 3 WHEN 'sf' THEN 4 WHEN 'f' THEN 5 ELSE 6 END, set_number, match_num`
+
+// eventTables are cleared, in order, when an event's data is deleted.
+// scout_metrics has no event_key, so the Clear methods delete it first,
+// by way of the scout_submissions it hangs off.
+var eventTables = []string{
+	"scout_submissions",
+	"analysis_cache",
+	"match_plan_cache",
+	"pick_lists",
+	"alliance_selection_actions",
+	"scout_assignments",
+	"scouters",
+}
+
+// ClearEvent deletes everything scouted or generated for eventKey.
+func (s *Store) ClearEvent(ctx context.Context, eventKey string) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM scout_metrics WHERE submission_id IN (SELECT id FROM scout_submissions WHERE event_key = ?)`,
+			eventKey); err != nil {
+			return fmt.Errorf("clear %s scout_metrics: %w", eventKey, err)
+		}
+		for _, table := range eventTables {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE event_key = ?`, eventKey); err != nil {
+				return fmt.Errorf("clear %s %s: %w", eventKey, table, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ClearAll deletes everything scouted or generated for every event. Users,
+// custom events and the API cache are kept.
+func (s *Store) ClearAll(ctx context.Context) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		for _, table := range append([]string{"scout_metrics"}, eventTables...) {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table); err != nil {
+				return fmt.Errorf("clear %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
+
+// inTx runs fn in a transaction, committing if it returns nil.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// NullString stores the empty string as NULL.
+func NullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// sqlTime formats t the way CURRENT_TIMESTAMP does, so imported and local
+// created_at values read back the same. The zero time is NULL.
+func sqlTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format("2006-01-02 15:04:05"), Valid: true}
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/glebarez/go-sqlite"
+)
+
+func newTestStore(t *testing.T) (*Store, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return New(db), db
+}
+
+func countRows(t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestInTxRollsBackOnError(t *testing.T) {
+	_, db := newTestStore(t)
+	ctx := context.Background()
+	insert := func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO scouters (event_key, name) VALUES ('2026test', 'Ada')`)
+		return err
+	}
+
+	errBoom := errors.New("boom")
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		if err := insert(tx); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("inTx returned %v, want %v", err, errBoom)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM scouters`); n != 0 {
+		t.Fatalf("%d rows after a failed transaction, want 0", n)
+	}
+
+	if err := inTx(ctx, db, insert); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM scouters`); n != 1 {
+		t.Fatalf("%d rows after a committed transaction, want 1", n)
+	}
+}
+
+func TestClearEvent(t *testing.T) {
+	s, db := newTestStore(t)
+	ctx := context.Background()
+	seven := 7
+
+	for _, event := range []string{"2026aaa", "2026bbb"} {
+		if _, err := s.Submissions.Save(ctx, []Submission{{
+			EventKey: event, MatchKey: event + "_qm1", CompLevel: "qm", SetNumber: 1, MatchNumber: 1,
+			TeamNumber: "254", Notes: "fast", UUID: event + "-1",
+			SchemaSeason: 2026, SchemaVersion: 1, Metrics: []Metric{{Key: "auto_pieces", IntValue: &seven}},
+		}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Analysis.Put(ctx, event, "254", CachedText{Text: "{}", NotesHash: "h"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.MatchPlans.Put(ctx, event, "254", event+"_qm2", CachedText{Text: "plan", NotesHash: "h"}); err != nil {
+			t.Fatal(err)
+		}
+		for _, stmt := range []string{
+			`INSERT INTO pick_lists (event_key, revision, entries) VALUES (?, 1, '[]')`,
+			`INSERT INTO alliance_selection_actions (event_key, seq, action, team_number) VALUES (?, 1, 'pick', '254')`,
+			`INSERT INTO scouters (event_key, name) VALUES (?, 'Ada')`,
+		} {
+			if _, err := db.Exec(stmt, event); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := db.Exec(`
+			INSERT INTO scout_assignments (event_key, match_key, team_number, scouter_id)
+			SELECT event_key, event_key || '_qm2', '254', id FROM scouters WHERE event_key = ?`, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.ClearEvent(ctx, "2026aaa"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range eventTables {
+		if n := countRows(t, db, `SELECT COUNT(*) FROM `+table+` WHERE event_key = '2026aaa'`); n != 0 {
+			t.Errorf("%s: %d rows left for the cleared event", table, n)
+		}
+		if n := countRows(t, db, `SELECT COUNT(*) FROM `+table+` WHERE event_key = '2026bbb'`); n != 1 {
+			t.Errorf("%s: %d rows for the other event, want 1", table, n)
+		}
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM scout_metrics`); n != 1 {
+		t.Errorf("%d metrics left, want only the other event's 1", n)
+	}
+}
+
+func TestSelectionRejectsStaleSeq(t *testing.T) {
+	s, db := newTestStore(t)
+	ctx := context.Background()
+	const event = "2026aaa"
+
+	if err := s.Selection.Append(ctx, event, 0, "pick", "254"); err != nil {
+		t.Fatal(err)
+	}
+	// A second scout recording from the same board
+	if err := s.Selection.Append(ctx, event, 0, "pick", "1678"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("append from a stale board: got %v, want ErrConflict", err)
+	}
+	if err := s.Selection.Append(ctx, event, 1, "decline", "1678"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Selection.Undo(ctx, event, 1); !errors.Is(err, ErrConflict) {
+		t.Fatalf("undo from a stale board: got %v, want ErrConflict", err)
+	}
+	if err := s.Selection.Reset(ctx, event, 1); !errors.Is(err, ErrConflict) {
+		t.Fatalf("reset from a stale board: got %v, want ErrConflict", err)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM alliance_selection_actions`); n != 2 {
+		t.Fatalf("%d actions after stale writes, want 2", n)
+	}
+
+	if err := s.Selection.Undo(ctx, event, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Selection.Reset(ctx, event, 1); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM alliance_selection_actions`); n != 0 {
+		t.Fatalf("%d actions after reset, want 0", n)
+	}
+}
@@ -0,0 +1,362 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Submissions is scout_submissions and the scout_metrics hanging off it.
+type Submissions struct {
+	db *sql.DB
+}
+
+// Submission is one team's row from one scouter's match report.
+type Submission struct {
+	EventKey    string
+	MatchKey    string
+	CompLevel   string
+	SetNumber   int
+	MatchNumber int
+	ScouterID   int
+	TeamNumber  string
+	Notes       string
+	AIGenerated bool
+
+	// UUID is the client's id for the whole report, shared by its team rows.
+	// A row whose (UUID, TeamNumber) is already stored is skipped, so offline
+	// replays are harmless. Empty means no deduplication.
+	UUID string
+
+	// Metrics are the structured form fields, stored against SchemaSeason
+	// and SchemaVersion.
+	SchemaSeason  int
+	SchemaVersion int
+	Metrics       []Metric
+
+	// Source is the partner team an imported row came from, empty for local
+	// rows. ScouterName is who scouted it: the roster name, or the name kept
+	// from an import; it's empty for anonymous seats. CreatedAt is kept from
+	// the original row and defaults to now.
+	Source      string
+	ScouterName string
+	CreatedAt   time.Time
+}
+
+// Metric is one structured field value; exactly one of IntValue and
+// TextValue is set.
+type Metric struct {
+	Key       string
+	IntValue  *int
+	TextValue *string
+}
+
+// Note is a team's notes from one match. Source is the partner team it was
+// imported from, if any.
+type Note struct {
+	EventKey    string
+	MatchKey    string
+	Notes       string
+	Source      string
+	ScouterName string // may be empty
+	AIGenerated bool
+	CreatedAt   time.Time
+}
+
+// Save stores subs in one transaction and returns how many were new rather
+// than replays. Each saved team's cached analysis is dropped so it's
+// regenerated with the new notes.
+func (s *Submissions) Save(ctx context.Context, subs []Submission) (int, error) {
+	saved := 0
+	err := inTx(ctx, s.db, func(tx *sql.Tx) error {
+		saved = 0
+		for _, sub := range subs {
+			ok, err := saveSubmission(ctx, tx, sub)
+			if err != nil {
+				return fmt.Errorf("save %s team %s: %w", sub.MatchKey, sub.TeamNumber, err)
+			}
+			if ok {
+				saved++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return saved, nil
+}
+
+func saveSubmission(ctx context.Context, tx *sql.Tx, sub Submission) (bool, error) {
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO scout_submissions (event_key, match_key, comp_level, set_number, match_num, scouter_id, team_number, notes, ai_generated,
+			submission_uuid, source, scouter_name, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP))
+		ON CONFLICT(submission_uuid, team_number) DO NOTHING`,
+		sub.EventKey, sub.MatchKey, sub.CompLevel, sub.SetNumber, sub.MatchNumber, sub.ScouterID, sub.TeamNumber, sub.Notes, sub.AIGenerated,
+		NullString(sub.UUID), NullString(sub.Source), NullString(sub.ScouterName), sqlTime(sub.CreatedAt))
+	if err != nil {
+		return false, fmt.Errorf("insert submission: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil // already saved by an earlier replay
+	}
+
+	if len(sub.Metrics) > 0 {
+		id, err := res.LastInsertId()
+		if err != nil {
+			return false, err
+		}
+		for _, m := range sub.Metrics {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO scout_metrics (submission_id, schema_season, schema_version, field_key, int_value, text_value)
+				VALUES (?, ?, ?, ?, ?, ?)`,
+				id, sub.SchemaSeason, sub.SchemaVersion, m.Key, m.IntValue, m.TextValue); err != nil {
+				return false, fmt.Errorf("insert metric %s: %w", m.Key, err)
+			}
+		}
+	}
+
+	if err := deleteAnalysis(ctx, tx, sub.EventKey, sub.TeamNumber); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CountWithNotes counts a team's submissions at an event that have notes.
+func (s *Submissions) CountWithNotes(ctx context.Context, eventKey, teamNumber string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM scout_submissions
+		WHERE event_key = ? AND team_number = ? AND TRIM(notes) != ''`,
+		eventKey, teamNumber).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count notes for %s team %s: %w", eventKey, teamNumber, err)
+	}
+	return n, nil
+}
+
+// Teams lists the teams with submissions at an event.
+func (s *Submissions) Teams(ctx context.Context, eventKey string) ([]string, error) {
+	teams, err := queryStrings(ctx, s.db, `
+		SELECT DISTINCT team_number FROM scout_submissions
+		WHERE event_key = ?
+		ORDER BY team_number`, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("list teams for %s: %w", eventKey, err)
+	}
+	return teams, nil
+}
+
+// EventKeys lists the events with submissions.
+func (s *Submissions) EventKeys(ctx context.Context) ([]string, error) {
+	events, err := queryStrings(ctx, s.db, `SELECT DISTINCT event_key FROM scout_submissions ORDER BY event_key`)
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+	return events, nil
+}
+
+// ScoutedMatches is the set of the event's match keys with at least one
+// submission.
+func (s *Submissions) ScoutedMatches(ctx context.Context, eventKey string) (map[string]bool, error) {
+	keys, err := queryStrings(ctx, s.db, `
+		SELECT DISTINCT match_key FROM scout_submissions
+		WHERE event_key = ? AND match_key IS NOT NULL`, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("scouted matches for %s: %w", eventKey, err)
+	}
+	return stringSet(keys), nil
+}
+
+// ScoutedMatchesBy is ScoutedMatches for one scouter's submissions.
+func (s *Submissions) ScoutedMatchesBy(ctx context.Context, eventKey string, scouterID int) (map[string]bool, error) {
+	keys, err := queryStrings(ctx, s.db, `
+		SELECT DISTINCT match_key FROM scout_submissions
+		WHERE event_key = ? AND scouter_id = ? AND match_key IS NOT NULL`, eventKey, scouterID)
+	if err != nil {
+		return nil, fmt.Errorf("matches scouted by %d at %s: %w", scouterID, eventKey, err)
+	}
+	return stringSet(keys), nil
+}
+
+func stringSet(ss []string) map[string]bool {
+	set := make(map[string]bool, len(ss))
+	for _, s := range ss {
+		set[s] = true
+	}
+	return set
+}
+
+// Notes returns a team's notes at an event in play order.
+func (s *Submissions) Notes(ctx context.Context, eventKey, teamNumber string) ([]Note, error) {
+	notes, err := s.queryNotes(ctx, `s.event_key = ? AND s.team_number = ?`, eventKey, teamNumber)
+	if err != nil {
+		return nil, fmt.Errorf("notes for %s team %s: %w", eventKey, teamNumber, err)
+	}
+	return notes, nil
+}
+
+// TeamNotes returns a team's notes from every event, grouped by event in
+// the order they were first scouted, then in play order.
+func (s *Submissions) TeamNotes(ctx context.Context, teamNumber string) ([]Note, error) {
+	notes, err := s.queryNotes(ctx, `s.team_number = ?`, teamNumber)
+	if err != nil {
+		return nil, fmt.Errorf("notes for team %s: %w", teamNumber, err)
+	}
+	return notes, nil
+}
+
+func (s *Submissions) queryNotes(ctx context.Context, where string, args ...any) ([]Note, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.event_key, s.match_key, s.notes, COALESCE(s.source, ''), COALESCE(s.scouter_name, ''),
+			s.ai_generated, s.created_at
+		FROM scout_submissions s
+		WHERE `+where+`
+		ORDER BY (SELECT MIN(f.created_at) FROM scout_submissions f WHERE f.event_key = s.event_key), s.event_key,
+			`+matchOrder+`, s.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []Note
+	for rows.Next() {
+		var n Note
+		var matchKey, text sql.NullString
+		var createdAt sql.NullTime
+		if err := rows.Scan(&n.EventKey, &matchKey, &text, &n.Source, &n.ScouterName, &n.AIGenerated, &createdAt); err != nil {
+			return nil, err
+		}
+		n.MatchKey, n.Notes, n.CreatedAt = matchKey.String, text.String, createdAt.Time
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// NoteTexts is Notes without the match keys.
+func (s *Submissions) NoteTexts(ctx context.Context, eventKey, teamNumber string) ([]string, error) {
+	notes, err := s.Notes(ctx, eventKey, teamNumber)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, len(notes))
+	for i, n := range notes {
+		texts[i] = n.Notes
+	}
+	return texts, nil
+}
+
+func queryStrings(ctx context.Context, db *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// SubmissionRow is a stored submission as listed by ForEvent.
+type SubmissionRow struct {
+	ID            int64
+	UUID          string
+	MatchKey      string
+	CompLevel     string
+	SetNumber     int
+	MatchNumber   int
+	ScouterID     int
+	ScouterName   string // empty for anonymous seats
+	Source        string
+	TeamNumber    string
+	Notes         string
+	AIGenerated   bool
+	CreatedAt     time.Time
+	SchemaSeason  int
+	SchemaVersion int
+	Metrics       []Metric
+}
+
+// ForEvent lists every submission at an event in play order, with metrics.
+func (s *Submissions) ForEvent(ctx context.Context, eventKey string) ([]SubmissionRow, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, COALESCE(s.submission_uuid, ''), s.match_key, s.comp_level, s.set_number, s.match_num, s.scouter_id,
+			COALESCE(s.scouter_name, ''), COALESCE(s.source, ''), s.team_number, COALESCE(s.notes, ''), s.ai_generated, s.created_at
+		FROM scout_submissions s
+		WHERE s.event_key = ?
+		ORDER BY `+matchOrder+`, s.team_number, s.id`, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("submissions for %s: %w", eventKey, err)
+	}
+	defer rows.Close()
+
+	var out []SubmissionRow
+	byID := map[int64]int{}
+	for rows.Next() {
+		var r SubmissionRow
+		var matchKey, compLevel sql.NullString
+		var setNumber, scouterID sql.NullInt64
+		var createdAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.UUID, &matchKey, &compLevel, &setNumber, &r.MatchNumber, &scouterID,
+			&r.ScouterName, &r.Source, &r.TeamNumber, &r.Notes, &r.AIGenerated, &createdAt); err != nil {
+			return nil, fmt.Errorf("submissions for %s: %w", eventKey, err)
+		}
+		r.MatchKey, r.CompLevel = matchKey.String, compLevel.String
+		r.SetNumber, r.ScouterID = int(setNumber.Int64), int(scouterID.Int64)
+		r.CreatedAt = createdAt.Time
+		byID[r.ID] = len(out)
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("submissions for %s: %w", eventKey, err)
+	}
+	rows.Close()
+
+	metrics, err := s.db.QueryContext(ctx, `
+		SELECT m.submission_id, m.schema_season, m.schema_version, m.field_key, m.int_value, m.text_value
+		FROM scout_metrics m
+		JOIN scout_submissions s ON s.id = m.submission_id
+		WHERE s.event_key = ?`, eventKey)
+	if err != nil {
+		return nil, fmt.Errorf("metrics for %s: %w", eventKey, err)
+	}
+	defer metrics.Close()
+	for metrics.Next() {
+		var id int64
+		var season, version int
+		var m Metric
+		var intValue sql.NullInt64
+		var textValue sql.NullString
+		if err := metrics.Scan(&id, &season, &version, &m.Key, &intValue, &textValue); err != nil {
+			return nil, fmt.Errorf("metrics for %s: %w", eventKey, err)
+		}
+		if intValue.Valid {
+			v := int(intValue.Int64)
+			m.IntValue = &v
+		}
+		if textValue.Valid {
+			m.TextValue = &textValue.String
+		}
+		if i, ok := byID[id]; ok {
+			out[i].SchemaSeason, out[i].SchemaVersion = season, version
+			out[i].Metrics = append(out[i].Metrics, m)
+		}
+	}
+	if err := metrics.Err(); err != nil {
+		return nil, fmt.Errorf("metrics for %s: %w", eventKey, err)
+	}
+	return out, nil
+}
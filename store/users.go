@@ -0,0 +1,173 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Users is users: accounts and their password hashes.
+type Users struct {
+	db *sql.DB
+}
+
+// User is one account. Role is scouter, strategist or admin.
+type User struct {
+	ID           int
+	Username     string
+	Role         string
+	PasswordHash string
+}
+
+// ByUsername returns ErrNotFound if there's no such account. Usernames
+// match without regard to case.
+func (u *Users) ByUsername(ctx context.Context, username string) (User, error) {
+	var user User
+	err := u.db.QueryRowContext(ctx, `SELECT id, username, role, password_hash FROM users WHERE username = ?`,
+		username).Scan(&user.ID, &user.Username, &user.Role, &user.PasswordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("user %s: %w", username, err)
+	}
+	return user, nil
+}
+
+// CountWithRole counts the accounts with role.
+func (u *Users) CountWithRole(ctx context.Context, role string) (int, error) {
+	var n int
+	if err := u.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role = ?`, role).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count %s accounts: %w", role, err)
+	}
+	return n, nil
+}
+
+// List returns every account by username, without password hashes.
+func (u *Users) List(ctx context.Context) ([]User, error) {
+	rows, err := u.db.QueryContext(ctx, `SELECT id, username, role FROM users ORDER BY username COLLATE NOCASE`)
+	if err != nil {
+		return nil, fmt.Errorf("users: %w", err)
+	}
+	defer rows.Close()
+
+	var out []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Role); err != nil {
+			return nil, fmt.Errorf("users: %w", err)
+		}
+		out = append(out, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("users: %w", err)
+	}
+	return out, nil
+}
+
+// SetRole changes an existing account's role, returning ErrNotFound if
+// there's no such account.
+func (u *Users) SetRole(ctx context.Context, username, role string) error {
+	res, err := u.db.ExecContext(ctx, `UPDATE users SET role = ? WHERE username = ?`, role, username)
+	if err != nil {
+		return fmt.Errorf("set role of %s: %w", username, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("set role of %s: %w", username, err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Save creates an account or replaces an existing one's role and password.
+// A password change signs the user out everywhere.
+func (u *Users) Save(ctx context.Context, username, role, passwordHash string) error {
+	return inTx(ctx, u.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO users (username, role, password_hash) VALUES (?, ?, ?)
+			ON CONFLICT(username) DO UPDATE SET role = excluded.role, password_hash = excluded.password_hash`,
+			username, role, passwordHash); err != nil {
+			return fmt.Errorf("save user %s: %w", username, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = (SELECT id FROM users WHERE username = ?)`, username); err != nil {
+			return fmt.Errorf("sign out %s: %w", username, err)
+		}
+		return nil
+	})
+}
+
+// Delete removes an account and its sessions.
+func (u *Users) Delete(ctx context.Context, username string) error {
+	return inTx(ctx, u.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = (SELECT id FROM users WHERE username = ?)`, username); err != nil {
+			return fmt.Errorf("sign out %s: %w", username, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE username = ?`, username); err != nil {
+			return fmt.Errorf("delete user %s: %w", username, err)
+		}
+		return nil
+	})
+}
+
+// Sessions is sessions: logins, keyed by the SHA-256 of the cookie token.
+type Sessions struct {
+	db *sql.DB
+}
+
+// Session is a live login and who it belongs to. User has no password hash.
+type Session struct {
+	TokenHash string
+	User      User
+	ReauthAt  time.Time
+}
+
+// Start records a new session, first dropping any that have expired.
+func (s *Sessions) Start(ctx context.Context, tokenHash string, userID int, now, expiresAt time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at < ?`, now); err != nil {
+		return fmt.Errorf("drop expired sessions: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO sessions (token_hash, user_id, expires_at, reauth_at) VALUES (?, ?, ?, ?)`,
+		tokenHash, userID, expiresAt, now); err != nil {
+		return fmt.Errorf("start session for user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// Lookup returns the session if it hasn't expired by now, or ErrNotFound.
+func (s *Sessions) Lookup(ctx context.Context, tokenHash string, now time.Time) (Session, error) {
+	sess := Session{TokenHash: tokenHash}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT u.id, u.username, u.role, s.reauth_at
+		FROM sessions s JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = ? AND s.expires_at > ?`,
+		tokenHash, now).Scan(&sess.User.ID, &sess.User.Username, &sess.User.Role, &sess.ReauthAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, ErrNotFound
+	}
+	if err != nil {
+		return Session{}, fmt.Errorf("look up session: %w", err)
+	}
+	return sess, nil
+}
+
+// Reauth records that the session's user just re-entered their password.
+func (s *Sessions) Reauth(ctx context.Context, tokenHash string, at time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE sessions SET reauth_at = ? WHERE token_hash = ?`, at, tokenHash); err != nil {
+		return fmt.Errorf("reauth session: %w", err)
+	}
+	return nil
+}
+
+// End deletes a session.
+func (s *Sessions) End(ctx context.Context, tokenHash string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE token_hash = ?`, tokenHash); err != nil {
+		return fmt.Errorf("end session: %w", err)
+	}
+	return nil
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
-	"sync"
+	"slices"
+	"sort"
 	"time"
 )
 
@@ -18,92 +20,249 @@ func tbaKey() string {
 	return ""
 }
 
+// Match is a TBA match. The result fields are only meaningful for matches
+// from TBA: scores are -1 until the match is played, and ScoreBreakdown is
+// only in the full (not /simple) match list and match_score webhooks.
 type Match struct {
 	Key         string `json:"key"`
 	MatchNumber int    `json:"match_number"`
+	SetNumber   int    `json:"set_number"`
 	CompLevel   string `json:"comp_level"`
 	Alliances   struct {
 		Red  Alliance `json:"red"`
 		Blue Alliance `json:"blue"`
 	} `json:"alliances"`
+	WinningAlliance string `json:"winning_alliance,omitempty"`
+	ActualTime      int64  `json:"actual_time,omitempty"`
+	ScoreBreakdown  *struct {
+		Red  json.RawMessage `json:"red"`
+		Blue json.RawMessage `json:"blue"`
+	} `json:"score_breakdown,omitempty"`
 }
 
 type Alliance struct {
 	TeamKeys []string `json:"team_keys"`
+	Score    int      `json:"score"`
 }
 
-// Cache variables
-var (
-	eventCache     []Event
-	cacheTimestamp time.Time
-	cacheMutex     sync.Mutex
+// TBA data is cached in api_cache (apicache.go) for these long; rankings
+// move during the event, so they're revalidated more often.
+const (
+	eventsMaxAge     = time.Hour
+	matchesMaxAge    = 10 * time.Minute
+	rankingsMaxAge   = 2 * time.Minute
+	teamEventsMaxAge = time.Hour
+	teamInfoMaxAge   = 24 * time.Hour
 )
 
-var (
-	matchCache     = make(map[string][]Match)
-	matchTimestamp = make(map[string]time.Time)
-	matchMutex     sync.Mutex
-)
+// tbaGet fetches a TBA API path through the cache and decodes the JSON
+// response into v. source names the data in the stale banner.
+func tbaGet(ctx context.Context, path string, maxAge time.Duration, source string, v interface{}) error {
+	header := http.Header{}
+	header.Set("X-TBA-Auth-Key", tbaKey())
+	if err := cachedGet(ctx, TBA_BASE+path, header, maxAge, source, v); err != nil {
+		return fmt.Errorf("TBA %s: %w", path, err)
+	}
+	return nil
+}
+
+func tbaMatchesPath(eventKey string) string {
+	return "/event/" + eventKey + "/matches/simple"
+}
+
+// tbaResultsPath is the full match list, which has score breakdowns.
+func tbaResultsPath(eventKey string) string {
+	return "/event/" + eventKey + "/matches"
+}
 
-func getMatchesCached(eventKey string) ([]Match, error) {
+func tbaRankingsPath(eventKey string) string {
+	return "/event/" + eventKey + "/rankings"
+}
+
+func getMatchesCached(ctx context.Context, eventKey string) ([]Match, error) {
 	if eventKey == testEventKey {
 		return testMatches, nil
 	}
-
-	matchMutex.Lock()
-	defer matchMutex.Unlock()
-
-	if m, ok := matchCache[eventKey]; ok && time.Since(matchTimestamp[eventKey]) < 10*time.Minute {
-		return m, nil
+	if matches, ok, err := customEventMatches(ctx, eventKey); err != nil || ok {
+		return matches, err
 	}
 
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/event/%s/matches/simple", TBA_BASE, eventKey), nil)
-	req.Header.Set("X-TBA-Auth-Key", tbaKey())
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
+	var matches []Match
+	if err := tbaGet(ctx, tbaMatchesPath(eventKey), matchesMaxAge, "Schedule", &matches); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return matches, nil
+}
 
+// getMatchResultsCached lists eventKey's matches with scores and breakdowns.
+// Use getMatchesCached for the schedule; this is a much bigger download.
+func getMatchResultsCached(ctx context.Context, eventKey string) ([]Match, error) {
 	var matches []Match
-	if err := json.NewDecoder(resp.Body).Decode(&matches); err != nil {
+	if err := tbaGet(ctx, tbaResultsPath(eventKey), matchesMaxAge, "Match results", &matches); err != nil {
 		return nil, err
 	}
-
-	matchCache[eventKey] = matches
-	matchTimestamp[eventKey] = time.Now()
 	return matches, nil
 }
 
-func getEventsCached(year string) ([]Event, error) {
+func getEventsCached(ctx context.Context, year string) ([]Event, error) {
 	// Always inject the test event regardless of year filter
 	testEvent := Event{Key: testEventKey, Name: testEventName, StartDate: "2026-01-01"}
 
-	cacheMutex.Lock()
-	defer cacheMutex.Unlock()
-
-	if len(eventCache) > 0 && time.Since(cacheTimestamp) < time.Hour {
-		return append(eventCache, testEvent), nil
+	var events []Event
+	if err := tbaGet(ctx, "/events/"+year+"/simple", eventsMaxAge, "Event list", &events); err != nil {
+		return nil, err
 	}
+	return append(events, testEvent), nil
+}
 
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/events/%s/simple", TBA_BASE, year), nil)
-	req.Header.Set("X-TBA-Auth-Key", tbaKey())
+// getRankingsCached returns team numbers in current qualification ranking
+// order.
+func getRankingsCached(ctx context.Context, eventKey string) ([]string, error) {
+	if eventKey == testEventKey {
+		return testRankings, nil
+	}
+	if custom, err := isCustomEvent(ctx, eventKey); err != nil || custom {
+		return nil, err // a custom event has no results to rank by
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
+	var data struct {
+		Rankings []struct {
+			Rank    int    `json:"rank"`
+			TeamKey string `json:"team_key"`
+		} `json:"rankings"`
+	}
+	if err := tbaGet(ctx, tbaRankingsPath(eventKey), rankingsMaxAge, "Rankings", &data); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
+	sort.Slice(data.Rankings, func(i, j int) bool { return data.Rankings[i].Rank < data.Rankings[j].Rank })
+	keys := make([]string, 0, len(data.Rankings))
+	for _, r := range data.Rankings {
+		keys = append(keys, r.TeamKey)
+	}
+	return stripFRC(keys), nil
+}
+
+// invalidateMatches makes the next read of eventKey's schedule go to TBA.
+func invalidateMatches(eventKey string) {
+	expireCachedResponse(TBA_BASE + tbaMatchesPath(eventKey))
+}
+
+// updateCachedMatch replaces or adds m in eventKey's cached schedule and
+// reports whether its alliances changed. A schedule that isn't cached is
+// left alone; a partial one would hide the rest of the matches.
+func updateCachedMatch(eventKey string, m Match) (changed bool) {
+	var matches []Match
+	updateCachedResponse(TBA_BASE+tbaMatchesPath(eventKey), &matches, func() {
+		for i, old := range matches {
+			if old.Key == m.Key {
+				matches[i] = m
+				changed = !sameAlliances(old, m)
+				return
+			}
+		}
+		matches = append(matches, m)
+		changed = true
+	})
+	return changed
+}
+
+func sameAlliances(a, b Match) bool {
+	return slices.Equal(a.Alliances.Red.TeamKeys, b.Alliances.Red.TeamKeys) &&
+		slices.Equal(a.Alliances.Blue.TeamKeys, b.Alliances.Blue.TeamKeys)
+}
+
+func invalidateMatchResults(eventKey string) {
+	expireCachedResponse(TBA_BASE + tbaResultsPath(eventKey))
+}
+
+func invalidateRankings(eventKey string) {
+	expireCachedResponse(TBA_BASE + tbaRankingsPath(eventKey))
+}
+
+// teamEventStatus is how a team did at one event, from TBA's
+// /team/{key}/events/{year}/statuses.
+type teamEventStatus struct {
+	Qual *struct {
+		NumTeams int `json:"num_teams"`
+		Ranking  struct {
+			Rank   int `json:"rank"`
+			Record *struct {
+				Wins   int `json:"wins"`
+				Losses int `json:"losses"`
+				Ties   int `json:"ties"`
+			} `json:"record"`
+		} `json:"ranking"`
+	} `json:"qual"`
+	Alliance *struct {
+		Name   string `json:"name"`
+		Number int    `json:"number"`
+		Pick   int    `json:"pick"`
+	} `json:"alliance"`
+	Playoff *struct {
+		Level  string `json:"level"`
+		Status string `json:"status"`
+	} `json:"playoff"`
+}
+
+// getTeamEventsCached lists the events a team is registered for in a season.
+func getTeamEventsCached(ctx context.Context, teamNum string, year int) ([]Event, error) {
 	var events []Event
-	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+	if err := tbaGet(ctx, fmt.Sprintf("/team/frc%s/events/%d/simple", teamNum, year), teamEventsMaxAge, "Team events", &events); err != nil {
 		return nil, err
 	}
+	return events, nil
+}
 
-	eventCache = events
-	cacheTimestamp = time.Now()
-	return append(events, testEvent), nil
+// getTeamEventStatusesCached returns a team's status at each of its events
+// in a season, by event key. Events that haven't started have no entry.
+func getTeamEventStatusesCached(ctx context.Context, teamNum string, year int) (map[string]teamEventStatus, error) {
+	var raw map[string]*teamEventStatus
+	if err := tbaGet(ctx, fmt.Sprintf("/team/frc%s/events/%d/statuses", teamNum, year), teamEventsMaxAge, "Team events", &raw); err != nil {
+		return nil, err
+	}
+	statuses := map[string]teamEventStatus{}
+	for key, st := range raw {
+		if st != nil {
+			statuses[key] = *st
+		}
+	}
+	return statuses, nil
+}
+
+// teamInfo is TBA's /team/{key}.
+type teamInfo struct {
+	Nickname   string `json:"nickname"`
+	RookieYear int    `json:"rookie_year"`
+	City       string `json:"city"`
+	StateProv  string `json:"state_prov"`
+	Country    string `json:"country"`
+}
+
+func getTeamCached(ctx context.Context, teamNum string) (teamInfo, error) {
+	var t teamInfo
+	if err := tbaGet(ctx, "/team/frc"+teamNum, teamInfoMaxAge, "Team info", &t); err != nil {
+		return teamInfo{}, err
+	}
+	return t, nil
+}
+
+// teamMatch is the part of a full TBA match the team page uses: whether it
+// has been played and where to watch it.
+type teamMatch struct {
+	Key        string `json:"key"`
+	ActualTime int64  `json:"actual_time"`
+	Videos     []struct {
+		Type string `json:"type"`
+		Key  string `json:"key"`
+	} `json:"videos"`
+}
+
+// getTeamEventMatchesCached lists a team's matches at an event with videos.
+func getTeamEventMatchesCached(ctx context.Context, teamNum, eventKey string) ([]teamMatch, error) {
+	var matches []teamMatch
+	if err := tbaGet(ctx, "/team/frc"+teamNum+"/event/"+eventKey+"/matches", matchesMaxAge, "Match videos", &matches); err != nil {
+		return nil, err
+	}
+	return matches, nil
 }
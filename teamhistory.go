@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+)
+
+// A team's history is what this server has on it from every event: the notes
+// and cached analyses scouted here, merged with TBA's list of the events it
+// attended and how it did. Team analysis can add the scouted part of earlier
+// events to its prompt, labelled as older data.
+
+// teamHistorySeasons is how many seasons of TBA events are listed, counting
+// this one.
+const teamHistorySeasons = 3
+
+// Earlier events in the analysis prompt are capped so they can't crowd out
+// the current event's notes.
+const (
+	priorEventLimit    = 3
+	priorNotesPerEvent = 12
+)
+
+type teamEventHistory struct {
+	EventKey  string
+	Name      string
+	StartDate string // YYYY-MM-DD; the first scouted day if TBA doesn't know the event
+	Status    string // from TBA, empty if the event hasn't started
+	Notes     []store.Note
+	Analysis  *teamAnalysisJSON
+}
+
+// loadTeamHistory lists the team's events, newest first. Without TBA only
+// the events scouted here are listed.
+func loadTeamHistory(ctx context.Context, teamNum string) ([]teamEventHistory, error) {
+	notes, err := repo.Submissions.TeamNotes(ctx, teamNum)
+	if err != nil {
+		return nil, err
+	}
+	analyses, err := repo.Analysis.ForTeam(ctx, teamNum)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]*teamEventHistory{}
+	event := func(key string) *teamEventHistory {
+		if h, ok := byKey[key]; ok {
+			return h
+		}
+		h := &teamEventHistory{EventKey: key, Name: key}
+		byKey[key] = h
+		return h
+	}
+	for _, n := range notes {
+		h := event(n.EventKey)
+		if h.StartDate == "" && !n.CreatedAt.IsZero() {
+			h.StartDate = n.CreatedAt.Local().Format("2006-01-02")
+		}
+		h.Notes = append(h.Notes, n)
+	}
+	for _, a := range analyses {
+		var result teamAnalysisJSON
+		if json.Unmarshal([]byte(a.Text), &result) == nil {
+			event(a.EventKey).Analysis = &result
+		}
+	}
+
+	if h, ok := byKey[testEventKey]; ok {
+		h.Name = testEventName
+	}
+	custom, err := listCustomEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range custom {
+		if h, ok := byKey[e.Key]; ok {
+			h.Name, h.StartDate = e.Name, e.StartDate
+		}
+	}
+	year := time.Now().Year()
+	for season := year; season > year-teamHistorySeasons; season-- {
+		events, err := getTeamEventsCached(ctx, teamNum, season)
+		if err != nil {
+			log.Printf("team history %s: %v", teamNum, err)
+			break // offline; the rest would fail too
+		}
+		statuses, err := getTeamEventStatusesCached(ctx, teamNum, season)
+		if err != nil {
+			log.Printf("team history %s: %v", teamNum, err)
+		}
+		for _, e := range events {
+			h := event(e.Key)
+			h.Name, h.StartDate = e.Name, e.StartDate
+			if st, ok := statuses[e.Key]; ok {
+				h.Status = st.summary()
+			}
+		}
+	}
+
+	history := make([]teamEventHistory, 0, len(byKey))
+	for _, h := range byKey {
+		history = append(history, *h)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].StartDate != history[j].StartDate {
+			return history[i].StartDate > history[j].StartDate
+		}
+		return history[i].EventKey > history[j].EventKey
+	})
+	return history, nil
+}
+
+// summary reads like "Rank 4 of 40 (9-3-0) · Alliance 2 first pick · won the
+// Final".
+func (st teamEventStatus) summary() string {
+	var parts []string
+	if q := st.Qual; q != nil && q.Ranking.Rank > 0 {
+		s := fmt.Sprintf("Rank %d of %d", q.Ranking.Rank, q.NumTeams)
+		if r := q.Ranking.Record; r != nil {
+			s += fmt.Sprintf(" (%d-%d-%d)", r.Wins, r.Losses, r.Ties)
+		}
+		parts = append(parts, s)
+	}
+	if a := st.Alliance; a != nil {
+		name := a.Name
+		if name == "" {
+			name = fmt.Sprintf("Alliance %d", a.Number)
+		}
+		picks := []string{"captain", "first pick", "second pick", "third pick"}
+		if a.Pick >= 0 && a.Pick < len(picks) {
+			name += " " + picks[a.Pick]
+		}
+		parts = append(parts, name)
+	}
+	if p := st.Playoff; p != nil && p.Level != "" {
+		level := compLevelName(p.Level)
+		switch p.Status {
+		case "won":
+			parts = append(parts, "won the "+level)
+		case "eliminated":
+			parts = append(parts, "out in the "+level)
+		case "playing":
+			parts = append(parts, "playing in the "+level)
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// priorEventContext describes what was scouted of the team at events that
+// started before eventKey did, newest first, for the team analysis prompt.
+// Events are dated as on the team page: by TBA, the custom event, or the
+// first scouted day. It returns the text and the events it covers, or "" and
+// nil if there are none.
+func priorEventContext(ctx context.Context, eventKey, teamNum string) (string, []string, error) {
+	history, err := loadTeamHistory(ctx, teamNum)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// An event nobody has dated yet is the one being played now.
+	start := time.Now().Format("2006-01-02")
+	for _, h := range history {
+		if h.EventKey == eventKey && h.StartDate != "" {
+			start = h.StartDate
+		}
+	}
+	var prior []teamEventHistory
+	for _, h := range history {
+		if h.EventKey == eventKey || h.EventKey == testEventKey || len(h.Notes) == 0 ||
+			h.StartDate == "" || h.StartDate >= start {
+			continue
+		}
+		prior = append(prior, h)
+		if len(prior) == priorEventLimit {
+			break
+		}
+	}
+
+	var b strings.Builder
+	var events []string
+	for _, h := range prior {
+		events = append(events, h.EventKey)
+		name := h.EventKey
+		if h.Name != h.EventKey {
+			name = fmt.Sprintf("%s (%s)", h.Name, h.EventKey)
+		}
+		fmt.Fprintf(&b, "--- %s, starting %s (OLDER DATA) ---\n", name, h.StartDate)
+
+		if a := h.Analysis; a != nil {
+			fmt.Fprintf(&b, "Analysis then: scoring %d/10, reliability %d/10, defense %s. %s\n",
+				a.Scoring, a.Reliability, defenseLabel(a.Defense), a.Summary)
+		}
+		eventNotes := h.Notes
+		if len(eventNotes) > priorNotesPerEvent {
+			eventNotes = eventNotes[len(eventNotes)-priorNotesPerEvent:]
+		}
+		for _, n := range eventNotes {
+			label := n.MatchKey
+			if id, err := parseMatchKey(n.MatchKey); err == nil {
+				label = id.Label()
+			}
+			fmt.Fprintf(&b, "- %s: %s\n", label, n.Notes)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String()), events, nil
+}
+
+func defenseLabel(score int) string {
+	if score == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%d/10", score)
+}
+
+// apiTeamHistoryHandler renders the team's history panel. event_key, if
+// given, marks the event being looked at.
+func apiTeamHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	teamNum := r.URL.Query().Get("team_number")
+	if teamNum == "" {
+		http.Error(w, "team_number required", http.StatusBadRequest)
+		return
+	}
+
+	history, err := loadTeamHistory(r.Context(), teamNum)
+	if err != nil {
+		log.Printf("team history %s: %v", teamNum, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	templates.TeamHistoryPanel(teamHistoryView(history, r.URL.Query().Get("event_key"))).Render(r.Context(), w)
+}
+
+func teamHistoryView(history []teamEventHistory, currentEvent string) []templates.TeamHistoryEvent {
+	view := make([]templates.TeamHistoryEvent, 0, len(history))
+	for _, h := range history {
+		e := templates.TeamHistoryEvent{
+			EventKey:  h.EventKey,
+			Name:      h.Name,
+			StartDate: h.StartDate,
+			Status:    h.Status,
+			Current:   h.EventKey == currentEvent,
+		}
+		for _, n := range h.Notes {
+			e.Notes = append(e.Notes, teamNoteView(n))
+		}
+		if a := h.Analysis; a != nil {
+			e.Analysis = &templates.TeamAnalysisCard{
+				EventKey:    h.EventKey,
+				Summary:     a.Summary,
+				Scoring:     a.Scoring,
+				Reliability: a.Reliability,
+				Defense:     a.Defense,
+			}
+		}
+		view = append(view, e)
+	}
+	return view
+}
+
+func teamNoteView(n store.Note) templates.TeamNote {
+	note := templates.TeamNote{
+		MatchLabel:  n.MatchKey,
+		Notes:       n.Notes,
+		Source:      n.Source,
+		Scouter:     n.ScouterName,
+		AIGenerated: n.AIGenerated,
+	}
+	if id, err := parseMatchKey(n.MatchKey); err == nil {
+		note.MatchLabel = id.Label()
+	}
+	return note
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
+
+	"vibe-scout/store"
+	"vibe-scout/templates"
+)
+
+// teamPageHandler serves /team/{number}: the team from TBA, Statbotics and
+// everything scouted of it, about ?event_key= or else the latest event it
+// was scouted at.
+func teamPageHandler(w http.ResponseWriter, r *http.Request) {
+	teamNum := r.PathValue("number")
+	if n, err := strconv.Atoi(teamNum); err != nil || n <= 0 || strconv.Itoa(n) != teamNum {
+		http.NotFound(w, r)
+		return
+	}
+	ctx := r.Context()
+
+	history, err := loadTeamHistory(ctx, teamNum)
+	if err != nil {
+		log.Printf("team page %s: %v", teamNum, err)
+		http.Error(w, "DB error", http.StatusInternalServerError)
+		return
+	}
+	eventKey := r.URL.Query().Get("event_key")
+	if eventKey == "" {
+		eventKey = latestTeamEvent(history)
+	}
+
+	data := templates.TeamPageData{
+		TeamNumber: teamNum,
+		EventKey:   eventKey,
+		EventName:  eventKey,
+		Events:     teamHistoryView(history, eventKey),
+	}
+	if info, err := getTeamCached(ctx, teamNum); err == nil {
+		data.Nickname, data.RookieYear = info.Nickname, info.RookieYear
+		data.Location = teamLocation(info)
+	} else if !errors.Is(err, errUpstreamNotFound) {
+		log.Printf("team page %s: %v", teamNum, err)
+	}
+	if breakdown, ok := fetchStatboticsBreakdown(ctx, teamNum); ok {
+		data.EPA = epaComponents(breakdown)
+	}
+
+	if eventKey != "" {
+		scouted := map[string]bool{}
+		for _, h := range history {
+			if h.EventKey != eventKey {
+				continue
+			}
+			data.EventName = h.Name
+			for _, n := range h.Notes {
+				scouted[n.MatchKey] = true
+			}
+		}
+		data.Analysis, err = cachedAnalysisCard(ctx, eventKey, teamNum)
+		if err != nil {
+			log.Printf("team page %s: %v", teamNum, err)
+			http.Error(w, "DB error", http.StatusInternalServerError)
+			return
+		}
+		data.Matches = teamPageMatches(ctx, eventKey, teamNum, scouted)
+	}
+
+	templ.Handler(templates.TeamPage(data)).ServeHTTP(w, r)
+}
+
+// latestTeamEvent picks the newest event the team was scouted at, or failing
+// that the newest one TBA lists.
+func latestTeamEvent(history []teamEventHistory) string {
+	for _, h := range history {
+		if len(h.Notes) > 0 {
+			return h.EventKey
+		}
+	}
+	if len(history) > 0 {
+		return history[0].EventKey
+	}
+	return ""
+}
+
+func teamLocation(t teamInfo) string {
+	var parts []string
+	for _, p := range []string{t.City, t.StateProv, t.Country} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func epaComponents(breakdown map[string]float64) []templates.EPAComponent {
+	keys := make([]string, 0, len(breakdown))
+	for k := range breakdown {
+		keys = append(keys, k)
+	}
+	sortEPAKeys(keys)
+	out := make([]templates.EPAComponent, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, templates.EPAComponent{Key: k, Value: breakdown[k]})
+	}
+	return out
+}
+
+// cachedAnalysisCard returns the team's cached analysis at the event, or nil
+// if it hasn't been analyzed. It may predate the latest notes.
+func cachedAnalysisCard(ctx context.Context, eventKey, teamNum string) (*templates.TeamAnalysisCard, error) {
+	cached, err := repo.Analysis.Get(ctx, eventKey, teamNum)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var result cachedTeamAnalysis
+	if json.Unmarshal([]byte(cached.Text), &result) != nil {
+		return nil, nil
+	}
+	return &templates.TeamAnalysisCard{
+		EventKey:    eventKey,
+		TeamNumber:  teamNum,
+		Summary:     result.Summary,
+		Scoring:     result.Scoring,
+		Reliability: result.Reliability,
+		Defense:     result.Defense,
+		PriorEvents: result.PriorEvents,
+		FromCache:   true,
+	}, nil
+}
+
+// teamPageMatches lists the team's matches at the event in play order. A
+// match counts as played once TBA has a time for it or we scouted it; videos
+// come from TBA, so test and custom events have none.
+func teamPageMatches(ctx context.Context, eventKey, teamNum string, scouted map[string]bool) []templates.TeamPageMatch {
+	schedule, err := getMatchesCached(ctx, eventKey)
+	if err != nil {
+		log.Printf("team page %s: %v", teamNum, err)
+		return nil
+	}
+	schedule = slices.Clone(schedule)
+	sortMatches(schedule)
+
+	tbaMatches := map[string]teamMatch{}
+	custom, err := isCustomEvent(ctx, eventKey)
+	if err != nil {
+		log.Printf("team page %s: %v", teamNum, err)
+	}
+	if eventKey != testEventKey && !custom && err == nil {
+		matches, err := getTeamEventMatchesCached(ctx, teamNum, eventKey)
+		if err != nil {
+			log.Printf("team page %s: %v", teamNum, err)
+		}
+		for _, m := range matches {
+			tbaMatches[m.Key] = m
+		}
+	}
+
+	teamKey := "frc" + teamNum
+	var out []templates.TeamPageMatch
+	for _, m := range schedule {
+		if !slices.Contains(m.Alliances.Red.TeamKeys, teamKey) && !slices.Contains(m.Alliances.Blue.TeamKeys, teamKey) {
+			continue
+		}
+		tm := tbaMatches[m.Key]
+		pm := templates.TeamPageMatch{
+			Label:  m.ID(eventKey).Label(),
+			Key:    m.Key,
+			Red:    stripFRC(m.Alliances.Red.TeamKeys),
+			Blue:   stripFRC(m.Alliances.Blue.TeamKeys),
+			Played: tm.ActualTime > 0 || scouted[m.Key],
+		}
+		for _, v := range tm.Videos {
+			if v.Type == "youtube" {
+				pm.Videos = append(pm.Videos, templates.MatchVideo{
+					Label: fmt.Sprintf("Video %d", len(pm.Videos)+1),
+					URL:   "https://www.youtube.com/watch?v=" + v.Key,
+				})
+			}
+		}
+		out = append(out, pm)
+	}
+	return out
+}
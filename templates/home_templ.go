@@ -8,7 +8,7 @@ package templates
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
-func Home(events map[string]string) templ.Component {
+func Home(events map[string]string, levels []CompLevelOption) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -41,7 +41,7 @@ func Home(events map[string]string) templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<main class=\"flex flex-col items-center justify-center min-h-screen px-4\"><div class=\"max-w-md w-full bg-[#F2E8D5] border-2 border-[#D2B48C] rounded-3xl p-8 shadow-2xl text-center\"><h1 class=\"text-4xl font-black text-[#5D4037] mb-2 tracking-tight uppercase\">Vibe Scout</h1><form action=\"/scout\" method=\"GET\" class=\"space-y-4\"><div class=\"text-left\"><label class=\"block text-xs font-bold uppercase text-[#A1887F] ml-2 mb-1\">Event</label> <select name=\"event_key\" class=\"w-full p-4 bg-[#FFFBF5] border-2 border-[#D2B48C] rounded-xl text-stone-700\">")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<main class=\"flex flex-col items-center justify-center min-h-screen px-4\"><div class=\"max-w-md w-full bg-[#F2E8D5] border-2 border-[#D2B48C] rounded-3xl p-8 shadow-2xl text-center\"><h1 class=\"text-4xl font-black text-[#5D4037] mb-2 tracking-tight uppercase\">Vibe Scout</h1><form action=\"/scout\" method=\"GET\" class=\"space-y-4\"><div class=\"text-left\"><label class=\"block text-xs font-bold uppercase text-[#A1887F] ml-2 mb-1\">Event</label> <select id=\"home-event\" name=\"event_key\" class=\"w-full p-4 bg-[#FFFBF5] border-2 border-[#D2B48C] rounded-xl text-stone-700\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -77,7 +77,23 @@ func Home(events map[string]string) templ.Component {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</select></div><div class=\"text-left\"><label class=\"block text-xs font-bold uppercase text-[#A1887F] ml-2 mb-1\">Start at Match #</label> <input type=\"number\" name=\"match_num\" value=\"1\" min=\"1\" class=\"w-full p-4 bg-[#FFFBF5] border-2 border-[#D2B48C] rounded-xl text-stone-700 font-bold\"></div><div class=\"text-left\"><label class=\"block text-xs font-bold uppercase text-[#A1887F] ml-2 mb-1\">Scouter #</label> <select name=\"scouter_id\" class=\"w-full p-4 bg-[#FFFBF5] border-2 border-[#D2B48C] rounded-xl text-stone-700\"><option value=\"1\">Scouter 1</option> <option value=\"2\">Scouter 2</option> <option value=\"3\">Scouter 3</option> <option value=\"4\">Scouter 4</option> <option value=\"5\">Scouter 5</option> <option value=\"6\">Scouter 6</option></select></div><div class=\"text-left\"><label class=\"block text-xs font-bold uppercase text-[#A1887F] ml-2 mb-1\">Alliance to Scout</label> <select name=\"alliance\" class=\"w-full p-4 bg-[#FFFBF5] border-2 border-[#D2B48C] rounded-xl text-stone-700\"><option value=\"auto\">Auto (based on scouter)</option> <option value=\"Red\">Red Alliance</option> <option value=\"Blue\">Blue Alliance</option></select></div><button type=\"submit\" class=\"w-full bg-[#D2B48C] hover:bg-[#B99976] text-[#4E342E] font-extrabold py-5 rounded-2xl shadow-lg transition active:scale-95 uppercase tracking-widest border-b-4 border-[#B99976]\">Join Scouting Rotation</button></form></div><div class=\"mt-6 text-center\"><a href=\"/analysis\" class=\"text-sm text-[#A1887F] hover:text-[#5D4037] font-bold\">AI Analysis</a></div></main>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "</select></div><div class=\"text-left\"><label class=\"block text-xs font-bold uppercase text-[#A1887F] ml-2 mb-1\">Scouter</label> <select name=\"scouter_id\" class=\"w-full p-4 bg-[#FFFBF5] border-2 border-[#D2B48C] rounded-xl text-stone-700\" hx-get=\"/api/roster/scouter-options\" hx-trigger=\"load, change from:#home-event\" hx-include=\"#home-event\"><option value=\"1\">Scouter 1</option> <option value=\"2\">Scouter 2</option> <option value=\"3\">Scouter 3</option> <option value=\"4\">Scouter 4</option> <option value=\"5\">Scouter 5</option> <option value=\"6\">Scouter 6</option></select></div><div class=\"text-left\"><label class=\"block text-xs font-bold uppercase text-[#A1887F] ml-2 mb-1\">Match Level</label>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = compLevelSelect(levels, "w-full p-4 bg-[#FFFBF5] border-2 border-[#D2B48C] rounded-xl text-stone-700").Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "</div><div class=\"flex gap-3 text-left\"><div class=\"w-1/3\"><label class=\"block text-xs font-bold uppercase text-[#A1887F] ml-2 mb-1\">Set #</label> <input type=\"number\" name=\"set_number\" value=\"1\" min=\"1\" class=\"w-full p-4 bg-[#FFFBF5] border-2 border-[#D2B48C] rounded-xl text-stone-700 font-bold\"></div><div class=\"flex-1\"><label class=\"block text-xs font-bold uppercase text-[#A1887F] ml-2 mb-1\">Match #</label> <input type=\"number\" name=\"match_num\" min=\"1\" placeholder=\"My next assignment\" class=\"w-full p-4 bg-[#FFFBF5] border-2 border-[#D2B48C] rounded-xl text-stone-700 font-bold\"></div></div><div class=\"text-left\"><label class=\"block text-xs font-bold uppercase text-[#A1887F] ml-2 mb-1\">Alliance to Scout</label> <select name=\"alliance\" class=\"w-full p-4 bg-[#FFFBF5] border-2 border-[#D2B48C] rounded-xl text-stone-700\"><option value=\"auto\">Auto (my assignment)</option> <option value=\"Red\">Red Alliance</option> <option value=\"Blue\">Blue Alliance</option></select></div><button type=\"submit\" class=\"w-full bg-[#D2B48C] hover:bg-[#B99976] text-[#4E342E] font-extrabold py-5 rounded-2xl shadow-lg transition active:scale-95 uppercase tracking-widest border-b-4 border-[#B99976]\">Join Scouting Rotation</button></form></div><div class=\"mt-6 flex gap-6 justify-center\"><a href=\"/analysis\" class=\"text-sm text-[#A1887F] hover:text-[#5D4037] font-bold\">AI Analysis</a> <a href=\"/picklist\" class=\"text-sm text-[#A1887F] hover:text-[#5D4037] font-bold\">Pick List</a> <a href=\"/results\" class=\"text-sm text-[#A1887F] hover:text-[#5D4037] font-bold\">Results</a> <a href=\"/roster\" class=\"text-sm text-[#A1887F] hover:text-[#5D4037] font-bold\">Scout Roster</a>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = logoutButton().Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "</div></main>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
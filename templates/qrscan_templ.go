@@ -0,0 +1,58 @@
+// Code generated by templ - DO NOT EDIT.
+
+// templ: version: v0.3.977
+package templates
+
+//lint:file-ignore SA4006 This context is only used if a nested component is present.
+
+import "github.com/a-h/templ"
+import templruntime "github.com/a-h/templ/runtime"
+
+func QRScanPage() templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var1 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var1 == nil {
+			templ_7745c5c3_Var1 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Var2 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<main class=\"container mx-auto px-4 py-8\"><div class=\"max-w-2xl mx-auto bg-[#FFFBF5] border-2 border-[#D2B48C] rounded-3xl p-6\"><h1 class=\"text-3xl font-black text-[#5D4037] mb-2\">QR Import</h1><p class=\"text-sm text-[#A1887F] mb-6\">Scan the codes shown on a scouting tablet. Submissions are saved as soon as all of their parts have been read.</p><div class=\"mb-6\"><h2 class=\"text-xl font-bold text-[#5D4037] mb-3\">Webcam</h2><video id=\"qr-video\" class=\"w-full rounded-xl border-2 border-[#D2B48C] bg-black hidden\" playsinline muted></video><button id=\"camera-btn\" onclick=\"toggleCamera()\" class=\"mt-3 bg-[#8D6E63] hover:bg-[#6D4C41] text-white font-bold py-2 px-4 rounded-xl transition\">Start Camera</button></div><div class=\"mb-6\"><h2 class=\"text-xl font-bold text-[#5D4037] mb-3\">Images</h2><p class=\"text-sm text-[#A1887F] mb-2\">Choose screenshots or photos, or paste an image anywhere on this page.</p><input id=\"qr-files\" type=\"file\" accept=\"image/*\" multiple onchange=\"scanFiles(this.files)\" class=\"w-full text-sm\"></div><div class=\"mb-6\"><h2 class=\"text-xl font-bold text-[#5D4037] mb-3\">Raw Text</h2><textarea id=\"qr-text\" rows=\"3\" placeholder=\"VS1|...\" class=\"w-full p-3 text-xs font-mono border-2 border-[#D2B48C] rounded-xl bg-white\"></textarea> <button onclick=\"scanText()\" class=\"mt-2 bg-[#8D6E63] hover:bg-[#6D4C41] text-white font-bold py-2 px-4 rounded-xl transition\">Add Text</button></div><h2 class=\"text-xl font-bold text-[#5D4037] mb-3\">Progress</h2><ul id=\"qr-log\" class=\"space-y-2 text-sm\"></ul><div class=\"mt-8 text-center\"><a href=\"/admin\" class=\"text-[#5D4037] hover:text-[#8D6E63] font-bold\">← Back to Admin</a></div></div></main><canvas id=\"qr-canvas\" class=\"hidden\"></canvas><script src=\"https://unpkg.com/jsqr@1.4.0/dist/jsQR.js\"></script> <script>\n\t\t\tconst groups = {};   // submission id -> {total, parts: {n: chunk}}\n\t\t\tconst imported = new Set();\n\t\t\tlet stream = null;\n\n\t\t\tfunction log(text, ok) {\n\t\t\t\tconst li = document.createElement('li');\n\t\t\t\tli.className = 'rounded-xl px-4 py-2 border ' + (ok ? 'bg-green-50 border-green-300 text-green-800' : 'bg-[#F2E8D5] border-[#D2B48C] text-[#5D4037]');\n\t\t\t\tli.textContent = text;\n\t\t\t\tdocument.getElementById('qr-log').prepend(li);\n\t\t\t}\n\n\t\t\tasync function addChunk(text) {\n\t\t\t\tconst parts = text.trim().split('|');\n\t\t\t\tif (parts.length < 5 || parts[0] !== 'VS1') return;\n\t\t\t\tconst id = parts[1], part = parseInt(parts[2]), total = parseInt(parts[3]);\n\t\t\t\tif (imported.has(id)) return;\n\t\t\t\tconst g = groups[id] || (groups[id] = {total: total, parts: {}});\n\t\t\t\tif (g.parts[part]) return;\n\t\t\t\tg.parts[part] = text.trim();\n\n\t\t\t\tconst have = Object.keys(g.parts).length;\n\t\t\t\tif (have < g.total) {\n\t\t\t\t\tlog(`Read part ${part}/${total} of ${id.slice(0, 8)}…`, false);\n\t\t\t\t\treturn;\n\t\t\t\t}\n\n\t\t\t\timported.add(id);\n\t\t\t\tconst resp = await fetch('/api/admin/qr-import', {\n\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\theaders: {'Content-Type': 'application/json'},\n\t\t\t\t\tbody: JSON.stringify({chunks: Object.values(g.parts)})\n\t\t\t\t});\n\t\t\t\tconst msg = await resp.text();\n\t\t\t\tif (!resp.ok) imported.delete(id);\n\t\t\t\tlog(msg, resp.ok);\n\t\t\t}\n\n\t\t\tfunction scanCanvas(source, width, height) {\n\t\t\t\tconst canvas = document.getElementById('qr-canvas');\n\t\t\t\tcanvas.width = width;\n\t\t\t\tcanvas.height = height;\n\t\t\t\tconst ctx = canvas.getContext('2d', {willReadFrequently: true});\n\t\t\t\tctx.drawImage(source, 0, 0, width, height);\n\t\t\t\tconst img = ctx.getImageData(0, 0, width, height);\n\t\t\t\tconst code = jsQR(img.data, width, height);\n\t\t\t\treturn code ? code.data : null;\n\t\t\t}\n\n\t\t\tfunction scanImage(blob) {\n\t\t\t\tconst img = new Image();\n\t\t\t\timg.onload = () => {\n\t\t\t\t\tconst data = scanCanvas(img, img.naturalWidth, img.naturalHeight);\n\t\t\t\t\tif (data) addChunk(data);\n\t\t\t\t\telse log('No QR code found in image', false);\n\t\t\t\t\tURL.revokeObjectURL(img.src);\n\t\t\t\t};\n\t\t\t\timg.src = URL.createObjectURL(blob);\n\t\t\t}\n\n\t\t\tfunction scanFiles(files) {\n\t\t\t\tArray.from(files).forEach(scanImage);\n\t\t\t}\n\n\t\t\tfunction scanText() {\n\t\t\t\tconst box = document.getElementById('qr-text');\n\t\t\t\tbox.value.split(/\\s+/).filter(Boolean).forEach(addChunk);\n\t\t\t\tbox.value = '';\n\t\t\t}\n\n\t\t\tasync function toggleCamera() {\n\t\t\t\tconst video = document.getElementById('qr-video');\n\t\t\t\tconst btn = document.getElementById('camera-btn');\n\t\t\t\tif (stream) {\n\t\t\t\t\tstream.getTracks().forEach(t => t.stop());\n\t\t\t\t\tstream = null;\n\t\t\t\t\tvideo.classList.add('hidden');\n\t\t\t\t\tbtn.textContent = 'Start Camera';\n\t\t\t\t\treturn;\n\t\t\t\t}\n\t\t\t\ttry {\n\t\t\t\t\tstream = await navigator.mediaDevices.getUserMedia({video: {facingMode: 'environment'}});\n\t\t\t\t} catch (err) {\n\t\t\t\t\tlog('Camera unavailable: ' + err.message, false);\n\t\t\t\t\treturn;\n\t\t\t\t}\n\t\t\t\tvideo.srcObject = stream;\n\t\t\t\tvideo.classList.remove('hidden');\n\t\t\t\tbtn.textContent = 'Stop Camera';\n\t\t\t\tawait video.play();\n\t\t\t\tconst tick = () => {\n\t\t\t\t\tif (!stream) return;\n\t\t\t\t\tif (video.readyState === video.HAVE_ENOUGH_DATA) {\n\t\t\t\t\t\tconst data = scanCanvas(video, video.videoWidth, video.videoHeight);\n\t\t\t\t\t\tif (data) addChunk(data);\n\t\t\t\t\t}\n\t\t\t\t\trequestAnimationFrame(tick);\n\t\t\t\t};\n\t\t\t\trequestAnimationFrame(tick);\n\t\t\t}\n\n\t\t\tdocument.addEventListener('paste', e => {\n\t\t\t\tfor (const item of e.clipboardData.items) {\n\t\t\t\t\tif (item.type.startsWith('image/')) scanImage(item.getAsFile());\n\t\t\t\t}\n\t\t\t});\n\t\t</script>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			return nil
+		})
+		templ_7745c5c3_Err = Layout("QR Import - Vibe Scout").Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+var _ = templruntime.GeneratedTemplate
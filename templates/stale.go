@@ -0,0 +1,78 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StaleNotice is data a page was built from that couldn't be refreshed
+// because TBA or Statbotics was unreachable. Layout shows them in a banner.
+type StaleNotice struct {
+	Source    string // e.g. "Schedule"
+	FetchedAt time.Time
+}
+
+// Updated is when the data was last fetched, e.g. "2:04PM (35m ago)".
+func (n StaleNotice) Updated() string {
+	ago := time.Since(n.FetchedAt).Round(time.Minute)
+	switch {
+	case ago < time.Minute:
+		return n.FetchedAt.Local().Format(time.Kitchen) + " (just now)"
+	case ago < 24*time.Hour:
+		return fmt.Sprintf("%s (%s ago)", n.FetchedAt.Local().Format(time.Kitchen), shortDuration(ago))
+	}
+	return n.FetchedAt.Local().Format("Jan 2 3:04PM")
+}
+
+func shortDuration(d time.Duration) string {
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	if h == 0 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh %dm", h, m)
+}
+
+type staleCtxKey struct{}
+
+type staleNotices struct {
+	mu      sync.Mutex
+	notices []StaleNotice
+}
+
+// WithStaleTracking lets data fetched with the returned context report that
+// it's stale, for the page's banner.
+func WithStaleTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, staleCtxKey{}, &staleNotices{})
+}
+
+// NoteStale records that data from source, last fetched at fetchedAt, went
+// into the page. Only the oldest copy per source is kept.
+func NoteStale(ctx context.Context, source string, fetchedAt time.Time) {
+	s, ok := ctx.Value(staleCtxKey{}).(*staleNotices)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, n := range s.notices {
+		if n.Source == source {
+			if fetchedAt.Before(n.FetchedAt) {
+				s.notices[i].FetchedAt = fetchedAt
+			}
+			return
+		}
+	}
+	s.notices = append(s.notices, StaleNotice{Source: source, FetchedAt: fetchedAt})
+}
+
+func staleNoticesFrom(ctx context.Context) []StaleNotice {
+	s, ok := ctx.Value(staleCtxKey{}).(*staleNotices)
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StaleNotice(nil), s.notices...)
+}
@@ -9,33 +9,173 @@ type TeamAnalysisCard struct {
 	EventKey    string
 	TeamNumber  string
 	Summary     string
-	Scoring     int    // 1-10
-	Reliability int    // 1-10
-	Defense     int    // 0 = N/A, 1-10 = score
+	Scoring     int      // 1-10
+	Reliability int      // 1-10
+	Defense     int      // 0 = N/A, 1-10 = score
+	PriorEvents []string // earlier events whose scouting was in the prompt
 	FromCache   bool
 }
 
 type TeamNote struct {
-	MatchNum int
-	Notes    string
+	MatchLabel  string
+	Notes       string
+	Source      string // partner team the note was imported from, if any
+	Scouter     string // may be empty
+	AIGenerated bool
+}
+
+// TeamHistoryEvent is one event in a team's history: what TBA says of it and
+// what was scouted here.
+type TeamHistoryEvent struct {
+	EventKey  string
+	Name      string
+	StartDate string
+	Status    string // TBA result, e.g. "Rank 4 of 40 (9-3-0)"; empty if not started
+	Current   bool   // the event being looked at
+	Notes     []TeamNote
+	Analysis  *TeamAnalysisCard // cached analysis, if any
+}
+
+// TeamPageData is the /team/{number} profile, about one of the team's
+// events.
+type TeamPageData struct {
+	TeamNumber string
+	Nickname   string // from TBA; empty if it couldn't be reached
+	RookieYear int
+	Location   string
+	EventKey   string // empty if the team has no events we know of
+	EventName  string
+	Analysis   *TeamAnalysisCard // cached analysis at EventKey, if any
+	EPA        []EPAComponent
+	Matches    []TeamPageMatch    // the team's matches at EventKey
+	Events     []TeamHistoryEvent // newest first
+}
+
+// EPAComponent is one entry of a Statbotics EPA breakdown.
+type EPAComponent struct {
+	Key   string
+	Value float64
+}
+
+type TeamPageMatch struct {
+	Label  string
+	Key    string
+	Red    []string
+	Blue   []string
+	Played bool
+	Videos []MatchVideo
+}
+
+type MatchVideo struct {
+	Label string
+	URL   string
+}
+
+// ComparePageData is the side-by-side comparison of 2-4 teams at an event.
+type ComparePageData struct {
+	Events   map[string]string
+	EventKey string
+	Inputs   []string // the team boxes as typed, four of them
+	Error    string
+	Teams    []CompareTeam // empty until a valid set is asked for
+	Radar    *RadarChart
+	EPABars  *BarChart
+	NoteBars *BarChart
+}
+
+type CompareTeam struct {
+	TeamNumber string
+	Nickname   string
+	Color      string
+	Analysis   *TeamAnalysisCard // cached analysis, if any
+	NoteCount  int
+	EPATotal   float64
+	HasEPA     bool
+}
+
+// RadarChart is an SVG radar (spider) chart, laid out by the server.
+type RadarChart struct {
+	Size   float64  // width and height
+	Rings  []string // polygon points for the background rings
+	Axes   []RadarAxis
+	Series []ChartSeries
+}
+
+type RadarAxis struct {
+	Label          string
+	X, Y           float64 // outer end of the spoke
+	LabelX, LabelY float64
+	Anchor         string // text-anchor for the label
+}
+
+type ChartSeries struct {
+	Team   string
+	Color  string
+	Points string // polygon points
+}
+
+// BarChart is an SVG grouped bar chart, laid out by the server.
+type BarChart struct {
+	Width, Height float64
+	Left          float64 // x of the y axis
+	Baseline      float64 // y of the x axis
+	Grid          []GridLine
+	Groups        []BarGroup
+}
+
+type GridLine struct {
+	Y     float64
+	Label string
+}
+
+type BarGroup struct {
+	Label  string
+	LabelX float64
+	Bars   []Bar
+}
+
+type Bar struct {
+	Team       string
+	Color      string
+	X, Y, W, H float64
+	Value      string
+}
+
+// HeadToHeadCard is the LLM's head-to-head comparison. StatusURL and
+// EventsURL are set while it's being generated.
+type HeadToHeadCard struct {
+	Teams     []string
+	Text      string
+	StatusURL string
+	EventsURL string
+	Error     string
 }
 
 type MatchPlannerPageData struct {
 	Events map[string]string
+	Levels []CompLevelOption
 }
 
 type MatchPlanCard struct {
-	MatchNum    int
+	MatchLabel  string // e.g. "Qualification 12" or "Semifinal 2 Match 1"
 	OurAlliance string // "Red" or "Blue"
 	RedTeams    []string
 	BlueTeams   []string
 	Strategy    string
 	FromCache   bool
+	// Set while the plan is still being generated: Strategy streams in from
+	// EventsURL, then the card is replaced from StatusURL.
+	EventsURL string
+	StatusURL string
 }
 
-type AiFillSlot struct {
-	Team  string
-	HXURL string
+// JobSlot is the placeholder for a background job. It polls StatusURL,
+// which returns either an updated placeholder or the finished result.
+type JobSlot struct {
+	ID        int64
+	Team      string
+	StatusURL string
+	Status    string // e.g. "Queued (3 ahead)"
 }
 
 type AiFillTeamResultData struct {
@@ -44,3 +184,229 @@ type AiFillTeamResultData struct {
 	Success bool
 	Skipped bool // team already had data
 }
+
+// ScoutFormField is one structured input on the scout page, rendered from the
+// season's game schema.
+type ScoutFormField struct {
+	Key     string
+	Label   string
+	Section string
+	Type    string // "counter", "checkbox" or "enum"
+	Min     int
+	Max     int
+	Options []string
+	Default string
+}
+
+type PickListPageData struct {
+	Events        map[string]string
+	SelectedEvent string
+}
+
+type PickListBoard struct {
+	EventKey  string
+	Revision  int
+	Author    string
+	UpdatedAt string
+	Tiers     []PickListTier
+}
+
+type PickListTier struct {
+	Number int
+	Teams  []PickListTeam
+}
+
+type PickListTeam struct {
+	Team        string
+	Status      string // "", "dnp" or "picked"
+	Rank        int    // position among still-available teams, 0 if unavailable
+	HasAnalysis bool
+	Scoring     int
+	Reliability int
+	Defense     int
+	HasEPA      bool
+	EPA         float64
+}
+
+type PickListRevision struct {
+	Revision  int
+	Author    string
+	CreatedAt string
+}
+
+type AllianceSelectionPageData struct {
+	Events        map[string]string
+	SelectedEvent string
+	OurTeam       string
+}
+
+type AllianceSelectionBoard struct {
+	EventKey        string
+	OurTeam         string
+	Seq             int // last recorded action, sent back with the next one
+	OnClock         int // alliance number on the clock, 0 when selection is done
+	Round           int
+	Alliances       []SelectionAlliance
+	Remaining       []string // ranking order
+	Declined        []string
+	Recommendations []SelectionRecommendation
+	Predictions     []SelectionPrediction
+}
+
+type SelectionAlliance struct {
+	Number int
+	Teams  []string // captain first
+}
+
+type SelectionRecommendation struct {
+	Team     string
+	Score    float64
+	ListRank int // position on our pick list, 0 if absent
+	Reasons  string
+}
+
+type SelectionPrediction struct {
+	Alliance int
+	Captain  string
+	Team     string
+}
+
+// CompLevelOption is one entry in a match level selector.
+type CompLevelOption struct {
+	Code string // TBA comp_level, e.g. "qm" or "sf"
+	Name string
+}
+
+type ScoutPageData struct {
+	Event          string
+	MatchKey       string
+	MatchLabel     string
+	MatchNum       int
+	ScouterID      string
+	ScouterName    string // set when the scouter is on the event roster
+	Alliance       string
+	Teams          []string
+	TeamDataCounts map[string]int
+	Fields         []ScoutFormField
+	NextURL        string          // empty after the last scheduled match
+	UpcomingURLs   []string        // next matches in play order, precached for offline use
+	PrevResult     *MatchResultRow // the match before this one, once it's scored
+}
+
+// MatchResultRow is a played match's official score. Winner is "red",
+// "blue" or "" for a tie; the details summarize each alliance's breakdown.
+type MatchResultRow struct {
+	Label      string
+	Key        string
+	Red        []string
+	Blue       []string
+	RedScore   int
+	BlueScore  int
+	Winner     string
+	RedDetail  string
+	BlueDetail string
+}
+
+type ResultsPageData struct {
+	Events   map[string]string
+	EventKey string
+	Results  []MatchResultRow
+	Error    string
+}
+
+type RosterPageData struct {
+	Events        map[string]string
+	SelectedEvent string
+}
+
+type RosterBoard struct {
+	EventKey    string
+	Message     string
+	ShiftLength int
+	BreakLength int
+	Scouters    []RosterScouter
+	Matches     []AssignmentRow
+	Coverage    []TeamCoverage // fewest distinct scouters first
+}
+
+type RosterScouter struct {
+	ID       int
+	Name     string
+	Breaks   string
+	Assigned int
+}
+
+type AssignmentRow struct {
+	MatchLabel string
+	Scouted    bool
+	Slots      []AssignmentSlot
+}
+
+type AssignmentSlot struct {
+	Team     string
+	Alliance string // "Red" or "Blue"
+	Scouter  string // empty when nobody is assigned
+}
+
+type TeamCoverage struct {
+	Team     string
+	Scouters int // distinct scouters assigned
+	Matches  int
+}
+
+type ScouterOption struct {
+	ID   int
+	Name string
+}
+
+type LoginPageData struct {
+	Next     string
+	Username string
+	Error    string
+}
+
+type AdminPageData struct {
+	Events       []string
+	Levels       []CompLevelOption
+	Users        []AdminUser
+	Username     string // the signed-in admin
+	LLMFailures  []LLMFailure
+	CustomEvents []CustomEvent
+}
+
+// RestoreSummary describes an uploaded backup awaiting confirmation, or the
+// outcome of restoring it.
+type RestoreSummary struct {
+	Token            string // names the staged upload for the confirm step
+	Submissions      int
+	Events           int
+	LatestSubmission string
+	PickLists        int
+	Users            int
+	Restored         bool
+	Error            string
+}
+
+// CustomEvent is an event whose schedule was uploaded rather than from TBA.
+type CustomEvent struct {
+	Key       string
+	Name      string
+	StartDate string
+	Matches   int
+}
+
+// LLMFailure is an AI generation that errored or never passed validation.
+type LLMFailure struct {
+	Task       string
+	EventKey   string
+	TeamNumber string
+	Attempts   int
+	Error      string
+	Response   string // last model output, if any
+	CreatedAt  string
+}
+
+type AdminUser struct {
+	Username string
+	Role     string
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"vibe-scout/store"
+)
+
+// TBA pushes schedule changes and scores to /webhooks/tba as they happen, so
+// we don't wait out the 10 minute schedule cache. Register the URL and secret
+// at https://www.thebluealliance.com/account and set TBA_WEBHOOK_SECRET.
+
+const (
+	maxWebhookBody        = 1 << 20
+	webhookRefreshTimeout = 30 * time.Second // budget for refetches a webhook triggers
+)
+
+type tbaWebhook struct {
+	MessageType string          `json:"message_type"`
+	MessageData json.RawMessage `json:"message_data"`
+}
+
+func tbaWebhookSecret() string {
+	return os.Getenv("TBA_WEBHOOK_SECRET")
+}
+
+// signTBAWebhook is the X-TBA-HMAC header for body: hex HMAC-SHA256 keyed
+// with the webhook secret.
+func signTBAWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func tbaWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	secret := tbaWebhookSecret()
+	if secret == "" {
+		http.Error(w, "TBA webhooks not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	want := signTBAWebhook(secret, body)
+	if !hmac.Equal([]byte(want), []byte(strings.ToLower(r.Header.Get("X-TBA-HMAC")))) {
+		log.Printf("webhook: rejected TBA message with bad signature from %s", r.RemoteAddr)
+		http.Error(w, "Bad signature", http.StatusUnauthorized)
+		return
+	}
+
+	var msg tbaWebhook
+	if err := json.Unmarshal(body, &msg); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	recordTBAWebhook(msg.MessageType, body)
+
+	if err := handleTBAWebhook(msg); err != nil {
+		log.Printf("webhook: %s: %v", msg.MessageType, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleTBAWebhook applies one message. Anything that needs TBA again runs in
+// the background; TBA expects a quick answer.
+func handleTBAWebhook(msg tbaWebhook) error {
+	var data struct {
+		EventKey        string   `json:"event_key"`
+		MatchKey        string   `json:"match_key"`
+		TeamKeys        []string `json:"team_keys"`
+		Match           *Match   `json:"match"`
+		VerificationKey string   `json:"verification_key"`
+		Desc            string   `json:"desc"`
+	}
+	if len(msg.MessageData) > 0 {
+		if err := json.Unmarshal(msg.MessageData, &data); err != nil {
+			return fmt.Errorf("invalid message_data: %w", err)
+		}
+	}
+
+	switch msg.MessageType {
+	case "verification":
+		log.Printf("webhook: TBA verification key %s — enter it on your TBA account page", data.VerificationKey)
+	case "ping":
+		log.Printf("webhook: TBA ping: %s", data.Desc)
+
+	case "schedule_updated":
+		if data.EventKey == "" {
+			return errors.New("event_key required")
+		}
+		go refreshSchedule(data.EventKey)
+
+	case "match_score":
+		if data.Match == nil || data.Match.Key == "" {
+			return errors.New("match required")
+		}
+		eventKey := data.EventKey
+		if eventKey == "" {
+			eventKey, _, _ = strings.Cut(data.Match.Key, "_")
+		}
+		m := *data.Match
+		if result, ok := matchResultFromTBA(eventKey, m); ok {
+			if err := repo.Results.Upsert(context.Background(), []store.MatchResult{result}); err != nil {
+				log.Printf("webhook: %v", err)
+			}
+		}
+		m.ScoreBreakdown = nil // the cached schedule is /simple, which has none
+		applyMatchUpdate(eventKey, m)
+		// A result moves the rankings and the teams' EPA, and makes the
+		// cached full match list stale.
+		invalidateMatchResults(eventKey)
+		invalidateRankings(eventKey)
+		forgetEPA(stripFRC(append(data.Match.Alliances.Red.TeamKeys, data.Match.Alliances.Blue.TeamKeys...)))
+
+	case "upcoming_match":
+		// team_keys lists red then blue; it's the latest word on who plays.
+		if data.EventKey == "" || data.MatchKey == "" || len(data.TeamKeys) == 0 || len(data.TeamKeys)%2 != 0 {
+			return errors.New("event_key, match_key and team_keys required")
+		}
+		id, err := parseMatchKey(data.MatchKey)
+		if err != nil {
+			return err
+		}
+		m := Match{Key: data.MatchKey, CompLevel: id.CompLevel, SetNumber: id.SetNumber, MatchNumber: id.MatchNumber}
+		half := len(data.TeamKeys) / 2
+		m.Alliances.Red.TeamKeys = data.TeamKeys[:half]
+		m.Alliances.Blue.TeamKeys = data.TeamKeys[half:]
+		m.Alliances.Red.Score, m.Alliances.Blue.Score = -1, -1 // not played yet
+		applyMatchUpdate(data.EventKey, m)
+
+	case "alliance_selection":
+		// Alliances decide the playoff schedule.
+		if data.EventKey == "" {
+			return errors.New("event_key required")
+		}
+		invalidateRankings(data.EventKey)
+		go refreshSchedule(data.EventKey)
+
+	default:
+		log.Printf("webhook: ignoring TBA %s message", msg.MessageType)
+	}
+	return nil
+}
+
+// applyMatchUpdate stores m in the cached schedule and drops match plans made
+// for the old alliances.
+func applyMatchUpdate(eventKey string, m Match) {
+	if updateCachedMatch(eventKey, m) {
+		log.Printf("webhook: %s alliances changed", m.Key)
+		forgetMatchPlans(eventKey, m.Key)
+	}
+}
+
+// refreshSchedule refetches eventKey's schedule and drops match plans for any
+// match whose alliances changed.
+func refreshSchedule(eventKey string) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookRefreshTimeout)
+	defer cancel()
+
+	before := map[string]Match{}
+	if old, err := getMatchesCached(ctx, eventKey); err == nil {
+		for _, m := range old {
+			before[m.Key] = m
+		}
+	}
+	invalidateMatches(eventKey)
+	matches, err := getMatchesCached(ctx, eventKey)
+	if err != nil {
+		log.Printf("webhook: refreshing %s schedule: %v", eventKey, err)
+		return
+	}
+	changed := 0
+	for _, m := range matches {
+		old, ok := before[m.Key]
+		if ok && !sameAlliances(old, m) {
+			forgetMatchPlans(eventKey, m.Key)
+			changed++
+		}
+	}
+	log.Printf("webhook: refreshed %s schedule, %d matches, %d changed", eventKey, len(matches), changed)
+}
+
+func forgetMatchPlans(eventKey, matchKey string) {
+	if err := repo.MatchPlans.DeleteMatch(context.Background(), eventKey, matchKey); err != nil {
+		log.Printf("webhook: %v", err)
+	}
+}
+
+// recordTBAWebhook saves verified payloads to TBA_WEBHOOK_RECORD_DIR, if set,
+// for replaying later with `vibe-scout replay-webhooks`.
+func recordTBAWebhook(messageType string, body []byte) {
+	dir := os.Getenv("TBA_WEBHOOK_RECORD_DIR")
+	if dir == "" {
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("webhook: record: %v", err)
+		return
+	}
+	name := fmt.Sprintf("%s-%s.json", time.Now().UTC().Format("20060102T150405.000000000"), messageType)
+	if err := os.WriteFile(filepath.Join(dir, name), body, 0o644); err != nil {
+		log.Printf("webhook: record: %v", err)
+	}
+}
+
+// replayWebhooks posts recorded payloads to a running server, signed with
+// TBA_WEBHOOK_SECRET, in file name order:
+//
+//	vibe-scout replay-webhooks [-url http://localhost:8080/webhooks/tba] [-delay 1s] file.json|dir ...
+func replayWebhooks(args []string) error {
+	fs := flag.NewFlagSet("replay-webhooks", flag.ExitOnError)
+	url := fs.String("url", "http://localhost:8080/webhooks/tba", "webhook endpoint")
+	delay := fs.Duration("delay", 0, "pause between messages")
+	fs.Parse(args)
+
+	secret := tbaWebhookSecret()
+	if secret == "" {
+		return errors.New("TBA_WEBHOOK_SECRET not set")
+	}
+
+	var files []string
+	for _, arg := range fs.Args() {
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			matches, _ := filepath.Glob(filepath.Join(arg, "*.json"))
+			sort.Strings(matches)
+			files = append(files, matches...)
+		} else {
+			files = append(files, arg)
+		}
+	}
+	if len(files) == 0 {
+		return errors.New("usage: vibe-scout replay-webhooks [-url URL] [-delay D] file.json|dir ...")
+	}
+
+	for i, file := range files {
+		if i > 0 {
+			time.Sleep(*delay)
+		}
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodPost, *url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-TBA-HMAC", signTBAWebhook(secret, body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		msg, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("%s: %s %s\n", file, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"vibe-scout/store"
+)
+
+const testWebhookSecret = "s3cret"
+
+func postTBAWebhook(body, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/tba", strings.NewReader(body))
+	if signature != "" {
+		req.Header.Set("X-TBA-HMAC", signature)
+	}
+	w := httptest.NewRecorder()
+	tbaWebhookHandler(w, req)
+	return w
+}
+
+func TestTBAWebhookSignature(t *testing.T) {
+	t.Setenv("TBA_WEBHOOK_SECRET", testWebhookSecret)
+	recordDir := t.TempDir()
+	t.Setenv("TBA_WEBHOOK_RECORD_DIR", recordDir)
+
+	ping := `{"message_type": "ping", "message_data": {"desc": "hello"}}`
+	verification := `{"message_type": "verification", "message_data": {"verification_key": "abc123"}}`
+	for _, tc := range []struct {
+		name      string
+		body      string
+		signature string
+		want      int
+	}{
+		{name: "no signature", body: ping, want: http.StatusUnauthorized},
+		{name: "wrong secret", body: ping, signature: signTBAWebhook("guess", []byte(ping)), want: http.StatusUnauthorized},
+		{name: "signed for another body", body: ping, signature: signTBAWebhook(testWebhookSecret, []byte(verification)), want: http.StatusUnauthorized},
+		{name: "ping", body: ping, signature: signTBAWebhook(testWebhookSecret, []byte(ping)), want: http.StatusOK},
+		{name: "uppercase hex", body: ping, signature: strings.ToUpper(signTBAWebhook(testWebhookSecret, []byte(ping))), want: http.StatusOK},
+		{name: "verification", body: verification, signature: signTBAWebhook(testWebhookSecret, []byte(verification)), want: http.StatusOK},
+		{name: "signed but not JSON", body: "nope", signature: signTBAWebhook(testWebhookSecret, []byte("nope")), want: http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := postTBAWebhook(tc.body, tc.signature); w.Code != tc.want {
+				t.Errorf("got %d %s, want %d", w.Code, strings.TrimSpace(w.Body.String()), tc.want)
+			}
+		})
+	}
+
+	// Only verified messages are recorded for replay.
+	recorded, err := filepath.Glob(filepath.Join(recordDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 3 {
+		t.Errorf("recorded %d messages, want the 3 verified ones: %v", len(recorded), recorded)
+	}
+}
+
+func TestTBAWebhookUnconfigured(t *testing.T) {
+	t.Setenv("TBA_WEBHOOK_SECRET", "")
+	if w := postTBAWebhook(`{"message_type": "ping"}`, signTBAWebhook("", []byte(`{"message_type": "ping"}`))); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d, want 503", w.Code)
+	}
+}
+
+// TestReplayWebhooks replays the recorded messages in testdata/webhooks
+// through replay-webhooks, which signs them, to the real handler.
+func TestReplayWebhooks(t *testing.T) {
+	useTestDB(t)
+	t.Setenv("TBA_WEBHOOK_SECRET", testWebhookSecret)
+	t.Setenv("TBA_WEBHOOK_RECORD_DIR", "")
+	ctx := context.Background()
+
+	rankingsURL := TBA_BASE + tbaRankingsPath("2026cmp")
+	if err := repo.APICache.Put(ctx, rankingsURL, store.CachedResponse{Body: []byte(`{"rankings": []}`), FetchedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(tbaWebhookHandler))
+	defer srv.Close()
+	if err := replayWebhooks([]string{"-url", srv.URL, "testdata/webhooks"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := repo.Results.Get(ctx, "2026cmp_qm7")
+	if err != nil {
+		t.Fatalf("match_score wasn't stored: %v", err)
+	}
+	if r.EventKey != "2026cmp" || r.RedScore != 112 || r.BlueScore != 98 || r.WinningAlliance != "red" ||
+		strings.Join(r.RedTeams, " ") != "254 1678 971" || strings.Join(r.BlueTeams, " ") != "118 148 2056" {
+		t.Errorf("stored %+v", r)
+	}
+	if h := breakdownHighlights(r.RedBreakdown); h != "auto 30, teleop 70, fouls 12, 3 RP" {
+		t.Errorf("red breakdown: %q", h)
+	}
+	if !r.PlayedAt.Equal(time.Unix(1773599400, 0)) {
+		t.Errorf("played at %v", r.PlayedAt)
+	}
+
+	// A result moves the rankings, so the cached copy must be revalidated.
+	cached, err := repo.APICache.Get(ctx, rankingsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cached.Expired {
+		t.Error("rankings still fresh after a match_score")
+	}
+}